@@ -0,0 +1,147 @@
+// Package afip contiene los proveedores de facturación electrónica.
+// El resto del sistema sólo conoce la interfaz Provider; la implementación
+// real (WSFE) o la local (Fake) se elige con la variable AFIP_PROVIDER.
+package afip
+
+import (
+	"errors"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Tipos de comprobante según la tabla de AFIP
+const (
+	FacturaA = 1
+	FacturaB = 6
+	FacturaC = 11
+
+	NotaCreditoA = 3
+	NotaCreditoB = 8
+	NotaCreditoC = 13
+)
+
+// Tipos de documento del receptor
+const (
+	DocCUIT            = 80
+	DocConsumidorFinal = 99
+)
+
+// Ids de alícuota de IVA
+const (
+	AlicuotaIVA105 = 4
+	AlicuotaIVA21  = 5
+)
+
+// Condición frente al IVA del receptor (RG 5616)
+const (
+	ReceptorResponsableInscripto = 1
+	ReceptorExento               = 4
+	ReceptorConsumidorFinal      = 5
+	ReceptorMonotributo          = 6
+)
+
+// AlicuotaIVA es la base imponible y el IVA de una alícuota
+type AlicuotaIVA struct {
+	ID      int
+	BaseImp float64
+	Importe float64
+}
+
+// Comprobante son los datos que se mandan a autorizar
+type Comprobante struct {
+	PuntoVenta      int
+	TipoComprobante int
+	DocTipo         int
+	DocNro          int64
+	CondicionIVA    int
+	Fecha           time.Time
+	ImporteNeto     float64
+	ImporteExento   float64
+	ImporteIVA      float64
+	ImporteTotal    float64
+	Alicuotas       []AlicuotaIVA
+	// Asociados son los comprobantes que anula o ajusta una nota de crédito
+	Asociados []ComprobanteAsociado
+}
+
+// ComprobanteAsociado identifica la factura a la que refiere una nota de crédito
+type ComprobanteAsociado struct {
+	TipoComprobante int
+	PuntoVenta      int
+	Numero          int
+	CUIT            int64
+	Fecha           time.Time
+}
+
+// Resultado es lo que devuelve AFIP al aprobar un comprobante
+type Resultado struct {
+	Numero         int
+	CAE            string
+	VencimientoCAE time.Time
+}
+
+// Emitido es un comprobante ya autorizado, tal como lo informa AFIP
+type Emitido struct {
+	Numero         int
+	Fecha          time.Time
+	DocTipo        int
+	DocNro         int64
+	ImporteTotal   float64
+	CAE            string
+	VencimientoCAE time.Time
+}
+
+// Coincide indica si lo emitido es el comprobante c: mismo total, fecha y documento
+func (e Emitido) Coincide(c Comprobante) bool {
+	return math.Abs(e.ImporteTotal-c.ImporteTotal) < 0.005 &&
+		e.Fecha.Format("20060102") == c.Fecha.Format("20060102") &&
+		e.DocTipo == c.DocTipo && e.DocNro == c.DocNro
+}
+
+// Provider autoriza comprobantes y devuelve el CAE. UltimoAutorizado y
+// Consultar permiten averiguar si un intento sin respuesta llegó a autorizarse.
+type Provider interface {
+	Nombre() string
+	Autorizar(c Comprobante) (Resultado, error)
+	UltimoAutorizado(puntoVenta, tipo int) (int, error)
+	Consultar(puntoVenta, tipo, numero int) (Emitido, error)
+}
+
+// ErrRechazo indica que AFIP rechazó el comprobante: no tiene sentido reintentar
+// sin corregir los datos. Cualquier otro error se considera transitorio.
+type ErrRechazo struct {
+	Mensajes []string
+}
+
+func (e *ErrRechazo) Error() string {
+	return "comprobante rechazado: " + strings.Join(e.Mensajes, "; ")
+}
+
+// EsRechazo indica si el error es un rechazo definitivo
+func EsRechazo(err error) bool {
+	var r *ErrRechazo
+	return errors.As(err, &r)
+}
+
+// NuevoProvider arma el proveedor configurado en el entorno
+func NuevoProvider() Provider {
+	switch os.Getenv("AFIP_PROVIDER") {
+	case "wsfe":
+		cuit, _ := strconv.ParseInt(os.Getenv("AFIP_CUIT"), 10, 64)
+		url := os.Getenv("AFIP_WSFE_URL")
+		if url == "" {
+			url = URLHomologacion
+		}
+		return &WSFE{
+			URL:          url,
+			CUIT:         cuit,
+			Credenciales: CredencialesEntorno{},
+		}
+	default:
+		fallas, _ := strconv.Atoi(os.Getenv("AFIP_FAKE_FALLAS"))
+		return NuevoFake(fallas)
+	}
+}
@@ -0,0 +1,79 @@
+package afip
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sync"
+)
+
+// Fake simula WSFE localmente: numera por punto de venta y tipo de comprobante
+// y genera un CAE determinístico. Sirve para desarrollar y probar sin AFIP.
+type Fake struct {
+	mu       sync.Mutex
+	ultimos  map[string]int
+	emitidos map[string]Emitido
+	// fallas es la cantidad de llamadas que van a fallar antes de responder bien,
+	// para probar la cola de reintentos
+	fallas int
+}
+
+func NuevoFake(fallas int) *Fake {
+	return &Fake{ultimos: map[string]int{}, emitidos: map[string]Emitido{}, fallas: fallas}
+}
+
+func (f *Fake) Nombre() string {
+	return "fake"
+}
+
+func (f *Fake) Autorizar(c Comprobante) (Resultado, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.fallas > 0 {
+		f.fallas--
+		return Resultado{}, errors.New("AFIP simulado no disponible")
+	}
+	if c.ImporteTotal <= 0 {
+		return Resultado{}, &ErrRechazo{Mensajes: []string{"El importe total debe ser mayor a cero"}}
+	}
+
+	clave := fmt.Sprintf("%d-%d", c.PuntoVenta, c.TipoComprobante)
+	f.ultimos[clave]++
+	numero := f.ultimos[clave]
+
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d-%d-%d-%s", c.PuntoVenta, c.TipoComprobante, numero, c.Fecha.Format("20060102"))
+
+	res := Resultado{
+		Numero:         numero,
+		CAE:            fmt.Sprintf("%014d", h.Sum64()%100000000000000),
+		VencimientoCAE: c.Fecha.AddDate(0, 0, 10),
+	}
+	f.emitidos[fmt.Sprintf("%s-%d", clave, numero)] = Emitido{
+		Numero:         numero,
+		Fecha:          c.Fecha,
+		DocTipo:        c.DocTipo,
+		DocNro:         c.DocNro,
+		ImporteTotal:   c.ImporteTotal,
+		CAE:            res.CAE,
+		VencimientoCAE: res.VencimientoCAE,
+	}
+	return res, nil
+}
+
+func (f *Fake) UltimoAutorizado(puntoVenta, tipo int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.ultimos[fmt.Sprintf("%d-%d", puntoVenta, tipo)], nil
+}
+
+func (f *Fake) Consultar(puntoVenta, tipo, numero int) (Emitido, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	e, ok := f.emitidos[fmt.Sprintf("%d-%d-%d", puntoVenta, tipo, numero)]
+	if !ok {
+		return e, fmt.Errorf("el comprobante %d no existe", numero)
+	}
+	return e, nil
+}
@@ -0,0 +1,45 @@
+package afip
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const urlQR = "https://www.afip.gob.ar/fe/qr/?p="
+
+// DatosQR son los campos que exige la RG 4291 para el código QR del comprobante
+type DatosQR struct {
+	Fecha           time.Time
+	CUITEmisor      int64
+	PuntoVenta      int
+	TipoComprobante int
+	Numero          int
+	Importe         float64
+	DocTipo         int
+	DocNro          int64
+	CAE             string
+}
+
+// URLQR arma la URL que va codificada en el QR impreso en la factura
+func URLQR(d DatosQR) string {
+	cae, _ := strconv.ParseInt(d.CAE, 10, 64)
+	payload := map[string]interface{}{
+		"ver":        1,
+		"fecha":      d.Fecha.Format("2006-01-02"),
+		"cuit":       d.CUITEmisor,
+		"ptoVta":     d.PuntoVenta,
+		"tipoCmp":    d.TipoComprobante,
+		"nroCmp":     d.Numero,
+		"importe":    d.Importe,
+		"moneda":     "PES",
+		"ctz":        1,
+		"tipoDocRec": d.DocTipo,
+		"nroDocRec":  d.DocNro,
+		"tipoCodAut": "E",
+		"codAut":     cae,
+	}
+	data, _ := json.Marshal(payload)
+	return urlQR + base64.StdEncoding.EncodeToString(data)
+}
@@ -0,0 +1,392 @@
+package afip
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	URLHomologacion = "https://wswhomo.afip.gov.ar/wsfev1/service.asmx"
+	URLProduccion   = "https://servicios1.afip.gov.ar/wsfev1/service.asmx"
+
+	namespaceWSFE = "http://ar.gov.afip.dif.FEV1/"
+)
+
+// Credenciales es el ticket de acceso (TA) que entrega el WSAA
+type Credenciales struct {
+	Token string
+	Sign  string
+}
+
+// FuenteCredenciales obtiene un TA vigente para el servicio wsfe
+type FuenteCredenciales interface {
+	Credenciales() (Credenciales, error)
+}
+
+// CredencialesEntorno lee el token y el sign de AFIP_TOKEN y AFIP_SIGN.
+// El TA se obtiene del WSAA con el certificado del contribuyente y dura 12 horas.
+type CredencialesEntorno struct{}
+
+func (CredencialesEntorno) Credenciales() (Credenciales, error) {
+	cred := Credenciales{Token: os.Getenv("AFIP_TOKEN"), Sign: os.Getenv("AFIP_SIGN")}
+	if cred.Token == "" || cred.Sign == "" {
+		return cred, errors.New("faltan AFIP_TOKEN / AFIP_SIGN")
+	}
+	return cred, nil
+}
+
+// WSFE es el cliente SOAP del web service de factura electrónica de AFIP
+type WSFE struct {
+	URL          string
+	CUIT         int64
+	Credenciales FuenteCredenciales
+	Client       *http.Client
+}
+
+func (w *WSFE) Nombre() string {
+	return "wsfe"
+}
+
+// ============================================
+// MENSAJES SOAP
+// ============================================
+
+type auth struct {
+	Token string `xml:"Token"`
+	Sign  string `xml:"Sign"`
+	Cuit  int64  `xml:"Cuit"`
+}
+
+type feCompUltimoAutorizado struct {
+	XMLName  xml.Name `xml:"http://ar.gov.afip.dif.FEV1/ FECompUltimoAutorizado"`
+	Auth     auth     `xml:"Auth"`
+	PtoVta   int      `xml:"PtoVta"`
+	CbteTipo int      `xml:"CbteTipo"`
+}
+
+// importe se serializa siempre con dos decimales (xml usaría notación exponencial)
+type importe float64
+
+func (i importe) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%.2f", float64(i))), nil
+}
+
+type alicIva struct {
+	Id      int     `xml:"Id"`
+	BaseImp importe `xml:"BaseImp"`
+	Importe importe `xml:"Importe"`
+}
+
+type cbteAsoc struct {
+	Tipo    int    `xml:"Tipo"`
+	PtoVta  int    `xml:"PtoVta"`
+	Nro     int    `xml:"Nro"`
+	Cuit    string `xml:"Cuit,omitempty"`
+	CbteFch string `xml:"CbteFch,omitempty"`
+}
+
+type feCAEDetRequest struct {
+	Concepto               int        `xml:"Concepto"`
+	DocTipo                int        `xml:"DocTipo"`
+	DocNro                 int64      `xml:"DocNro"`
+	CbteDesde              int        `xml:"CbteDesde"`
+	CbteHasta              int        `xml:"CbteHasta"`
+	CbteFch                string     `xml:"CbteFch"`
+	ImpTotal               importe    `xml:"ImpTotal"`
+	ImpTotConc             importe    `xml:"ImpTotConc"`
+	ImpNeto                importe    `xml:"ImpNeto"`
+	ImpOpEx                importe    `xml:"ImpOpEx"`
+	ImpTrib                importe    `xml:"ImpTrib"`
+	ImpIVA                 importe    `xml:"ImpIVA"`
+	MonId                  string     `xml:"MonId"`
+	MonCotiz               float64    `xml:"MonCotiz"`
+	CondicionIVAReceptorId int        `xml:"CondicionIVAReceptorId"`
+	CbtesAsoc              []cbteAsoc `xml:"CbtesAsoc>CbteAsoc,omitempty"`
+	Iva                    []alicIva  `xml:"Iva>AlicIva,omitempty"`
+}
+
+type feCAESolicitar struct {
+	XMLName  xml.Name `xml:"http://ar.gov.afip.dif.FEV1/ FECAESolicitar"`
+	Auth     auth     `xml:"Auth"`
+	FeCAEReq struct {
+		FeCabReq struct {
+			CantReg  int `xml:"CantReg"`
+			PtoVta   int `xml:"PtoVta"`
+			CbteTipo int `xml:"CbteTipo"`
+		} `xml:"FeCabReq"`
+		FeDetReq []feCAEDetRequest `xml:"FeDetReq>FECAEDetRequest"`
+	} `xml:"FeCAEReq"`
+}
+
+type envelope struct {
+	XMLName xml.Name `xml:"soap:Envelope"`
+	Soap    string   `xml:"xmlns:soap,attr"`
+	Body    struct {
+		Contenido interface{}
+	} `xml:"soap:Body"`
+}
+
+type wsfeError struct {
+	Code int    `xml:"Code"`
+	Msg  string `xml:"Msg"`
+}
+
+type feCompConsultar struct {
+	XMLName       xml.Name `xml:"http://ar.gov.afip.dif.FEV1/ FECompConsultar"`
+	Auth          auth     `xml:"Auth"`
+	FeCompConsReq struct {
+		CbteTipo int `xml:"CbteTipo"`
+		CbteNro  int `xml:"CbteNro"`
+		PtoVta   int `xml:"PtoVta"`
+	} `xml:"FeCompConsReq"`
+}
+
+type compConsultarResponse struct {
+	Body struct {
+		Resp struct {
+			Result struct {
+				ResultGet struct {
+					DocTipo         int     `xml:"DocTipo"`
+					DocNro          int64   `xml:"DocNro"`
+					CbteDesde       int     `xml:"CbteDesde"`
+					CbteFch         string  `xml:"CbteFch"`
+					ImpTotal        float64 `xml:"ImpTotal"`
+					Resultado       string  `xml:"Resultado"`
+					CodAutorizacion string  `xml:"CodAutorizacion"`
+					FchVto          string  `xml:"FchVto"`
+				} `xml:"ResultGet"`
+				Errors []wsfeError `xml:"Errors>Err"`
+			} `xml:"FECompConsultarResult"`
+		} `xml:"FECompConsultarResponse"`
+	} `xml:"Body"`
+}
+
+type ultimoAutorizadoResponse struct {
+	Body struct {
+		Resp struct {
+			Result struct {
+				CbteNro int         `xml:"CbteNro"`
+				Errors  []wsfeError `xml:"Errors>Err"`
+			} `xml:"FECompUltimoAutorizadoResult"`
+		} `xml:"FECompUltimoAutorizadoResponse"`
+	} `xml:"Body"`
+}
+
+type caeSolicitarResponse struct {
+	Body struct {
+		Resp struct {
+			Result struct {
+				FeCabResp struct {
+					Resultado string `xml:"Resultado"`
+				} `xml:"FeCabResp"`
+				FeDetResp []struct {
+					Resultado     string      `xml:"Resultado"`
+					CbteDesde     int         `xml:"CbteDesde"`
+					CAE           string      `xml:"CAE"`
+					CAEFchVto     string      `xml:"CAEFchVto"`
+					Observaciones []wsfeError `xml:"Observaciones>Obs"`
+				} `xml:"FeDetResp>FECAEDetResponse"`
+				Errors []wsfeError `xml:"Errors>Err"`
+			} `xml:"FECAESolicitarResult"`
+		} `xml:"FECAESolicitarResponse"`
+	} `xml:"Body"`
+}
+
+func mensajes(errs []wsfeError) []string {
+	var out []string
+	for _, e := range errs {
+		out = append(out, fmt.Sprintf("%d: %s", e.Code, e.Msg))
+	}
+	return out
+}
+
+// llamar envía la operación SOAP y decodifica la respuesta en resp
+func (w *WSFE) llamar(operacion string, req interface{}, resp interface{}) error {
+	env := envelope{Soap: "http://schemas.xmlsoap.org/soap/envelope/"}
+	env.Body.Contenido = req
+
+	body, err := xml.Marshal(env)
+	if err != nil {
+		return err
+	}
+
+	httpReq, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(append([]byte(xml.Header), body...)))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "text/xml; charset=utf-8")
+	httpReq.Header.Set("SOAPAction", namespaceWSFE+operacion)
+
+	client := w.Client
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	httpResp, err := client.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return err
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("wsfe %s: HTTP %d", operacion, httpResp.StatusCode)
+	}
+	return xml.Unmarshal(data, resp)
+}
+
+func (w *WSFE) auth() (auth, error) {
+	cred, err := w.Credenciales.Credenciales()
+	if err != nil {
+		return auth{}, err
+	}
+	return auth{Token: cred.Token, Sign: cred.Sign, Cuit: w.CUIT}, nil
+}
+
+// UltimoAutorizado devuelve el último número autorizado para el punto de venta y tipo
+func (w *WSFE) UltimoAutorizado(puntoVenta, tipo int) (int, error) {
+	a, err := w.auth()
+	if err != nil {
+		return 0, err
+	}
+
+	var resp ultimoAutorizadoResponse
+	req := feCompUltimoAutorizado{Auth: a, PtoVta: puntoVenta, CbteTipo: tipo}
+	if err := w.llamar("FECompUltimoAutorizado", req, &resp); err != nil {
+		return 0, err
+	}
+	result := resp.Body.Resp.Result
+	if len(result.Errors) > 0 {
+		return 0, errors.New(strings.Join(mensajes(result.Errors), "; "))
+	}
+	return result.CbteNro, nil
+}
+
+// Consultar devuelve los datos con que AFIP autorizó un comprobante
+func (w *WSFE) Consultar(puntoVenta, tipo, numero int) (Emitido, error) {
+	a, err := w.auth()
+	if err != nil {
+		return Emitido{}, err
+	}
+
+	var resp compConsultarResponse
+	req := feCompConsultar{Auth: a}
+	req.FeCompConsReq.CbteTipo = tipo
+	req.FeCompConsReq.CbteNro = numero
+	req.FeCompConsReq.PtoVta = puntoVenta
+	if err := w.llamar("FECompConsultar", req, &resp); err != nil {
+		return Emitido{}, err
+	}
+	result := resp.Body.Resp.Result
+	if len(result.Errors) > 0 {
+		return Emitido{}, errors.New(strings.Join(mensajes(result.Errors), "; "))
+	}
+
+	get := result.ResultGet
+	fecha, err := time.ParseInLocation("20060102", get.CbteFch, time.Local)
+	if err != nil {
+		return Emitido{}, fmt.Errorf("wsfe: fecha de comprobante inválida %q", get.CbteFch)
+	}
+	vto, err := time.ParseInLocation("20060102", get.FchVto, time.Local)
+	if err != nil {
+		return Emitido{}, fmt.Errorf("wsfe: vencimiento de CAE inválido %q", get.FchVto)
+	}
+	return Emitido{
+		Numero:         get.CbteDesde,
+		Fecha:          fecha,
+		DocTipo:        get.DocTipo,
+		DocNro:         get.DocNro,
+		ImporteTotal:   get.ImpTotal,
+		CAE:            get.CodAutorizacion,
+		VencimientoCAE: vto,
+	}, nil
+}
+
+func (w *WSFE) Autorizar(c Comprobante) (Resultado, error) {
+	a, err := w.auth()
+	if err != nil {
+		return Resultado{}, err
+	}
+
+	ultimo, err := w.UltimoAutorizado(c.PuntoVenta, c.TipoComprobante)
+	if err != nil {
+		return Resultado{}, err
+	}
+	numero := ultimo + 1
+
+	det := feCAEDetRequest{
+		Concepto:               1, // productos
+		DocTipo:                c.DocTipo,
+		DocNro:                 c.DocNro,
+		CbteDesde:              numero,
+		CbteHasta:              numero,
+		CbteFch:                c.Fecha.Format("20060102"),
+		ImpTotal:               importe(c.ImporteTotal),
+		ImpNeto:                importe(c.ImporteNeto),
+		ImpOpEx:                importe(c.ImporteExento),
+		ImpIVA:                 importe(c.ImporteIVA),
+		MonId:                  "PES",
+		MonCotiz:               1,
+		CondicionIVAReceptorId: c.CondicionIVA,
+	}
+	for _, as := range c.Asociados {
+		asoc := cbteAsoc{Tipo: as.TipoComprobante, PtoVta: as.PuntoVenta, Nro: as.Numero}
+		if as.CUIT > 0 {
+			asoc.Cuit = strconv.FormatInt(as.CUIT, 10)
+		}
+		if !as.Fecha.IsZero() {
+			asoc.CbteFch = as.Fecha.Format("20060102")
+		}
+		det.CbtesAsoc = append(det.CbtesAsoc, asoc)
+	}
+	for _, al := range c.Alicuotas {
+		det.Iva = append(det.Iva, alicIva{Id: al.ID, BaseImp: importe(al.BaseImp), Importe: importe(al.Importe)})
+	}
+
+	var req feCAESolicitar
+	req.Auth = a
+	req.FeCAEReq.FeCabReq.CantReg = 1
+	req.FeCAEReq.FeCabReq.PtoVta = c.PuntoVenta
+	req.FeCAEReq.FeCabReq.CbteTipo = c.TipoComprobante
+	req.FeCAEReq.FeDetReq = []feCAEDetRequest{det}
+
+	var resp caeSolicitarResponse
+	if err := w.llamar("FECAESolicitar", req, &resp); err != nil {
+		return Resultado{}, err
+	}
+
+	result := resp.Body.Resp.Result
+	if len(result.FeDetResp) == 0 {
+		if len(result.Errors) > 0 {
+			return Resultado{}, &ErrRechazo{Mensajes: mensajes(result.Errors)}
+		}
+		return Resultado{}, errors.New("wsfe: respuesta sin detalle")
+	}
+
+	detResp := result.FeDetResp[0]
+	if detResp.Resultado != "A" {
+		msgs := append(mensajes(result.Errors), mensajes(detResp.Observaciones)...)
+		return Resultado{}, &ErrRechazo{Mensajes: msgs}
+	}
+
+	vto, err := time.ParseInLocation("20060102", detResp.CAEFchVto, time.Local)
+	if err != nil {
+		return Resultado{}, fmt.Errorf("wsfe: vencimiento de CAE inválido %q", detResp.CAEFchVto)
+	}
+
+	return Resultado{
+		Numero:         detResp.CbteDesde,
+		CAE:            detResp.CAE,
+		VencimientoCAE: vto,
+	}, nil
+}
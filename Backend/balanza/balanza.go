@@ -0,0 +1,203 @@
+// Package balanza lee y arma las etiquetas EAN-13 de peso o precio variable
+// de la balanza: prefijo 2, PLU del producto, valor y dígito verificador.
+package balanza
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Qué trae la etiqueta en la parte del valor
+const (
+	ValorPeso   = "PESO"
+	ValorPrecio = "PRECIO"
+)
+
+// FormatoPorDefecto: prefijo 2, PLU de 5 dígitos, peso de 6 dígitos en gramos y dígito verificador
+const FormatoPorDefecto = "2PPPPPVVVVVVC"
+
+// Formato describe cómo arma la balanza el código. El patrón tiene 13
+// posiciones: dígitos fijos (el prefijo), P (PLU), V (valor), X (se ignora,
+// por ejemplo un verificador del precio) y C (verificador del EAN, al final).
+type Formato struct {
+	Patron    string
+	Valor     string // PESO o PRECIO
+	Decimales int    // decimales implícitos del valor: 3 = gramos si es PESO, 2 = centavos si es PRECIO
+}
+
+// Etiqueta es lo que se leyó de un código de balanza
+type Etiqueta struct {
+	PLU   string  // sin ceros a la izquierda
+	Valor float64 // kilos si el formato es PESO, pesos si es PRECIO
+}
+
+// FormatoEntorno lee BALANZA_FORMATO, BALANZA_VALOR y BALANZA_DECIMALES
+func FormatoEntorno() (Formato, error) {
+	f := Formato{
+		Patron: strings.ToUpper(strings.TrimSpace(os.Getenv("BALANZA_FORMATO"))),
+		Valor:  strings.ToUpper(strings.TrimSpace(os.Getenv("BALANZA_VALOR"))),
+	}
+	if f.Patron == "" {
+		f.Patron = FormatoPorDefecto
+	}
+	if f.Valor == "" {
+		f.Valor = ValorPeso
+	}
+	f.Decimales = 3
+	if f.Valor == ValorPrecio {
+		f.Decimales = 2
+	}
+	if d := os.Getenv("BALANZA_DECIMALES"); d != "" {
+		n, err := strconv.Atoi(d)
+		if err != nil || n < 0 || n > 5 {
+			return f, fmt.Errorf("BALANZA_DECIMALES inválido: %q", d)
+		}
+		f.Decimales = n
+	}
+	return f, f.Validar()
+}
+
+// Validar controla que el patrón tenga 13 posiciones, PLU, valor y el verificador al final
+func (f Formato) Validar() error {
+	if len(f.Patron) != 13 {
+		return fmt.Errorf("BALANZA_FORMATO debe tener 13 posiciones: %q", f.Patron)
+	}
+	if f.Valor != ValorPeso && f.Valor != ValorPrecio {
+		return fmt.Errorf("BALANZA_VALOR debe ser PESO o PRECIO: %q", f.Valor)
+	}
+	for i, r := range f.Patron {
+		switch {
+		case r == 'C' && i != 12, r != 'C' && i == 12:
+			return fmt.Errorf("BALANZA_FORMATO debe terminar en C (dígito verificador): %q", f.Patron)
+		case r >= '0' && r <= '9', r == 'P', r == 'V', r == 'X', r == 'C':
+		default:
+			return fmt.Errorf("BALANZA_FORMATO sólo admite dígitos, P, V, X y C: %q", f.Patron)
+		}
+	}
+	if f.DigitosPLU() == 0 || !strings.Contains(f.Patron, "V") {
+		return fmt.Errorf("BALANZA_FORMATO debe tener posiciones P y V: %q", f.Patron)
+	}
+	return nil
+}
+
+// DigitosPLU es la cantidad de posiciones del PLU, que limita los PLU que se pueden cargar
+func (f Formato) DigitosPLU() int {
+	return strings.Count(f.Patron, "P")
+}
+
+// Corresponde indica si el código tiene el largo y el prefijo de una etiqueta de balanza
+func (f Formato) Corresponde(codigo string) bool {
+	if len(codigo) != len(f.Patron) || !SoloDigitos(codigo) {
+		return false
+	}
+	for i, r := range f.Patron {
+		if r >= '0' && r <= '9' && codigo[i] != byte(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Decodificar separa el PLU y el valor de una etiqueta. Devuelve error si el
+// código no corresponde al formato o el dígito verificador no coincide.
+func (f Formato) Decodificar(codigo string) (Etiqueta, error) {
+	if !f.Corresponde(codigo) {
+		return Etiqueta{}, fmt.Errorf("el código %s no es una etiqueta de balanza", codigo)
+	}
+	if !VerificadorValido(codigo) {
+		return Etiqueta{}, fmt.Errorf("el dígito verificador de %s no es válido", codigo)
+	}
+	var plu, valor strings.Builder
+	for i, r := range f.Patron {
+		switch r {
+		case 'P':
+			plu.WriteByte(codigo[i])
+		case 'V':
+			valor.WriteByte(codigo[i])
+		}
+	}
+	n, _ := strconv.Atoi(valor.String())
+	return Etiqueta{
+		PLU:   NormalizarPLU(plu.String()),
+		Valor: float64(n) / math.Pow10(f.Decimales),
+	}, nil
+}
+
+// Codificar arma la etiqueta del PLU con el valor (kilos o pesos, según el
+// formato), para imprimirla y que se pueda escanear en la venta
+func (f Formato) Codificar(plu string, valor float64) (string, error) {
+	plu = NormalizarPLU(plu)
+	if len(plu) > f.DigitosPLU() {
+		return "", fmt.Errorf("el PLU %s no entra en la etiqueta (hasta %d dígitos)", plu, f.DigitosPLU())
+	}
+	digitosValor := strings.Count(f.Patron, "V")
+	n := int64(math.Round(valor * math.Pow10(f.Decimales)))
+	v := strconv.FormatInt(n, 10)
+	if n < 0 || len(v) > digitosValor {
+		return "", fmt.Errorf("el valor %v no entra en la etiqueta", valor)
+	}
+	plu = strings.Repeat("0", f.DigitosPLU()-len(plu)) + plu
+	v = strings.Repeat("0", digitosValor-len(v)) + v
+
+	codigo := make([]byte, 0, len(f.Patron))
+	for _, r := range f.Patron[:len(f.Patron)-1] {
+		switch r {
+		case 'P':
+			codigo, plu = append(codigo, plu[0]), plu[1:]
+		case 'V':
+			codigo, v = append(codigo, v[0]), v[1:]
+		case 'X':
+			codigo = append(codigo, '0')
+		default:
+			codigo = append(codigo, byte(r))
+		}
+	}
+	return string(codigo) + strconv.Itoa(DigitoVerificador(string(codigo))), nil
+}
+
+// NormalizarPLU quita los ceros a la izquierda: 00042 y 42 son el mismo PLU
+func NormalizarPLU(plu string) string {
+	plu = strings.TrimLeft(strings.TrimSpace(plu), "0")
+	if plu == "" {
+		return "0"
+	}
+	return plu
+}
+
+// SoloDigitos indica si el texto no está vacío y tiene sólo dígitos
+func SoloDigitos(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// VerificadorValido controla el último dígito de un código GTIN (EAN-8, UPC-A, EAN-13 o GTIN-14)
+func VerificadorValido(codigo string) bool {
+	if len(codigo) < 8 || !SoloDigitos(codigo) {
+		return false
+	}
+	return DigitoVerificador(codigo[:len(codigo)-1]) == int(codigo[len(codigo)-1]-'0')
+}
+
+// DigitoVerificador calcula el dígito que va al final de los dígitos de un GTIN
+func DigitoVerificador(digitos string) int {
+	suma := 0
+	for i := len(digitos) - 1; i >= 0; i-- {
+		d := int(digitos[i] - '0')
+		// Desde la derecha, las posiciones impares pesan 3
+		if (len(digitos)-1-i)%2 == 0 {
+			d *= 3
+		}
+		suma += d
+	}
+	return (10 - suma%10) % 10
+}
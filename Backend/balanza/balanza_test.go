@@ -0,0 +1,125 @@
+package balanza
+
+import "testing"
+
+func TestVerificadorValido(t *testing.T) {
+	casos := []struct {
+		codigo string
+		want   bool
+	}{
+		{"4006381333931", true},  // EAN-13
+		{"5901234123457", true},  // EAN-13
+		{"7790001000010", false}, // verificador cambiado
+		{"96385074", true},       // EAN-8
+		{"036000291452", true},   // UPC-A
+		{"10036000291459", true}, // GTIN-14
+		{"0360002914", false},    // muy corto para un GTIN sin relleno
+		{"1234567", false},       // menos de 8 dígitos
+		{"400638133393A", false}, // no numérico
+		{"", false},
+	}
+	for _, c := range casos {
+		if got := VerificadorValido(c.codigo); got != c.want {
+			t.Errorf("VerificadorValido(%q) = %v, want %v", c.codigo, got, c.want)
+		}
+	}
+}
+
+func TestDigitoVerificador(t *testing.T) {
+	casos := []struct {
+		digitos string
+		want    int
+	}{
+		{"400638133393", 1},
+		{"590123412345", 7},
+		{"200042001234", 6},
+		{"9638507", 4},
+		{"000000000000", 0},
+	}
+	for _, c := range casos {
+		if got := DigitoVerificador(c.digitos); got != c.want {
+			t.Errorf("DigitoVerificador(%q) = %d, want %d", c.digitos, got, c.want)
+		}
+	}
+}
+
+func TestCodificarDecodificar(t *testing.T) {
+	peso := Formato{Patron: FormatoPorDefecto, Valor: ValorPeso, Decimales: 3}
+	precio := Formato{Patron: "2PPPPXVVVVVVC", Valor: ValorPrecio, Decimales: 2}
+	casos := []struct {
+		formato Formato
+		plu     string
+		valor   float64
+		codigo  string
+	}{
+		{peso, "42", 1.234, "2000420012346"},
+		{peso, "00042", 0.45, "2000420004501"},
+		{peso, "99999", 999.999, "2999999999991"},
+		{precio, "7", 1530.5, "2000701530507"},
+		{precio, "1234", 0.01, "2123400000017"},
+	}
+	for _, c := range casos {
+		codigo, err := c.formato.Codificar(c.plu, c.valor)
+		if err != nil {
+			t.Fatalf("Codificar(%s, %v): %v", c.plu, c.valor, err)
+		}
+		if codigo != c.codigo {
+			t.Errorf("Codificar(%s, %v) = %s, want %s", c.plu, c.valor, codigo, c.codigo)
+		}
+		if !VerificadorValido(codigo) {
+			t.Errorf("Codificar(%s, %v) = %s: verificador inválido", c.plu, c.valor, codigo)
+		}
+		etiqueta, err := c.formato.Decodificar(codigo)
+		if err != nil {
+			t.Fatalf("Decodificar(%s): %v", codigo, err)
+		}
+		if etiqueta.PLU != NormalizarPLU(c.plu) || etiqueta.Valor != c.valor {
+			t.Errorf("Decodificar(%s) = %+v, want PLU %s valor %v", codigo, etiqueta, NormalizarPLU(c.plu), c.valor)
+		}
+	}
+}
+
+func TestCodificarFueraDeRango(t *testing.T) {
+	f := Formato{Patron: FormatoPorDefecto, Valor: ValorPeso, Decimales: 3}
+	if _, err := f.Codificar("123456", 1); err == nil {
+		t.Error("un PLU de 6 dígitos no debería entrar en 5 posiciones")
+	}
+	if _, err := f.Codificar("1", 1000); err == nil {
+		t.Error("1000 kg no debería entrar en 6 dígitos de gramos")
+	}
+	if _, err := f.Codificar("1", -1); err == nil {
+		t.Error("un peso negativo no debería codificarse")
+	}
+}
+
+func TestDecodificarRechaza(t *testing.T) {
+	f := Formato{Patron: FormatoPorDefecto, Valor: ValorPeso, Decimales: 3}
+	for _, codigo := range []string{
+		"2000420012340", // verificador incorrecto
+		"4006381333931", // EAN de producto, no de balanza
+		"200042001234",  // largo incorrecto
+	} {
+		if _, err := f.Decodificar(codigo); err == nil {
+			t.Errorf("Decodificar(%s) debería fallar", codigo)
+		}
+	}
+}
+
+func TestValidar(t *testing.T) {
+	casos := []struct {
+		formato Formato
+		ok      bool
+	}{
+		{Formato{Patron: FormatoPorDefecto, Valor: ValorPeso}, true},
+		{Formato{Patron: "2PPPPXVVVVVVC", Valor: ValorPrecio}, true},
+		{Formato{Patron: "2PPPPPVVVVVV", Valor: ValorPeso}, false},  // 12 posiciones
+		{Formato{Patron: "2PPPPPVVVVVVV", Valor: ValorPeso}, false}, // sin verificador
+		{Formato{Patron: "2PPPPPPPPPPPC", Valor: ValorPeso}, false}, // sin valor
+		{Formato{Patron: "2PPPPPVVVVVVC", Valor: "KILOS"}, false},
+	}
+	for _, c := range casos {
+		if err := c.formato.Validar(); (err == nil) != c.ok {
+			t.Errorf("Validar(%+v) = %v, want ok=%v", c.formato, err, c.ok)
+		}
+	}
+}
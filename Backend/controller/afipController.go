@@ -0,0 +1,424 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"sanJoseProyect/afip"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+var (
+	providerAFIP     afip.Provider
+	providerAFIPOnce sync.Once
+
+	// colaAFIP serializa las autorizaciones: AFIP exige numeración correlativa
+	colaAFIP sync.Mutex
+)
+
+// proveedorAFIP se inicializa recién cuando se usa, después de cargar el .env
+func proveedorAFIP() afip.Provider {
+	providerAFIPOnce.Do(func() {
+		providerAFIP = afip.NuevoProvider()
+		log.Println("✅ Facturación electrónica con proveedor:", providerAFIP.Nombre())
+	})
+	return providerAFIP
+}
+
+func tipoComprobanteAFIP(tipo, letra string) int {
+	if tipo == models.NotaCredito {
+		switch letra {
+		case "A":
+			return afip.NotaCreditoA
+		case "B":
+			return afip.NotaCreditoB
+		default:
+			return afip.NotaCreditoC
+		}
+	}
+	switch letra {
+	case "A":
+		return afip.FacturaA
+	case "B":
+		return afip.FacturaB
+	default:
+		return afip.FacturaC
+	}
+}
+
+func condicionReceptorAFIP(condicion string) int {
+	switch condicion {
+	case models.CondicionResponsableInscripto:
+		return afip.ReceptorResponsableInscripto
+	case models.CondicionMonotributo:
+		return afip.ReceptorMonotributo
+	case models.CondicionExento:
+		return afip.ReceptorExento
+	default:
+		return afip.ReceptorConsumidorFinal
+	}
+}
+
+// soloDigitos limpia guiones y espacios de un CUIT
+func soloDigitos(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// comprobanteAFIP arma el pedido de autorización a partir de la factura con sus
+// líneas, cliente y, si es una nota de crédito, la factura asociada
+func comprobanteAFIP(f *models.Invoice) (afip.Comprobante, error) {
+	comp := afip.Comprobante{
+		PuntoVenta:      f.PuntoVenta,
+		TipoComprobante: tipoComprobanteAFIP(f.Tipo, f.Letra),
+		DocTipo:         afip.DocConsumidorFinal,
+		CondicionIVA:    condicionReceptorAFIP(f.CondicionIVA),
+		Fecha:           f.Fecha.Time,
+		ImporteTotal:    f.Total,
+	}
+
+	if f.Cliente != nil {
+		if cuit := soloDigitos(f.Cliente.CUIT); cuit != "" {
+			comp.DocTipo = afip.DocCUIT
+			comp.DocNro, _ = strconv.ParseInt(cuit, 10, 64)
+		}
+	}
+	if f.Letra == "A" && comp.DocTipo != afip.DocCUIT {
+		return comp, &afip.ErrRechazo{Mensajes: []string{"El cliente de una factura A debe tener CUIT"}}
+	}
+
+	if asoc := f.FacturaAsociada; asoc != nil {
+		if asoc.CAE == "" {
+			// Se reintenta cuando la factura original esté autorizada
+			return comp, errors.New("la factura asociada todavía no tiene CAE")
+		}
+		cuit, _ := strconv.ParseInt(soloDigitos(os.Getenv("AFIP_CUIT")), 10, 64)
+		comp.Asociados = append(comp.Asociados, afip.ComprobanteAsociado{
+			TipoComprobante: tipoComprobanteAFIP(asoc.Tipo, asoc.Letra),
+			PuntoVenta:      asoc.PuntoVenta,
+			Numero:          asoc.NumeroComprobante,
+			CUIT:            cuit,
+			Fecha:           asoc.Fecha.Time,
+		})
+	}
+
+	// En las facturas C no se discrimina IVA: todo es neto
+	if f.Letra == "C" {
+		comp.ImporteNeto = f.Total
+		return comp, nil
+	}
+
+	comp.ImporteNeto = f.NetoGravado
+	comp.ImporteExento = f.Exento
+	comp.ImporteIVA = models.Round2(f.IVA105 + f.IVA21)
+
+	bases := map[string]float64{}
+	for _, l := range f.Lineas {
+		if l.AlicuotaIVA != models.IVAExento {
+			bases[l.AlicuotaIVA] += l.Neto
+		}
+	}
+	if base, ok := bases[models.IVA21]; ok {
+		comp.Alicuotas = append(comp.Alicuotas, afip.AlicuotaIVA{ID: afip.AlicuotaIVA21, BaseImp: models.Round2(base), Importe: f.IVA21})
+	}
+	if base, ok := bases[models.IVA105]; ok {
+		comp.Alicuotas = append(comp.Alicuotas, afip.AlicuotaIVA{ID: afip.AlicuotaIVA105, BaseImp: models.Round2(base), Importe: f.IVA105})
+	}
+	return comp, nil
+}
+
+// esperaReintento crece exponencialmente con los intentos, hasta una hora
+func esperaReintento(intentos int) time.Duration {
+	espera := time.Minute
+	for i := 1; i < intentos && espera < time.Hour; i++ {
+		espera *= 2
+	}
+	if espera > time.Hour {
+		espera = time.Hour
+	}
+	return espera
+}
+
+// consultasAFIP limita cuántos números hacia atrás se revisan antes de reintentar
+const consultasAFIP = 10
+
+// autorizacionPrevia averigua si un intento anterior de la factura llegó a
+// autorizarse aunque no se recibió la respuesta (por ejemplo, por un timeout):
+// revisa los últimos números autorizados en AFIP que no tiene ninguna factura
+// y toma el que coincide en total, fecha y documento. Sin esto el reintento
+// pediría el número siguiente y el comprobante quedaría emitido dos veces.
+func autorizacionPrevia(f *models.Invoice, comp afip.Comprobante) (afip.Resultado, bool, error) {
+	proveedor := proveedorAFIP()
+	ultimo, err := proveedor.UltimoAutorizado(comp.PuntoVenta, comp.TipoComprobante)
+	if err != nil {
+		return afip.Resultado{}, false, err
+	}
+	var registrado int
+	if err := database.DB.Model(&models.Invoice{}).
+		Select("COALESCE(MAX(numero_comprobante), 0)").
+		Where("tipo = ? AND letra = ? AND punto_venta = ? AND estado_afip = ?",
+			f.Tipo, f.Letra, f.PuntoVenta, models.AFIPAutorizada).
+		Scan(&registrado).Error; err != nil {
+		return afip.Resultado{}, false, err
+	}
+
+	for n := ultimo; n > registrado && n > ultimo-consultasAFIP; n-- {
+		emitido, err := proveedor.Consultar(comp.PuntoVenta, comp.TipoComprobante, n)
+		if err != nil {
+			return afip.Resultado{}, false, err
+		}
+		if emitido.Coincide(comp) {
+			log.Printf("✅ Factura %d ya estaba autorizada en AFIP con el número %d", f.ID, n)
+			return afip.Resultado{Numero: n, CAE: emitido.CAE, VencimientoCAE: emitido.VencimientoCAE}, true, nil
+		}
+	}
+	return afip.Resultado{}, false, nil
+}
+
+// cambiosAutorizada completa los datos que se guardan cuando AFIP autoriza la factura
+func cambiosAutorizada(cambios map[string]interface{}, res afip.Resultado) {
+	vto := models.CustomDate{Time: res.VencimientoCAE}
+	cambios["estado_afip"] = models.AFIPAutorizada
+	cambios["cae"] = res.CAE
+	cambios["cae_vencimiento"] = &vto
+	cambios["numero_comprobante"] = res.Numero
+	cambios["afip_error"] = ""
+	cambios["afip_proximo_intento"] = nil
+}
+
+// pasarANumeroFiscal deja los movimientos de la factura con el número que
+// asignó AFIP: se cargaron con el de la numeración local, que puede no
+// coincidir (por ejemplo, si AFIP rechazó o se anuló una factura anterior)
+func pasarANumeroFiscal(tx *gorm.DB, f *models.Invoice, numero int) error {
+	if numero == f.Numero {
+		return nil
+	}
+	copia := *f
+	copia.NumeroComprobante = 0
+	anterior := nombreComprobante(&copia)
+	copia.NumeroComprobante = numero
+	nuevo := nombreComprobante(&copia)
+
+	var ids []uint
+	for _, l := range f.Lineas {
+		for _, id := range []*uint{l.MovimientoID, l.MovimientoMermaID} {
+			if id != nil {
+				ids = append(ids, *id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return tx.Model(&models.Movement{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+		"numero_factura": numero,
+		"descripcion":    gorm.Expr("REPLACE(descripcion, ?, ?)", anterior, nuevo),
+	}).Error
+}
+
+// guardarResultadoAFIP guarda el intento y, si se autorizó, pasa los
+// movimientos al número fiscal. Sólo escribe sobre una factura activa y
+// pendiente: si otra operación la cambió mientras se esperaba a AFIP, falla
+// en lugar de pisarla.
+func guardarResultadoAFIP(f *models.Invoice, cambios map[string]interface{}, res afip.Resultado, autorizada bool) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		resultado := tx.Model(f).Where("estado = ? AND estado_afip = ?", true, models.AFIPPendiente).Updates(cambios)
+		if resultado.Error != nil {
+			return resultado.Error
+		}
+		if resultado.RowsAffected == 0 {
+			if autorizada {
+				log.Printf("🚨 Factura %d autorizada por AFIP (CAE %s, número %d) pero ya no estaba pendiente: revísela",
+					f.ID, res.CAE, res.Numero)
+			}
+			return fmt.Errorf("la factura %d ya no está pendiente de AFIP: no se guardó el resultado", f.ID)
+		}
+		if !autorizada {
+			return nil
+		}
+		return pasarANumeroFiscal(tx, f, res.Numero)
+	})
+}
+
+// verificarPendienteAFIP se llama antes de anular una factura pendiente: si un
+// intento anterior quedó autorizado en AFIP sin que llegara la respuesta, guarda
+// el CAE, y la anulación se rechaza porque la factura ya tiene CAE. Se llama con
+// colaAFIP tomada, que no se suelta hasta terminar la anulación.
+func verificarPendienteAFIP(id string) error {
+	var factura models.Invoice
+	if err := database.DB.Preload("Cliente").Preload("Lineas").Preload("FacturaAsociada").
+		First(&factura, id).Error; err != nil {
+		return nil // la anulación responde que no existe
+	}
+	if !factura.Estado || factura.EstadoAFIP != models.AFIPPendiente || factura.AFIPIntentos == 0 {
+		return nil
+	}
+	comp, err := comprobanteAFIP(&factura)
+	if err != nil {
+		return nil // nunca se pudo mandar a AFIP
+	}
+	res, autorizada, err := autorizacionPrevia(&factura, comp)
+	if err != nil {
+		return errorHTTP(503, "No se pudo confirmar con AFIP que la factura no esté autorizada: "+err.Error())
+	}
+	if !autorizada {
+		return nil
+	}
+	cambios := map[string]interface{}{}
+	cambiosAutorizada(cambios, res)
+	if err := guardarResultadoAFIP(&factura, cambios, res, true); err != nil {
+		return errorHTTP(500, "Error guardando la autorización de AFIP")
+	}
+	return nil
+}
+
+// autorizarFactura pide el CAE de una factura pendiente y guarda el resultado.
+// Si AFIP no responde la factura queda en la cola para reintentar más tarde;
+// antes de cada reintento se controla que el intento anterior no haya quedado
+// autorizado.
+func autorizarFactura(id uint) error {
+	colaAFIP.Lock()
+	defer colaAFIP.Unlock()
+
+	var factura models.Invoice
+	if err := database.DB.Preload("Cliente").Preload("Lineas").Preload("FacturaAsociada").
+		First(&factura, id).Error; err != nil {
+		return err
+	}
+	if !factura.Estado || factura.EstadoAFIP != models.AFIPPendiente {
+		return nil
+	}
+
+	comp, err := comprobanteAFIP(&factura)
+	var res afip.Resultado
+	autorizada := false
+	if err == nil && factura.AFIPIntentos > 0 {
+		res, autorizada, err = autorizacionPrevia(&factura, comp)
+	}
+	if err == nil && !autorizada {
+		res, err = proveedorAFIP().Autorizar(comp)
+	}
+
+	cambios := map[string]interface{}{"afip_intentos": factura.AFIPIntentos + 1}
+	switch {
+	case err == nil:
+		cambiosAutorizada(cambios, res)
+	case afip.EsRechazo(err):
+		cambios["estado_afip"] = models.AFIPRechazada
+		cambios["afip_error"] = err.Error()
+		cambios["afip_proximo_intento"] = nil
+	default:
+		proximo := time.Now().Add(esperaReintento(factura.AFIPIntentos + 1))
+		cambios["afip_error"] = err.Error()
+		cambios["afip_proximo_intento"] = &proximo
+		log.Printf("⚠️  Factura %d sin CAE, se reintenta a las %s: %v", factura.ID, proximo.Format("15:04"), err)
+	}
+
+	if errDB := guardarResultadoAFIP(&factura, cambios, res, err == nil); errDB != nil {
+		return errDB
+	}
+	return err
+}
+
+// procesarColaAFIP reintenta las facturas pendientes cuyo próximo intento ya venció
+func procesarColaAFIP() {
+	var ids []uint
+	database.DB.Model(&models.Invoice{}).
+		Where("tipo IN ? AND estado = ? AND estado_afip = ?", models.ComprobantesElectronicos, true, models.AFIPPendiente).
+		Where("afip_proximo_intento IS NULL OR afip_proximo_intento <= ?", time.Now()).
+		Order("id").
+		Pluck("id", &ids)
+
+	for _, id := range ids {
+		if err := autorizarFactura(id); err != nil && !afip.EsRechazo(err) {
+			// Si AFIP sigue caído no tiene sentido probar con el resto ahora
+			return
+		}
+	}
+}
+
+// IniciarColaAFIP lanza el proceso que reintenta las autorizaciones fallidas
+func IniciarColaAFIP(intervalo time.Duration) {
+	go func() {
+		ticker := time.NewTicker(intervalo)
+		defer ticker.Stop()
+		for range ticker.C {
+			procesarColaAFIP()
+		}
+	}()
+}
+
+// ============================================
+// FACTURA ELECTRÓNICA
+// ============================================
+
+// AuthorizeInvoice fuerza un nuevo intento de autorización de una factura sin CAE
+func AuthorizeInvoice(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var factura models.Invoice
+	if err := database.DB.First(&factura, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Factura no encontrada"})
+	}
+	if !factura.EsElectronica() || !factura.Estado {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Sólo se autorizan facturas de venta y notas de crédito activas"})
+	}
+	if factura.EstadoAFIP == models.AFIPAutorizada {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "La factura ya tiene CAE"})
+	}
+
+	database.DB.Model(&factura).Updates(map[string]interface{}{
+		"estado_afip":          models.AFIPPendiente,
+		"afip_proximo_intento": nil,
+	})
+
+	err := autorizarFactura(factura.ID)
+	database.DB.First(&factura, factura.ID)
+	if err != nil {
+		status := fiber.StatusServiceUnavailable
+		if afip.EsRechazo(err) {
+			status = fiber.StatusUnprocessableEntity
+		}
+		return c.Status(status).JSON(fiber.Map{
+			"error":   "No se pudo autorizar la factura: " + err.Error(),
+			"factura": factura,
+		})
+	}
+
+	return c.JSON(factura)
+}
+
+// GetPendingAFIPInvoices lista la cola de facturas sin CAE
+func GetPendingAFIPInvoices(c *fiber.Ctx) error {
+	var facturas []models.Invoice
+	if err := database.DB.Preload("Cliente").
+		Where("tipo IN ? AND estado = ? AND estado_afip IN ?", models.ComprobantesElectronicos, true,
+			[]string{models.AFIPPendiente, models.AFIPRechazada}).
+		Order("id").
+		Find(&facturas).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo facturas"})
+	}
+	return c.JSON(facturas)
+}
+
+// solicitarCAE se llama después de crear una factura de venta; si falla queda en la cola
+func solicitarCAE(factura *models.Invoice) {
+	if err := autorizarFactura(factura.ID); err != nil {
+		log.Printf("⚠️  Factura %d no autorizada: %v", factura.ID, err)
+	}
+	database.DB.First(factura, factura.ID)
+}
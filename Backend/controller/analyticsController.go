@@ -0,0 +1,256 @@
+package controller
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+	"sanJoseProyect/pronostico"
+)
+
+// rangoFechas lee fecha_inicio y fecha_fin (obligatorias)
+func rangoFechas(c *fiber.Ctx) (time.Time, time.Time, error) {
+	desde, err := parseLocalDate(c.Query("fecha_inicio"))
+	if err != nil {
+		return desde, desde, errorHTTP(400, "fecha_inicio inválida")
+	}
+	hasta, err := parseLocalDate(c.Query("fecha_fin"))
+	if err != nil {
+		return desde, hasta, errorHTTP(400, "fecha_fin inválida")
+	}
+	if hasta.Before(desde) {
+		return desde, hasta, errorHTTP(400, "fecha_fin anterior a fecha_inicio")
+	}
+	return desde, hasta, nil
+}
+
+// ventasPorProducto suma las SALIDAS activas del período por producto
+func ventasPorProducto(desde, hasta time.Time) (map[uint]float64, error) {
+	var totales []struct {
+		ProductoID uint
+		Cantidad   float64
+	}
+	if err := database.DB.Model(&models.Movement{}).
+		Select("producto_id, SUM(-cantidad) AS cantidad").
+		Where("tipo = ? AND estado = ? AND fecha BETWEEN ? AND ?", models.MovimientoSalida, true,
+			desde.Format("2006-01-02"), hasta.Format("2006-01-02")).
+		Group("producto_id").Scan(&totales).Error; err != nil {
+		return nil, err
+	}
+	ventas := map[uint]float64{}
+	for _, t := range totales {
+		ventas[t.ProductoID] = t.Cantidad
+	}
+	return ventas, nil
+}
+
+// ventasTotales suma las SALIDAS activas del período, de un producto o de todos
+func ventasTotales(desde, hasta time.Time, productoID string) (float64, error) {
+	var total float64
+	query := database.DB.Model(&models.Movement{}).
+		Select("COALESCE(SUM(-cantidad), 0)").
+		Where("tipo = ? AND estado = ? AND fecha BETWEEN ? AND ?", models.MovimientoSalida, true,
+			desde.Format("2006-01-02"), hasta.Format("2006-01-02"))
+	if productoID != "" {
+		query = query.Where("producto_id = ?", productoID)
+	}
+	err := query.Scan(&total).Error
+	return total, err
+}
+
+// variacion devuelve el cambio porcentual, o nil si no hay base para comparar
+func variacion(actual, anterior float64) *float64 {
+	if anterior == 0 {
+		return nil
+	}
+	v := models.Round2((actual - anterior) * 100 / anterior)
+	return &v
+}
+
+// ============================================
+// ANÁLISIS DE VENTAS
+// ============================================
+
+// GetTopProducts devuelve los productos más vendidos (SALIDAS) del período.
+// Los kilos no se comparan con unidades ni cajas: el ranking es por unidad de
+// stock, con hasta limite productos en cada una (o sólo la de ?unidad=).
+func GetTopProducts(c *fiber.Ctx) error {
+	desde, hasta, err := rangoFechas(c)
+	if err != nil {
+		return responderError(c, err)
+	}
+	limite, err := strconv.Atoi(c.Query("limite", "10"))
+	if err != nil || limite <= 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "limite inválido"})
+	}
+
+	ventas := database.DB.Table("movimientos m").
+		Select("p.id AS producto_id, p.codigo, p.descripcion, p.tipo_cantidad AS unidad, "+
+			"SUM(-m.cantidad) AS cantidad, COUNT(*) AS tickets, "+
+			"ROW_NUMBER() OVER (PARTITION BY p.tipo_cantidad ORDER BY SUM(-m.cantidad) DESC, p.descripcion) AS puesto").
+		Joins("JOIN productos p ON p.id = m.producto_id").
+		Where("m.tipo = ? AND m.estado = ? AND m.fecha BETWEEN ? AND ?", models.MovimientoSalida, true,
+			desde.Format("2006-01-02"), hasta.Format("2006-01-02")).
+		Group("p.id, p.codigo, p.descripcion, p.tipo_cantidad")
+	if unidad := models.NormalizarUnidad(c.Query("unidad")); unidad != "" {
+		if !models.TipoCantidadValido(unidad) {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "unidad inválida (unidades, cajas o kg)"})
+		}
+		ventas = ventas.Where("p.tipo_cantidad = ?", unidad)
+	}
+
+	filas := []models.TopProductRow{}
+	if err := database.DB.Table("(?) AS v", ventas).
+		Where("puesto <= ?", limite).
+		Order("unidad, puesto").
+		Scan(&filas).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando las ventas"})
+	}
+	return c.JSON(filas)
+}
+
+// GetStockRotation calcula la rotación (vendido / stock promedio del período)
+// y los días que cubre el stock disponible al ritmo de venta del período
+func GetStockRotation(c *fiber.Ctx) error {
+	desde, hasta, err := rangoFechas(c)
+	if err != nil {
+		return responderError(c, err)
+	}
+	dias := hasta.Sub(desde).Hours()/24 + 1
+
+	var productos []models.Product
+	if err := database.DB.Order("codigo").Find(&productos).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error al obtener productos"})
+	}
+	ventas, err := ventasPorProducto(desde, hasta)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando las ventas"})
+	}
+	inicial, err := movimientosAntesDe(desde)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando el stock"})
+	}
+	final, err := movimientosAntesDe(hasta.AddDate(0, 0, 1))
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando el stock"})
+	}
+
+	filas := make([]models.RotationRow, 0, len(productos))
+	for _, p := range productos {
+		fila := models.RotationRow{
+			ProductoID:    p.ID,
+			Codigo:        p.Codigo,
+			Descripcion:   p.Descripcion,
+			Unidad:        p.TipoCantidad,
+			Vendido:       ventas[p.ID],
+			StockInicial:  models.Round3(p.StockInicial + inicial[p.ID]),
+			StockFinal:    models.Round3(p.StockInicial + final[p.ID]),
+			Disponible:    p.Disponible,
+			DiasCobertura: -1,
+		}
+		fila.StockPromedio = models.Round2((fila.StockInicial + fila.StockFinal) / 2)
+		if fila.StockPromedio > 0 {
+			fila.Rotacion = models.Round2(fila.Vendido / fila.StockPromedio)
+		}
+		diaria := fila.Vendido / dias
+		fila.VentaDiaria = models.Round2(diaria)
+		if diaria > 0 {
+			fila.DiasCobertura = models.Round2(fila.Disponible / diaria)
+		}
+		filas = append(filas, fila)
+	}
+	return c.JSON(filas)
+}
+
+// GetWeeklySales agrupa las ventas por semana (de lunes a domingo) y compara
+// cada una con la semana anterior y con la misma semana del año anterior
+func GetWeeklySales(c *fiber.Ctx) error {
+	desde, hasta, err := rangoFechas(c)
+	if err != nil {
+		return responderError(c, err)
+	}
+	// Lunes de la semana de inicio
+	lunes := desde.AddDate(0, 0, -((int(desde.Weekday()) + 6) % 7))
+	// Hace falta un año más de historia para las comparaciones
+	inicio := lunes.AddDate(0, 0, -7*52)
+
+	var semanas []struct {
+		Semana   time.Time
+		Cantidad float64
+	}
+	query := database.DB.Model(&models.Movement{}).
+		Select("date_trunc('week', fecha)::date AS semana, SUM(-cantidad) AS cantidad").
+		Where("tipo = ? AND estado = ? AND fecha BETWEEN ? AND ?", models.MovimientoSalida, true,
+			inicio.Format("2006-01-02"), hasta.Format("2006-01-02"))
+	if productoID := c.Query("producto_id"); productoID != "" {
+		query = query.Where("producto_id = ?", productoID)
+	}
+	if err := query.Group("semana").Scan(&semanas).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando las ventas"})
+	}
+	porSemana := map[string]float64{}
+	for _, s := range semanas {
+		porSemana[s.Semana.Format("2006-01-02")] = s.Cantidad
+	}
+
+	filas := []models.WeeklySalesRow{}
+	for s := lunes; !s.After(hasta); s = s.AddDate(0, 0, 7) {
+		fila := models.WeeklySalesRow{
+			Semana:         models.CustomDate{Time: s},
+			Cantidad:       porSemana[s.Format("2006-01-02")],
+			SemanaAnterior: porSemana[s.AddDate(0, 0, -7).Format("2006-01-02")],
+			AnioAnterior:   porSemana[s.AddDate(0, 0, -7*52).Format("2006-01-02")],
+		}
+		fila.VariacionSemanal = variacion(fila.Cantidad, fila.SemanaAnterior)
+		fila.VariacionInteranual = variacion(fila.Cantidad, fila.AnioAnterior)
+		filas = append(filas, fila)
+	}
+	return c.JSON(filas)
+}
+
+// GetSeasonality compara, para cada año, la venta diaria de Semana Santa
+// (Domingo de Ramos al Sábado Santo) y de las Fiestas (15 al 31 de diciembre)
+// con la de las cuatro semanas anteriores
+func GetSeasonality(c *fiber.Ctx) error {
+	hasta, err := strconv.Atoi(c.Query("anio", strconv.Itoa(time.Now().Year())))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "anio inválido"})
+	}
+	anios, err := strconv.Atoi(c.Query("anios", "3"))
+	if err != nil || anios <= 0 || anios > 20 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "anios inválido"})
+	}
+	productoID := c.Query("producto_id")
+
+	filas := []models.SeasonRow{}
+	for anio := hasta - anios + 1; anio <= hasta; anio++ {
+		for _, temporada := range []string{pronostico.SemanaSanta, pronostico.Fiestas} {
+			ini, fin := pronostico.RangoTemporada(temporada, anio)
+			t := models.SeasonRow{
+				Temporada: temporada,
+				Anio:      anio,
+				Desde:     models.CustomDate{Time: ini},
+				Hasta:     models.CustomDate{Time: fin},
+			}
+			cantidad, err := ventasTotales(t.Desde.Time, t.Hasta.Time, productoID)
+			if err != nil {
+				return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando las ventas"})
+			}
+			base, err := ventasTotales(t.Desde.AddDate(0, 0, -28), t.Desde.AddDate(0, 0, -1), productoID)
+			if err != nil {
+				return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando las ventas"})
+			}
+			dias := t.Hasta.Sub(t.Desde.Time).Hours()/24 + 1
+			t.Cantidad = cantidad
+			t.VentaDiaria = models.Round2(cantidad / dias)
+			t.VentaDiariaBase = models.Round2(base / 28)
+			if base > 0 {
+				t.Indice = models.Round2(cantidad / dias / (base / 28))
+			}
+			filas = append(filas, t)
+		}
+	}
+	return c.JSON(filas)
+}
@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// referenciasProducto son las tablas del historial que apuntan a un producto
+// por producto_id: con alguna fila el producto no se puede borrar
+var referenciasProducto = []struct{ Tabla, Nombre string }{
+	{"movimientos", "movimientos"},
+	{"pedido_lineas", "líneas de pedidos"},
+	{"remito_lineas", "líneas de remitos"},
+	{"factura_lineas", "líneas de facturas"},
+	{"orden_compra_lineas", "líneas de órdenes de compra"},
+	{"recepcion_lineas", "líneas de recepciones"},
+	{"transformacion_insumos", "insumos de transformaciones"},
+	{"transformacion_productos", "productos de transformaciones"},
+}
+
+// historialProducto cuenta las filas de cada tabla del historial que usan el producto
+func historialProducto(tx *gorm.DB, productoID uint) ([]models.ProductReference, error) {
+	var usos []models.ProductReference
+	for _, r := range referenciasProducto {
+		var n int64
+		if err := tx.Table(r.Tabla).Where("producto_id = ?", productoID).Count(&n).Error; err != nil {
+			return nil, err
+		}
+		if n > 0 {
+			usos = append(usos, models.ProductReference{Tabla: r.Tabla, Nombre: r.Nombre, Cantidad: n})
+		}
+	}
+	return usos, nil
+}
+
+// productoActivo rechaza operar con un producto archivado
+func productoActivo(producto *models.Product) error {
+	if producto.Archivado {
+		return errorHTTP(400, fmt.Sprintf("El producto %d - %s está archivado: restáurelo para operar con él",
+			producto.Codigo, producto.Descripcion))
+	}
+	return nil
+}
+
+// cambiarArchivado archiva o restaura el producto y lo deja en la auditoría
+func cambiarArchivado(c *fiber.Ctx, archivar bool) error {
+	id := c.Params("id")
+	req := new(models.ArchiveProductRequest)
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(req); err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+		}
+	}
+
+	var producto models.Product
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&producto, id).Error; err != nil {
+			return errorHTTP(404, "Producto no encontrado")
+		}
+		if producto.Archivado == archivar {
+			if archivar {
+				return errorHTTP(400, "El producto ya está archivado")
+			}
+			return errorHTTP(400, "El producto no está archivado")
+		}
+		// Lo reservado se tiene que entregar o liberar antes: después no se podría mover
+		if archivar && producto.Reservado > 0 {
+			return errorHTTP(400, fmt.Sprintf("El producto tiene %v reservados en pedidos sin entregar", producto.Reservado))
+		}
+
+		accion := models.AuditoriaProductoRestaurado
+		producto.Archivado, producto.ArchivadoEn = archivar, nil
+		if archivar {
+			ahora := time.Now()
+			producto.ArchivadoEn = &ahora
+			accion = models.AuditoriaProductoArchivado
+		}
+		if err := tx.Model(&producto).Select("archivado", "archivado_en").Updates(&producto).Error; err != nil {
+			return errorHTTP(500, "Error al actualizar el producto")
+		}
+		return auditar(tx, c, accion, "producto", producto.ID, req.Motivo, fiber.Map{
+			"codigo":      producto.Codigo,
+			"descripcion": producto.Descripcion,
+			"stock":       producto.Stock,
+		})
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+	return c.JSON(producto)
+}
+
+// ============================================
+// ARCHIVO DE PRODUCTOS
+// ============================================
+
+// ArchiveProduct deja de ofrecer el producto: no aparece en el listado ni en
+// la búsqueda ni en la reposición y no admite movimientos nuevos, pero su
+// historial y los reportes lo siguen mostrando
+func ArchiveProduct(c *fiber.Ctx) error {
+	return cambiarArchivado(c, true)
+}
+
+// RestoreProduct vuelve a poner a la venta un producto archivado
+func RestoreProduct(c *fiber.Ctx) error {
+	return cambiarArchivado(c, false)
+}
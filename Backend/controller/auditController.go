@@ -0,0 +1,73 @@
+package controller
+
+import (
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// auditar registra la operación con el usuario del token. Va dentro de la misma
+// transacción que la operación para que no quede una sin la otra.
+func auditar(tx *gorm.DB, c *fiber.Ctx, accion, entidad string, entidadID uint, motivo string, detalle interface{}) error {
+	registro := models.AuditLog{
+		Accion:    accion,
+		Entidad:   entidad,
+		EntidadID: entidadID,
+		Motivo:    motivo,
+	}
+	if id, ok := c.Locals("user_id").(uint); ok {
+		registro.UsuarioID = id
+	}
+	if email, ok := c.Locals("email").(string); ok {
+		registro.Email = email
+	}
+	if detalle != nil {
+		data, err := json.Marshal(detalle)
+		if err != nil {
+			return err
+		}
+		registro.Detalle = string(data)
+	}
+	return tx.Create(&registro).Error
+}
+
+// ============================================
+// AUDITORÍA
+// ============================================
+
+func GetAuditLogs(c *fiber.Ctx) error {
+	query := database.DB.Order("created_at desc, id desc")
+
+	if accion := c.Query("accion"); accion != "" {
+		query = query.Where("accion = ?", accion)
+	}
+	if entidad := c.Query("entidad"); entidad != "" {
+		query = query.Where("entidad = ?", entidad)
+	}
+	if entidadID := c.Query("entidad_id"); entidadID != "" {
+		query = query.Where("entidad_id = ?", entidadID)
+	}
+	if desde := c.Query("fecha_inicio"); desde != "" {
+		fecha, err := parseLocalDate(desde)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+		}
+		query = query.Where("created_at >= ?", fecha)
+	}
+	if hasta := c.Query("fecha_fin"); hasta != "" {
+		fecha, err := parseLocalDate(hasta)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+		}
+		query = query.Where("created_at < ?", fecha.AddDate(0, 0, 1))
+	}
+
+	var registros []models.AuditLog
+	if err := query.Find(&registros).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo la auditoría"})
+	}
+	return c.JSON(registros)
+}
@@ -0,0 +1,241 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"sanJoseProyect/balanza"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// formatoBalanza lee el formato de etiqueta configurado en el entorno
+func formatoBalanza() (balanza.Formato, error) {
+	formato, err := balanza.FormatoEntorno()
+	if err != nil {
+		log.Println("❌ Formato de balanza:", err)
+		return formato, errorHTTP(500, "El formato de etiqueta de balanza está mal configurado")
+	}
+	return formato, nil
+}
+
+// leerCodigo identifica el producto de un código escaneado: primero los EAN
+// cargados y después las etiquetas de balanza, buscando el producto por PLU.
+// La cantidad de una etiqueta de peso va en kilos con los gramos exactos; si
+// el producto no se lleva en kilos, se convierte al registrar el movimiento.
+func leerCodigo(tx *gorm.DB, codigo string) (models.ScanResult, error) {
+	res := models.ScanResult{Codigo: strings.TrimSpace(codigo)}
+	if !balanza.SoloDigitos(res.Codigo) {
+		return res, errorHTTP(400, "El código debe tener sólo dígitos")
+	}
+	formato, err := formatoBalanza()
+	if err != nil {
+		return res, err
+	}
+
+	var registrado models.ProductBarcode
+	if err := tx.Where("tipo = ? AND codigo = ?", models.CodigoBarraEAN, res.Codigo).First(&registrado).Error; err == nil {
+		if err := tx.First(&res.Producto, registrado.ProductoID).Error; err != nil {
+			return res, errorHTTP(404, "Producto no encontrado")
+		}
+		res.Cantidad = 1
+		return res, nil
+	}
+
+	if !formato.Corresponde(res.Codigo) {
+		if len(res.Codigo) >= 8 && !balanza.VerificadorValido(res.Codigo) {
+			return res, errorHTTP(400, "El dígito verificador del código no es válido: vuelva a escanear")
+		}
+		return res, errorHTTP(404, "Código no registrado: "+res.Codigo)
+	}
+
+	etiqueta, err := formato.Decodificar(res.Codigo)
+	if err != nil {
+		return res, errorHTTP(400, "Etiqueta de balanza inválida: "+err.Error())
+	}
+	res.Balanza = true
+	res.PLU = etiqueta.PLU
+	if err := tx.Where("tipo = ? AND codigo = ?", models.CodigoBarraPLU, etiqueta.PLU).First(&registrado).Error; err != nil {
+		return res, errorHTTP(404, fmt.Sprintf("El PLU %s no está asignado a ningún producto", etiqueta.PLU))
+	}
+	if err := tx.First(&res.Producto, registrado.ProductoID).Error; err != nil {
+		return res, errorHTTP(404, "Producto no encontrado")
+	}
+
+	// Cantidad en unidades de stock, o en kilos si se pesa
+	var cantidad float64
+	enKilos := models.NormalizarUnidad(res.Producto.TipoCantidad) == models.UnidadKg
+	pesado := formato.Valor == balanza.ValorPeso || enKilos
+	if formato.Valor == balanza.ValorPeso {
+		cantidad = etiqueta.Valor
+	} else {
+		importe := etiqueta.Valor
+		res.Importe = &importe
+		if res.Producto.PrecioVenta <= 0 {
+			return res, errorHTTP(400, fmt.Sprintf("%s no tiene precio de venta para calcular la cantidad", res.Producto.Descripcion))
+		}
+		cantidad = importe / res.Producto.PrecioVenta
+	}
+	if pesado {
+		peso := models.Round3(cantidad)
+		res.Peso = &peso
+		res.Cantidad = peso
+		if !enKilos {
+			res.Unidad = models.UnidadKg
+		}
+	} else {
+		res.Cantidad = math.Round(cantidad)
+	}
+	if res.Cantidad <= 0 {
+		return res, errorHTTP(400, "La etiqueta no trae cantidad")
+	}
+	return res, nil
+}
+
+// ============================================
+// CÓDIGOS DE BARRAS Y ESCANEO
+// ============================================
+
+func GetProductBarcodes(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var producto models.Product
+	if err := database.DB.Preload("CodigosBarra").First(&producto, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Producto no encontrado"})
+	}
+	return c.JSON(producto.CodigosBarra)
+}
+
+// SetProductBarcodes reemplaza los códigos del producto. Los EAN deben tener
+// dígito verificador válido y los PLU entrar en las posiciones de la etiqueta.
+func SetProductBarcodes(c *fiber.Ctx) error {
+	id := c.Params("id")
+	req := new(models.SetBarcodesRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+	formato, err := formatoBalanza()
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	var producto models.Product
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&producto, id).Error; err != nil {
+			return errorHTTP(404, "Producto no encontrado")
+		}
+
+		vistos := map[string]bool{}
+		for _, cb := range req.CodigosBarra {
+			tipo := strings.ToUpper(strings.TrimSpace(cb.Tipo))
+			codigo := strings.TrimSpace(cb.Codigo)
+			switch tipo {
+			case models.CodigoBarraEAN:
+				if !balanza.VerificadorValido(codigo) || len(codigo) > 14 {
+					return errorHTTP(400, "EAN inválido: "+codigo)
+				}
+			case models.CodigoBarraPLU:
+				if !balanza.SoloDigitos(codigo) {
+					return errorHTTP(400, "El PLU debe tener sólo dígitos: "+codigo)
+				}
+				codigo = balanza.NormalizarPLU(codigo)
+				if len(codigo) > formato.DigitosPLU() {
+					return errorHTTP(400, fmt.Sprintf("El PLU %s no entra en la etiqueta (hasta %d dígitos)", codigo, formato.DigitosPLU()))
+				}
+			default:
+				return errorHTTP(400, "El tipo debe ser EAN o PLU")
+			}
+			if vistos[tipo+codigo] {
+				return errorHTTP(400, fmt.Sprintf("%s repetido: %s", tipo, codigo))
+			}
+			vistos[tipo+codigo] = true
+
+			var otro models.ProductBarcode
+			if err := tx.Where("tipo = ? AND codigo = ? AND producto_id <> ?", tipo, codigo, producto.ID).
+				First(&otro).Error; err == nil {
+				return errorHTTP(400, fmt.Sprintf("El %s %s ya está asignado al producto %d", tipo, codigo, otro.ProductoID))
+			}
+			producto.CodigosBarra = append(producto.CodigosBarra, models.ProductBarcode{
+				ProductoID: producto.ID,
+				Tipo:       tipo,
+				Codigo:     codigo,
+			})
+		}
+
+		if err := tx.Where("producto_id = ?", producto.ID).Delete(&models.ProductBarcode{}).Error; err != nil {
+			return errorHTTP(500, "Error actualizando códigos de barras")
+		}
+		if len(producto.CodigosBarra) > 0 {
+			if err := tx.Create(&producto.CodigosBarra).Error; err != nil {
+				return errorHTTP(500, "Error actualizando códigos de barras")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.JSON(producto)
+}
+
+// ScanCode devuelve el producto y la cantidad de un código escaneado (?codigo=)
+func ScanCode(c *fiber.Ctx) error {
+	res, err := leerCodigo(database.DB, c.Query("codigo"))
+	if err != nil {
+		return responderError(c, err)
+	}
+	return c.JSON(res)
+}
+
+// CreateScanOutMovement registra una salida con su número de ticket a partir
+// de un código escaneado, con el producto y la cantidad que trae la etiqueta
+func CreateScanOutMovement(c *fiber.Ctx) error {
+	req := new(models.ScanOutMovementRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+
+	fecha, err := parseLocalDate(req.Fecha)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+	}
+	pv, err := puntoVentaValido(req.PuntoVenta)
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	var mov models.Movement
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		escaneo, err := leerCodigo(tx, req.Codigo)
+		if err != nil {
+			return err
+		}
+		numero, err := siguienteNumeroTicket(tx, pv)
+		if err != nil {
+			return err
+		}
+		mov = models.Movement{
+			ProductoID:     escaneo.Producto.ID,
+			NumeroFactura:  numero,
+			PuntoVenta:     pv,
+			Comprobante:    models.ComprobanteTicket,
+			Fecha:          models.CustomDate{Time: fecha},
+			Descripcion:    req.Descripcion,
+			Cantidad:       escaneo.Cantidad,
+			UnidadOriginal: escaneo.Unidad,
+		}
+		if mov.Descripcion == "" {
+			mov.Descripcion = fmt.Sprintf("Ticket %04d-%08d", pv, numero)
+		}
+		return registrarSalida(tx, &mov)
+	}); err != nil {
+		return responderError(c, err)
+	}
+
+	return c.Status(201).JSON(mov)
+}
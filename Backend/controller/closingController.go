@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+	"sanJoseProyect/pdf"
+	"sanJoseProyect/planilla"
+)
+
+// ============================================
+// CIERRE DEL DÍA
+// ============================================
+
+// GetDailyClosing arma el cierre de un día a partir de los movimientos activos
+// con esa fecha. Las anulaciones se toman por la hora en que se hicieron
+// (zona horaria de Buenos Aires), aunque el movimiento sea de otro día.
+// formato=json (por defecto), csv o pdf.
+func GetDailyClosing(c *fiber.Ctx) error {
+	fechaStr := c.Query("fecha")
+	fecha, err := parseLocalDate(fechaStr)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "fecha inválida"})
+	}
+	formato := c.Query("formato", "json")
+	if formato != "json" && formato != "csv" && formato != "pdf" {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "El formato debe ser json, csv o pdf"})
+	}
+	siguiente := fecha.AddDate(0, 0, 1)
+
+	var productos []models.Product
+	if err := database.DB.Order("codigo").Find(&productos).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error al obtener productos"})
+	}
+	anteriores, err := movimientosAntesDe(fecha)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando el stock inicial"})
+	}
+
+	var totales []struct {
+		ProductoID uint
+		Tipo       string
+		Cantidad   float64
+	}
+	if err := database.DB.Model(&models.Movement{}).
+		Select("producto_id, tipo, SUM(cantidad) AS cantidad").
+		Where("estado = ? AND fecha = ?", true, fecha.Format("2006-01-02")).
+		Group("producto_id, tipo").Scan(&totales).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo movimientos"})
+	}
+
+	filas := map[uint]*models.DailyClosingRow{}
+	for _, p := range productos {
+		filas[p.ID] = &models.DailyClosingRow{
+			ProductoID:   p.ID,
+			Codigo:       p.Codigo,
+			Descripcion:  p.Descripcion,
+			Unidad:       p.TipoCantidad,
+			StockInicial: models.Round3(p.StockInicial + anteriores[p.ID]),
+		}
+	}
+	for _, t := range totales {
+		fila, ok := filas[t.ProductoID]
+		if !ok {
+			continue
+		}
+		switch {
+		case t.Tipo == models.MovimientoMerma:
+			fila.Merma = models.Round3(fila.Merma - t.Cantidad)
+		case t.Cantidad > 0:
+			fila.Entradas = models.Round3(fila.Entradas + t.Cantidad)
+		default:
+			fila.Salidas = models.Round3(fila.Salidas - t.Cantidad)
+		}
+	}
+
+	reporte := models.DailyClosingReport{
+		Fecha:       fechaStr,
+		Productos:   []models.DailyClosingRow{},
+		Anulaciones: []models.DailyCancellation{},
+		Ventas:      []models.SalesByPaymentRow{},
+	}
+	for _, p := range productos {
+		fila := filas[p.ID]
+		fila.StockFinal = models.Round3(fila.StockInicial + fila.Entradas - fila.Salidas - fila.Merma)
+		// Sólo los productos con stock o con movimientos en el día
+		if fila.StockInicial == 0 && fila.Entradas == 0 && fila.Salidas == 0 && fila.Merma == 0 {
+			continue
+		}
+		reporte.Productos = append(reporte.Productos, *fila)
+		reporte.Entradas = models.Round3(reporte.Entradas + fila.Entradas)
+		reporte.Salidas = models.Round3(reporte.Salidas + fila.Salidas)
+		reporte.Merma = models.Round3(reporte.Merma + fila.Merma)
+	}
+
+	var anulados []models.Movement
+	if err := database.DB.Preload("Producto").
+		Where("estado = ? AND anulado_en >= ? AND anulado_en < ?", false, fecha, siguiente).
+		Order("anulado_en").Find(&anulados).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo anulaciones"})
+	}
+	for _, m := range anulados {
+		a := models.DailyCancellation{
+			MovimientoID:  m.ID,
+			Fecha:         m.Fecha,
+			ProductoID:    m.ProductoID,
+			Tipo:          m.Tipo,
+			Comprobante:   m.Comprobante,
+			NumeroFactura: m.NumeroFactura,
+			Cantidad:      m.Cantidad,
+		}
+		if m.Producto != nil {
+			a.Descripcion = m.Producto.Descripcion
+		}
+		reporte.Anulaciones = append(reporte.Anulaciones, a)
+	}
+
+	if err := database.DB.Model(&models.Invoice{}).
+		Select("forma_pago, COUNT(*) AS comprobantes, "+
+			"COALESCE(SUM(CASE WHEN tipo = ? THEN -total ELSE total END), 0) AS total", models.NotaCredito).
+		Where("tipo IN ? AND estado = ? AND fecha = ?", models.ComprobantesElectronicos, true, fecha.Format("2006-01-02")).
+		Group("forma_pago").Scan(&reporte.Ventas).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo ventas"})
+	}
+	sort.Slice(reporte.Ventas, func(i, j int) bool { return reporte.Ventas[i].FormaPago < reporte.Ventas[j].FormaPago })
+	for i := range reporte.Ventas {
+		reporte.Ventas[i].Total = models.Round2(reporte.Ventas[i].Total)
+		reporte.TotalVentas += reporte.Ventas[i].Total
+	}
+	reporte.TotalVentas = models.Round2(reporte.TotalVentas)
+
+	switch formato {
+	case "csv":
+		return enviarCSV(c, fmt.Sprintf("cierre-%s.csv", fechaStr), cierreCSV(&reporte))
+	case "pdf":
+		contenido, err := pdf.CierreDiario(&reporte, pdf.EmpresaDesdeEntorno())
+		if err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{Error: "Error generando el PDF"})
+		}
+		return enviarPDF(c, fmt.Sprintf("cierre-%s.pdf", fechaStr), contenido)
+	}
+	return c.JSON(reporte)
+}
+
+// cierreCSV escribe las tres secciones del cierre una debajo de la otra
+func cierreCSV(r *models.DailyClosingReport) [][]string {
+	filas := [][]string{
+		{"Cierre del día", r.Fecha},
+		{},
+		{"Código", "Descripción", "Unidad", "Apertura", "Entradas", "Salidas", "Merma", "Cierre"},
+	}
+	for _, p := range r.Productos {
+		filas = append(filas, []string{
+			strconv.Itoa(p.Codigo), p.Descripcion, p.Unidad,
+			planilla.Texto(p.StockInicial), planilla.Texto(p.Entradas), planilla.Texto(p.Salidas),
+			planilla.Texto(p.Merma), planilla.Texto(p.StockFinal),
+		})
+	}
+	filas = append(filas, []string{"", "TOTAL", "", "", planilla.Texto(r.Entradas), planilla.Texto(r.Salidas), planilla.Texto(r.Merma), ""})
+
+	filas = append(filas, []string{}, []string{"Anulaciones"},
+		[]string{"Movimiento", "Fecha", "Producto", "Tipo", "Comprobante", "Número", "Cantidad"})
+	for _, a := range r.Anulaciones {
+		filas = append(filas, []string{
+			strconv.Itoa(int(a.MovimientoID)), a.Fecha.Format("2006-01-02"), a.Descripcion, a.Tipo,
+			a.Comprobante, strconv.Itoa(a.NumeroFactura), planilla.Texto(a.Cantidad),
+		})
+	}
+
+	filas = append(filas, []string{}, []string{"Ventas por forma de pago"},
+		[]string{"Forma de pago", "Comprobantes", "Total"})
+	for _, v := range r.Ventas {
+		filas = append(filas, []string{v.FormaPago, strconv.Itoa(v.Comprobantes), planilla.Texto(planilla.Decimal(v.Total))})
+	}
+	filas = append(filas, []string{"TOTAL", "", planilla.Texto(planilla.Decimal(r.TotalVentas))})
+	return filas
+}
@@ -0,0 +1,215 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// acreditadoPorLinea es lo que ya devolvieron las notas de crédito activas sobre una línea de factura
+type acreditadoPorLinea struct {
+	FacturaLineaID uint
+	Devuelto       float64
+	Neto           float64
+}
+
+// marcarComprobante vincula el movimiento con la nota de crédito que lo generó
+func marcarComprobante(mov *models.Movement, nota *models.Invoice) {
+	mov.NumeroFactura = nota.Numero
+	mov.PuntoVenta = nota.PuntoVenta
+	mov.Comprobante = models.ClaveComprobante(nota.Tipo, nota.Letra)
+}
+
+// esDeNotaCredito indica si el movimiento lo generó una nota de crédito
+func esDeNotaCredito(mov *models.Movement) bool {
+	return strings.HasPrefix(mov.Comprobante, models.ClaveComprobante(models.NotaCredito, ""))
+}
+
+// ============================================
+// NOTAS DE CRÉDITO
+// ============================================
+
+// CreateCreditNote emite una nota de crédito sobre una factura de venta. Cada
+// línea puede devolver la mercadería al stock (DEVOLUCION), darla de baja
+// (MERMA) o ser sólo un ajuste de precio. El total se descuenta del saldo del cliente.
+func CreateCreditNote(c *fiber.Ctx) error {
+	id := c.Params("id")
+	req := new(models.CreateCreditNoteRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+	if len(req.Lineas) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Indique las líneas a acreditar"})
+	}
+	for _, l := range req.Lineas {
+		if l.Cantidad <= 0 || (l.PrecioUnitario != nil && *l.PrecioUnitario < 0) {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Cantidad o precio inválidos"})
+		}
+		if l.Destino != "" && l.Destino != models.DestinoDevolucion && l.Destino != models.DestinoMerma {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "El destino debe ser DEVOLUCION, MERMA o vacío"})
+		}
+	}
+
+	fecha := fechaHoy()
+	if req.Fecha != "" {
+		var err error
+		if fecha, err = parseLocalDate(req.Fecha); err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+		}
+	}
+
+	var nota models.Invoice
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var factura models.Invoice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&factura, id).Error; err != nil {
+			return errorHTTP(404, "Factura no encontrada")
+		}
+		if factura.Tipo != models.FacturaVenta || !factura.Estado {
+			return errorHTTP(400, "Sólo se emiten notas de crédito sobre facturas de venta activas")
+		}
+		if factura.EstadoAFIP == models.AFIPRechazada {
+			return errorHTTP(400, "La factura fue rechazada por AFIP: anúlela en lugar de emitir una nota de crédito")
+		}
+		if fecha.Before(factura.Fecha.Time) {
+			return errorHTTP(400, "La nota de crédito no puede ser anterior a la factura")
+		}
+		if err := tx.Where("factura_id = ?", factura.ID).Find(&factura.Lineas).Error; err != nil {
+			return err
+		}
+		lineasFactura := map[uint]models.InvoiceLine{}
+		for _, l := range factura.Lineas {
+			lineasFactura[l.ID] = l
+		}
+
+		// Lo ya acreditado por otras notas de crédito no se puede volver a acreditar
+		var previos []acreditadoPorLinea
+		if err := tx.Model(&models.InvoiceLine{}).
+			Select("factura_lineas.factura_linea_id, "+
+				"COALESCE(SUM(CASE WHEN factura_lineas.destino <> '' THEN factura_lineas.cantidad ELSE 0 END), 0) AS devuelto, "+
+				"COALESCE(SUM(factura_lineas.neto), 0) AS neto").
+			Joins("JOIN facturas ON facturas.id = factura_lineas.factura_id").
+			Where("facturas.factura_asociada_id = ? AND facturas.estado = ?", factura.ID, true).
+			Group("factura_lineas.factura_linea_id").
+			Scan(&previos).Error; err != nil {
+			return err
+		}
+		acreditado := map[uint]*acreditadoPorLinea{}
+		for i := range previos {
+			acreditado[previos[i].FacturaLineaID] = &previos[i]
+		}
+
+		nota = models.Invoice{
+			Tipo:              models.NotaCredito,
+			Fecha:             models.CustomDate{Time: fecha},
+			Descripcion:       req.Descripcion,
+			Estado:            true,
+			ClienteID:         factura.ClienteID,
+			CondicionIVA:      factura.CondicionIVA,
+			Letra:             factura.Letra,
+			PuntoVenta:        factura.PuntoVenta,
+			FacturaAsociadaID: &factura.ID,
+			EstadoAFIP:        models.AFIPPendiente,
+			FormaPago:         factura.FormaPago, // se devuelve por el mismo medio
+		}
+		numero, err := siguienteNumeroComprobante(tx, nota.Tipo, nota.Letra, nota.PuntoVenta)
+		if err != nil {
+			return err
+		}
+		nota.Numero = numero
+
+		for _, l := range req.Lineas {
+			orig, ok := lineasFactura[l.FacturaLineaID]
+			if !ok {
+				return errorHTTP(400, "La línea no pertenece a la factura")
+			}
+			previo := acreditado[orig.ID]
+			if previo == nil {
+				previo = &acreditadoPorLinea{FacturaLineaID: orig.ID}
+				acreditado[orig.ID] = previo
+			}
+
+			linea := models.InvoiceLine{
+				ProductoID:     orig.ProductoID,
+				FacturaLineaID: &orig.ID,
+				Cantidad:       l.Cantidad,
+				PrecioUnitario: orig.PrecioUnitario,
+				AlicuotaIVA:    orig.AlicuotaIVA,
+				Destino:        l.Destino,
+			}
+			if l.PrecioUnitario != nil {
+				linea.PrecioUnitario = *l.PrecioUnitario
+			}
+			linea.Calcular(nota.Letra != "C")
+
+			if l.Destino != "" {
+				if models.Round3(previo.Devuelto+l.Cantidad) > orig.Cantidad {
+					return errorHTTP(400, fmt.Sprintf("Sólo quedan %v sin devolver de la línea %d", models.Round3(orig.Cantidad-previo.Devuelto), orig.ID))
+				}
+				previo.Devuelto = models.Round3(previo.Devuelto + l.Cantidad)
+			}
+			if models.Round2(previo.Neto+linea.Neto) > orig.Neto {
+				return errorHTTP(400, fmt.Sprintf("El importe acreditado supera el facturado en la línea %d", orig.ID))
+			}
+			previo.Neto += linea.Neto
+
+			nota.Lineas = append(nota.Lineas, linea)
+		}
+		nota.CalcularTotales()
+
+		descripcion := nota.Descripcion
+		if descripcion == "" {
+			descripcion = nombreComprobante(&nota)
+		}
+		for i := range nota.Lineas {
+			l := &nota.Lineas[i]
+			if l.Destino == "" {
+				continue
+			}
+			// Lo devuelto siempre reingresa; si no se puede revender se da de baja como merma
+			dev := models.Movement{
+				Tipo:        models.MovimientoDevolucion,
+				ProductoID:  l.ProductoID,
+				Fecha:       nota.Fecha,
+				Descripcion: descripcion,
+				Cantidad:    l.Cantidad,
+			}
+			marcarComprobante(&dev, &nota)
+			if err := registrarMovimiento(tx, &dev); err != nil {
+				return err
+			}
+			l.MovimientoID = &dev.ID
+
+			if l.Destino == models.DestinoMerma {
+				merma := models.Movement{
+					Tipo:        models.MovimientoMerma,
+					ProductoID:  l.ProductoID,
+					Fecha:       nota.Fecha,
+					Descripcion: descripcion,
+					Cantidad:    l.Cantidad,
+				}
+				marcarComprobante(&merma, &nota)
+				if err := registrarMovimiento(tx, &merma); err != nil {
+					return err
+				}
+				l.MovimientoMermaID = &merma.ID
+			}
+		}
+
+		if err := tx.Create(&nota).Error; err != nil {
+			return errorHTTP(500, "Error al crear la nota de crédito")
+		}
+		return actualizarSaldo(tx, nota.ClienteID, -nota.Total)
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	solicitarCAE(&nota)
+
+	return c.Status(201).JSON(nota)
+}
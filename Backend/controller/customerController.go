@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// ============================================
+// CLIENTES
+// ============================================
+
+func CreateCustomer(c *fiber.Ctx) error {
+	req := new(models.CreateCustomerRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Solicitud inválida",
+		})
+	}
+	if req.RazonSocial == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "La razón social es obligatoria",
+		})
+	}
+	if req.CondicionIVA == "" {
+		req.CondicionIVA = models.CondicionConsumidorFinal
+	}
+	if !models.CondicionIVAValida(req.CondicionIVA) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Condición de IVA inválida",
+		})
+	}
+
+	cliente := models.Customer{
+		RazonSocial:  req.RazonSocial,
+		CUIT:         req.CUIT,
+		CondicionIVA: req.CondicionIVA,
+		Domicilio:    req.Domicilio,
+		Telefono:     req.Telefono,
+		Email:        req.Email,
+	}
+
+	if err := database.DB.Create(&cliente).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Error al crear el cliente",
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(cliente)
+}
+
+func GetCustomers(c *fiber.Ctx) error {
+	var clientes []models.Customer
+	if err := database.DB.Order("razon_social").Find(&clientes).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Error al obtener clientes",
+		})
+	}
+	return c.JSON(clientes)
+}
+
+func GetCustomerByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var cliente models.Customer
+	if err := database.DB.First(&cliente, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Cliente no encontrado",
+		})
+	}
+	return c.JSON(cliente)
+}
+
+func UpdateCustomer(c *fiber.Ctx) error {
+	id := c.Params("id")
+	req := new(models.UpdateCustomerRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Solicitud inválida",
+		})
+	}
+
+	var cliente models.Customer
+	if err := database.DB.First(&cliente, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Cliente no encontrado",
+		})
+	}
+
+	if req.CondicionIVA != "" {
+		if !models.CondicionIVAValida(req.CondicionIVA) {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "Condición de IVA inválida",
+			})
+		}
+		cliente.CondicionIVA = req.CondicionIVA
+	}
+	if req.RazonSocial != "" {
+		cliente.RazonSocial = req.RazonSocial
+	}
+	if req.CUIT != "" {
+		cliente.CUIT = req.CUIT
+	}
+	if req.Domicilio != "" {
+		cliente.Domicilio = req.Domicilio
+	}
+	if req.Telefono != "" {
+		cliente.Telefono = req.Telefono
+	}
+	if req.Email != "" {
+		cliente.Email = req.Email
+	}
+
+	if err := database.DB.Save(&cliente).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Error al actualizar cliente",
+		})
+	}
+	return c.JSON(cliente)
+}
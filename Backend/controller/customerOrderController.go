@@ -0,0 +1,265 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// reservarStock aparta (cantidad positiva) o libera (negativa) stock de un
+// producto para un pedido. Debe llamarse dentro de una transacción.
+func reservarStock(tx *gorm.DB, productoID uint, cantidad float64) error {
+	var producto models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&producto, productoID).Error; err != nil {
+		return errorHTTP(404, "Producto no encontrado")
+	}
+	if cantidad > 0 {
+		if err := productoActivo(&producto); err != nil {
+			return err
+		}
+		if err := cantidadEntera(&producto, cantidad); err != nil {
+			return err
+		}
+	}
+
+	reservado := models.Round3(producto.Reservado + cantidad)
+	if cantidad > 0 && reservado > producto.Stock {
+		return errorHTTP(400, fmt.Sprintf("Stock insuficiente para reservar %s: hay %v disponibles",
+			producto.Descripcion, producto.Disponible))
+	}
+	if reservado < 0 {
+		reservado = 0
+	}
+	return tx.Model(&producto).Update("reservado", reservado).Error
+}
+
+// ============================================
+// PEDIDOS
+// ============================================
+
+// CreateCustomerOrder registra un pedido y reserva el stock de cada línea
+func CreateCustomerOrder(c *fiber.Ctx) error {
+	req := new(models.CreateCustomerOrderRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+	if len(req.Lineas) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "El pedido debe tener al menos una línea"})
+	}
+	for _, l := range req.Lineas {
+		if l.Cantidad <= 0 {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Cantidad inválida"})
+		}
+	}
+	if req.ClienteID == nil && req.Contacto == "" {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Indique el cliente o un contacto"})
+	}
+
+	entrega, err := parseLocalDate(req.FechaEntrega)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha de entrega inválido"})
+	}
+
+	pedido := models.CustomerOrder{
+		Contacto:     req.Contacto,
+		Fecha:        models.CustomDate{Time: time.Now()},
+		FechaEntrega: models.CustomDate{Time: entrega},
+		Descripcion:  req.Descripcion,
+		Estado:       models.PedidoPendiente,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if req.ClienteID != nil {
+			var cliente models.Customer
+			if err := tx.First(&cliente, *req.ClienteID).Error; err != nil {
+				return errorHTTP(404, "Cliente no encontrado")
+			}
+			pedido.ClienteID = &cliente.ID
+		}
+
+		for _, l := range req.Lineas {
+			if err := reservarStock(tx, l.ProductoID, l.Cantidad); err != nil {
+				return err
+			}
+			pedido.Lineas = append(pedido.Lineas, models.CustomerOrderLine{
+				ProductoID: l.ProductoID,
+				Cantidad:   l.Cantidad,
+			})
+		}
+
+		if err := tx.Create(&pedido).Error; err != nil {
+			return errorHTTP(500, "Error al crear el pedido")
+		}
+		return nil
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.Status(201).JSON(pedido)
+}
+
+func GetCustomerOrders(c *fiber.Ctx) error {
+	query := database.DB.Preload("Cliente").Preload("Lineas").Preload("Lineas.Producto").
+		Order("fecha_entrega, id")
+
+	if estado := c.Query("estado"); estado != "" {
+		query = query.Where("estado = ?", estado)
+	}
+	if clienteID := c.Query("cliente_id"); clienteID != "" {
+		query = query.Where("cliente_id = ?", clienteID)
+	}
+	if fecha := c.Query("fecha_entrega"); fecha != "" {
+		entrega, err := parseLocalDate(fecha)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+		}
+		query = query.Where("fecha_entrega = ?", entrega)
+	}
+
+	var pedidos []models.CustomerOrder
+	if err := query.Find(&pedidos).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo pedidos"})
+	}
+	return c.JSON(pedidos)
+}
+
+func GetCustomerOrderByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var pedido models.CustomerOrder
+	if err := database.DB.Preload("Cliente").Preload("Lineas").Preload("Lineas.Producto").
+		First(&pedido, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Pedido no encontrado"})
+	}
+	return c.JSON(pedido)
+}
+
+// UpdateCustomerOrderStatus mueve el pedido entre estados. Cancelar libera la
+// reserva; entregar la libera y saca la mercadería con un ticket de SALIDAS.
+func UpdateCustomerOrderStatus(c *fiber.Ctx) error {
+	id := c.Params("id")
+	req := new(models.UpdateCustomerOrderStatusRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+
+	fecha := fechaHoy()
+	if req.Fecha != "" {
+		var err error
+		if fecha, err = parseLocalDate(req.Fecha); err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+		}
+	}
+
+	var pedido models.CustomerOrder
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&pedido, id).Error; err != nil {
+			return errorHTTP(404, "Pedido no encontrado")
+		}
+		if !models.TransicionPedidoValida(pedido.Estado, req.Estado) {
+			return errorHTTP(400, fmt.Sprintf("El pedido no puede pasar de %s a %s", pedido.Estado, req.Estado))
+		}
+		if err := tx.Where("pedido_id = ?", pedido.ID).Order("id").Find(&pedido.Lineas).Error; err != nil {
+			return err
+		}
+
+		switch req.Estado {
+		case models.PedidoCancelado:
+			for _, l := range pedido.Lineas {
+				if err := reservarStock(tx, l.ProductoID, -l.Cantidad); err != nil {
+					return err
+				}
+			}
+		case models.PedidoEntregado:
+			if err := entregarPedido(tx, &pedido, req, fecha); err != nil {
+				return err
+			}
+		}
+
+		pedido.Estado = req.Estado
+		return tx.Model(&pedido).Updates(map[string]interface{}{
+			"estado":        pedido.Estado,
+			"punto_venta":   pedido.PuntoVenta,
+			"numero_ticket": pedido.NumeroTicket,
+		}).Error
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.JSON(pedido)
+}
+
+// entregarPedido libera la reserva y registra una SALIDA por línea bajo un
+// mismo número de ticket. Lo entregado puede diferir de lo pedido (por peso).
+func entregarPedido(tx *gorm.DB, pedido *models.CustomerOrder, req *models.UpdateCustomerOrderStatusRequest, fecha time.Time) error {
+	entregado := map[uint]float64{}
+	for _, l := range pedido.Lineas {
+		entregado[l.ID] = l.Cantidad
+	}
+	for _, l := range req.Lineas {
+		if _, ok := entregado[l.PedidoLineaID]; !ok {
+			return errorHTTP(400, "La línea no pertenece al pedido")
+		}
+		if l.Cantidad < 0 {
+			return errorHTTP(400, "Cantidad inválida")
+		}
+		entregado[l.PedidoLineaID] = l.Cantidad
+	}
+
+	pv, err := puntoVentaValido(req.PuntoVenta)
+	if err != nil {
+		return err
+	}
+	// Si no se entrega nada no se consume un número de ticket
+	numero := 0
+	for _, cant := range entregado {
+		if cant > 0 {
+			if numero, err = siguienteNumeroTicket(tx, pv); err != nil {
+				return err
+			}
+			pedido.PuntoVenta, pedido.NumeroTicket = pv, numero
+			break
+		}
+	}
+
+	descripcion := fmt.Sprintf("Pedido #%d", pedido.ID)
+	if pedido.Descripcion != "" {
+		descripcion += " - " + pedido.Descripcion
+	}
+	for i := range pedido.Lineas {
+		l := &pedido.Lineas[i]
+		if err := reservarStock(tx, l.ProductoID, -l.Cantidad); err != nil {
+			return err
+		}
+		l.CantidadEntregada = entregado[l.ID]
+		if l.CantidadEntregada > 0 {
+			mov := models.Movement{
+				ProductoID:    l.ProductoID,
+				NumeroFactura: numero,
+				PuntoVenta:    pv,
+				Comprobante:   models.ComprobanteTicket,
+				Fecha:         models.CustomDate{Time: fecha},
+				Descripcion:   descripcion,
+				Cantidad:      l.CantidadEntregada,
+			}
+			if err := registrarSalida(tx, &mov); err != nil {
+				return err
+			}
+			l.MovimientoID = &mov.ID
+		}
+		if err := tx.Model(l).Updates(map[string]interface{}{
+			"cantidad_entregada": l.CantidadEntregada,
+			"movimiento_id":      l.MovimientoID,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
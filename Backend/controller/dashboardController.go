@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// diasSerieTablero es el largo de la serie diaria del tablero
+const diasSerieTablero = 30
+
+// GetDashboard devuelve las cifras del tablero con consultas agregadas, para
+// que el frontend no tenga que bajar todos los productos y movimientos
+func GetDashboard(c *fiber.Ctx) error {
+	ahora := time.Now()
+	hoy := time.Date(ahora.Year(), ahora.Month(), ahora.Day(), 0, 0, 0, 0, time.Local)
+	lunes := hoy.AddDate(0, 0, -((int(hoy.Weekday()) + 6) % 7))
+	inicioSerie := hoy.AddDate(0, 0, -(diasSerieTablero - 1))
+
+	resp := models.DashboardResponse{
+		StockPorUnidad: []models.StockByUnit{},
+		Serie:          make([]models.DailyMovementRow, 0, diasSerieTablero),
+	}
+
+	var porUnidad []struct {
+		models.StockByUnit
+		Bajo int
+	}
+	if err := database.DB.Model(&models.Product{}).
+		Select("tipo_cantidad AS unidad, COUNT(*) AS productos, COALESCE(SUM(stock), 0) AS stock, " +
+			"COUNT(*) FILTER (WHERE NOT archivado AND stock_minimo > 0 AND stock - reservado <= stock_minimo) AS bajo").
+		Group("tipo_cantidad").Order("tipo_cantidad").
+		Scan(&porUnidad).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando el stock"})
+	}
+	for _, u := range porUnidad {
+		resp.Productos += u.Productos
+		resp.StockBajo += u.Bajo
+		switch models.NormalizarUnidad(u.Unidad) {
+		case "kg":
+			resp.StockKg += u.Stock
+		case "unidades":
+			resp.StockUnidades += u.Stock
+		}
+		resp.StockPorUnidad = append(resp.StockPorUnidad, u.StockByUnit)
+	}
+
+	var hoyPorTipo []struct {
+		Tipo string
+		models.MovementTotals
+	}
+	if err := database.DB.Model(&models.Movement{}).
+		Select("tipo, COUNT(*) AS movimientos, COALESCE(SUM(ABS(cantidad)), 0) AS cantidad").
+		Where("estado = ? AND fecha = ? AND tipo IN ?", true, hoy.Format("2006-01-02"),
+			[]string{models.MovimientoEntrada, models.MovimientoSalida}).
+		Group("tipo").Scan(&hoyPorTipo).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo movimientos"})
+	}
+	for _, t := range hoyPorTipo {
+		if t.Tipo == models.MovimientoEntrada {
+			resp.EntradasHoy = t.MovementTotals
+		} else {
+			resp.SalidasHoy = t.MovementTotals
+		}
+	}
+
+	var anulaciones int64
+	if err := database.DB.Model(&models.Movement{}).
+		Where("estado = ? AND anulado_en >= ?", false, lunes).
+		Count(&anulaciones).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo anulaciones"})
+	}
+	resp.AnulacionesSemana = int(anulaciones)
+
+	var dias []struct {
+		Fecha       time.Time
+		Entradas    float64
+		Salidas     float64
+		Movimientos int
+	}
+	if err := database.DB.Model(&models.Movement{}).
+		Select("fecha, "+
+			"COALESCE(SUM(CASE WHEN tipo = ? THEN cantidad ELSE 0 END), 0) AS entradas, "+
+			"COALESCE(SUM(CASE WHEN tipo = ? THEN -cantidad ELSE 0 END), 0) AS salidas, "+
+			"COUNT(*) AS movimientos", models.MovimientoEntrada, models.MovimientoSalida).
+		Where("estado = ? AND fecha BETWEEN ? AND ?", true, inicioSerie.Format("2006-01-02"), hoy.Format("2006-01-02")).
+		Group("fecha").Scan(&dias).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo movimientos"})
+	}
+	porDia := map[string]models.DailyMovementRow{}
+	for _, d := range dias {
+		porDia[d.Fecha.Format("2006-01-02")] = models.DailyMovementRow{
+			Entradas:    d.Entradas,
+			Salidas:     d.Salidas,
+			Movimientos: d.Movimientos,
+		}
+	}
+	// Los días sin movimientos van en cero para que el gráfico no tenga huecos
+	for d := inicioSerie; !d.After(hoy); d = d.AddDate(0, 0, 1) {
+		fila := porDia[d.Format("2006-01-02")]
+		fila.Fecha = models.CustomDate{Time: d}
+		resp.Serie = append(resp.Serie, fila)
+	}
+
+	return c.JSON(resp)
+}
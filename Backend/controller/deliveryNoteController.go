@@ -0,0 +1,324 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// siguienteNumeroRemito reserva el próximo número de remito del punto de venta
+func siguienteNumeroRemito(tx *gorm.DB, puntoVenta int) (int, error) {
+	numero, err := siguienteNumero(tx, models.ComprobanteRemito, puntoVenta, func(tx *gorm.DB) (int, error) {
+		var ultimo int
+		err := tx.Model(&models.DeliveryNote{}).
+			Where("punto_venta = ?", puntoVenta).
+			Select("COALESCE(MAX(numero), 0)").
+			Scan(&ultimo).Error
+		return ultimo, err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var usados int64
+	if err := tx.Model(&models.DeliveryNote{}).Where("punto_venta = ? AND numero = ?", puntoVenta, numero).
+		Count(&usados).Error; err != nil {
+		return 0, err
+	}
+	if usados > 0 {
+		return 0, errorHTTP(409, "El número de remito ya fue usado: revise el numerador")
+	}
+	return numero, nil
+}
+
+// nombreRemito arma "Remito 0001-00000005"
+func nombreRemito(r *models.DeliveryNote) string {
+	return fmt.Sprintf("Remito %04d-%08d", r.PuntoVenta, r.Numero)
+}
+
+// ============================================
+// REMITOS
+// ============================================
+
+// CreateDeliveryNote emite un remito y descuenta el stock con una SALIDA por línea
+func CreateDeliveryNote(c *fiber.Ctx) error {
+	req := new(models.CreateDeliveryNoteRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+	if len(req.Lineas) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "El remito debe tener al menos una línea"})
+	}
+	for _, l := range req.Lineas {
+		if l.Cantidad <= 0 {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Cantidad inválida"})
+		}
+	}
+
+	fecha, err := parseLocalDate(req.Fecha)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+	}
+
+	remito := models.DeliveryNote{
+		Fecha:       models.CustomDate{Time: fecha},
+		Descripcion: req.Descripcion,
+		Estado:      models.RemitoEmitido,
+	}
+	if remito.PuntoVenta, err = puntoVentaValido(req.PuntoVenta); err != nil {
+		return responderError(c, err)
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if req.ClienteID != nil {
+			var cliente models.Customer
+			if err := tx.First(&cliente, *req.ClienteID).Error; err != nil {
+				return errorHTTP(404, "Cliente no encontrado")
+			}
+			remito.ClienteID = &cliente.ID
+		}
+
+		numero, err := siguienteNumeroRemito(tx, remito.PuntoVenta)
+		if err != nil {
+			return err
+		}
+		remito.Numero = numero
+
+		descripcion := remito.Descripcion
+		if descripcion == "" {
+			descripcion = nombreRemito(&remito)
+		}
+		for _, l := range req.Lineas {
+			mov := models.Movement{
+				ProductoID:    l.ProductoID,
+				NumeroFactura: remito.Numero,
+				PuntoVenta:    remito.PuntoVenta,
+				Comprobante:   models.ComprobanteRemito,
+				Fecha:         remito.Fecha,
+				Descripcion:   descripcion,
+				Cantidad:      l.Cantidad,
+			}
+			if err := registrarSalida(tx, &mov); err != nil {
+				return err
+			}
+			remito.Lineas = append(remito.Lineas, models.DeliveryNoteLine{
+				ProductoID:   l.ProductoID,
+				MovimientoID: &mov.ID,
+				Cantidad:     l.Cantidad,
+			})
+		}
+
+		if err := tx.Create(&remito).Error; err != nil {
+			return errorHTTP(500, "Error al crear el remito")
+		}
+		return nil
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.Status(201).JSON(remito)
+}
+
+func GetDeliveryNotes(c *fiber.Ctx) error {
+	query := database.DB.Preload("Cliente").Preload("Lineas").Order("fecha desc, id desc")
+
+	if estado := c.Query("estado"); estado != "" {
+		query = query.Where("estado = ?", estado)
+	}
+	if clienteID := c.Query("cliente_id"); clienteID != "" {
+		query = query.Where("cliente_id = ?", clienteID)
+	}
+
+	var remitos []models.DeliveryNote
+	if err := query.Find(&remitos).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo remitos"})
+	}
+	return c.JSON(remitos)
+}
+
+func GetDeliveryNoteByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var remito models.DeliveryNote
+	if err := database.DB.Preload("Cliente").Preload("Lineas").Preload("Lineas.Producto").
+		First(&remito, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Remito no encontrado"})
+	}
+	return c.JSON(remito)
+}
+
+// CancelDeliveryNote anula un remito sin facturar y devuelve el stock
+func CancelDeliveryNote(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var remito models.DeliveryNote
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Lineas").First(&remito, id).Error; err != nil {
+			return errorHTTP(404, "Remito no encontrado")
+		}
+		if remito.Estado == models.RemitoAnulado {
+			return errorHTTP(400, "El remito ya está anulado")
+		}
+		if remito.Estado != models.RemitoEmitido {
+			return errorHTTP(400, "No se puede anular un remito ya facturado")
+		}
+
+		for _, l := range remito.Lineas {
+			if l.MovimientoID == nil {
+				continue
+			}
+			var mov models.Movement
+			if err := tx.First(&mov, *l.MovimientoID).Error; err != nil {
+				return errorHTTP(404, "Movimiento no encontrado")
+			}
+			if !mov.Estado {
+				continue
+			}
+			if err := anularMovimiento(tx, &mov); err != nil {
+				return err
+			}
+		}
+
+		remito.Estado = models.RemitoAnulado
+		return tx.Model(&remito).Update("estado", remito.Estado).Error
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.JSON(remito)
+}
+
+// InvoiceDeliveryNote factura todo o parte de un remito. El stock ya salió con
+// el remito, así que las líneas de la factura no generan movimientos.
+func InvoiceDeliveryNote(c *fiber.Ctx) error {
+	id := c.Params("id")
+	req := new(models.InvoiceDeliveryNoteRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+	if len(req.Lineas) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Indique las líneas a facturar"})
+	}
+	for _, l := range req.Lineas {
+		if l.Cantidad <= 0 || l.PrecioUnitario < 0 {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Cantidad o precio inválidos"})
+		}
+		if l.AlicuotaIVA != "" {
+			if _, ok := models.TasaIVA(l.AlicuotaIVA); !ok {
+				return c.Status(400).JSON(models.ErrorResponse{Error: "Alícuota de IVA inválida"})
+			}
+		}
+	}
+
+	fecha := fechaHoy()
+	if req.Fecha != "" {
+		var err error
+		if fecha, err = parseLocalDate(req.Fecha); err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+		}
+	}
+
+	var factura models.Invoice
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var remito models.DeliveryNote
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&remito, id).Error; err != nil {
+			return errorHTTP(404, "Remito no encontrado")
+		}
+		if remito.Estado == models.RemitoAnulado {
+			return errorHTTP(400, "El remito está anulado")
+		}
+		if err := tx.Where("remito_id = ?", remito.ID).Order("id").Find(&remito.Lineas).Error; err != nil {
+			return err
+		}
+		lineasRemito := map[uint]*models.DeliveryNoteLine{}
+		for i := range remito.Lineas {
+			lineasRemito[remito.Lineas[i].ID] = &remito.Lineas[i]
+		}
+
+		descripcion := req.Descripcion
+		if descripcion == "" {
+			descripcion = "Según " + nombreRemito(&remito)
+		}
+		forma, err := formaPagoVenta(req.FormaPago)
+		if err != nil {
+			return err
+		}
+		factura = models.Invoice{
+			Tipo:        models.FacturaVenta,
+			Fecha:       models.CustomDate{Time: fecha},
+			Descripcion: descripcion,
+			Estado:      true,
+			FormaPago:   forma,
+		}
+		if err := prepararFacturaVenta(tx, &factura, remito.ClienteID); err != nil {
+			return err
+		}
+
+		for _, l := range req.Lineas {
+			lr, ok := lineasRemito[l.RemitoLineaID]
+			if !ok {
+				return errorHTTP(400, "La línea no pertenece al remito")
+			}
+			if l.Cantidad > lr.Pendiente() {
+				return errorHTTP(400, fmt.Sprintf("Sólo quedan %v sin facturar de la línea %d", lr.Pendiente(), lr.ID))
+			}
+			linea, err := lineaFactura(tx, lr.ProductoID, l.Cantidad, l.PrecioUnitario, l.AlicuotaIVA)
+			if err != nil {
+				return err
+			}
+			linea.RemitoLineaID = &lr.ID
+			factura.Lineas = append(factura.Lineas, linea)
+			lr.CantidadFacturada = models.Round3(lr.CantidadFacturada + l.Cantidad)
+		}
+		factura.CalcularTotales()
+
+		if err := tx.Create(&factura).Error; err != nil {
+			return errorHTTP(500, "Error al crear la factura")
+		}
+		if err := actualizarSaldo(tx, factura.ClienteID, factura.Total); err != nil {
+			return err
+		}
+		for _, lr := range lineasRemito {
+			if err := tx.Model(lr).Update("cantidad_facturada", lr.CantidadFacturada).Error; err != nil {
+				return err
+			}
+		}
+		remito.ActualizarEstado()
+		return tx.Model(&remito).Update("estado", remito.Estado).Error
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	solicitarCAE(&factura)
+
+	return c.Status(201).JSON(factura)
+}
+
+// devolverFacturadoRemito descuenta de los remitos lo facturado por una factura que se anula
+func devolverFacturadoRemito(tx *gorm.DB, l models.InvoiceLine) error {
+	var lr models.DeliveryNoteLine
+	if err := tx.First(&lr, *l.RemitoLineaID).Error; err != nil {
+		return errorHTTP(404, "Línea de remito no encontrada")
+	}
+	lr.CantidadFacturada = models.Round3(lr.CantidadFacturada - l.Cantidad)
+	if lr.CantidadFacturada < 0 {
+		lr.CantidadFacturada = 0
+	}
+	if err := tx.Model(&lr).Update("cantidad_facturada", lr.CantidadFacturada).Error; err != nil {
+		return err
+	}
+
+	var remito models.DeliveryNote
+	if err := tx.Preload("Lineas").First(&remito, lr.RemitoID).Error; err != nil {
+		return errorHTTP(404, "Remito no encontrado")
+	}
+	remito.ActualizarEstado()
+	return tx.Model(&remito).Update("estado", remito.Estado).Error
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"sanJoseProyect/models"
+)
+
+// Criterios con los que se detecta una factura de compra repetida
+const (
+	criterioNumeroProveedor = "NUMERO_PROVEEDOR"
+	criterioNumeroFechaTot  = "NUMERO_FECHA_TOTAL"
+	criterioNumeroFecha     = "NUMERO_OTRA_FECHA"
+	criterioNumeroProducto  = "NUMERO_MISMO_PRODUCTO"
+)
+
+// bloquearNumeroCompra serializa hasta el fin de la transacción los controles de
+// un mismo número de factura de compra: si dos usuarios cargan la misma factura
+// a la vez, el segundo espera y ve la del primero. No se usa un índice único
+// porque un duplicado se puede cargar igual, forzándolo con un motivo.
+func bloquearNumeroCompra(tx *gorm.DB, numero int) error {
+	return tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", fmt.Sprintf("factura_compra:%d", numero)).Error
+}
+
+// facturasCompraDuplicadas busca facturas de compra activas con el mismo número
+// del mismo proveedor, o con el mismo número, fecha y total de cualquier proveedor
+func facturasCompraDuplicadas(tx *gorm.DB, f *models.Invoice) ([]models.DuplicateMatch, error) {
+	if err := bloquearNumeroCompra(tx, f.Numero); err != nil {
+		return nil, err
+	}
+	var mismas []models.Invoice
+	if err := tx.Where("tipo = ? AND estado = ? AND numero = ?", models.FacturaCompra, true, f.Numero).
+		Where("(proveedor_id = ? AND letra = ? AND punto_venta = ?) OR (fecha = ? AND total = ?)",
+			f.ProveedorID, f.Letra, f.PuntoVenta, f.Fecha, f.Total).
+		Order("id").
+		Find(&mismas).Error; err != nil {
+		return nil, err
+	}
+
+	coincidencias := []models.DuplicateMatch{}
+	for _, m := range mismas {
+		criterio := criterioNumeroFechaTot
+		if m.ProveedorID != nil && f.ProveedorID != nil && *m.ProveedorID == *f.ProveedorID &&
+			m.Letra == f.Letra && m.PuntoVenta == f.PuntoVenta {
+			criterio = criterioNumeroProveedor
+		}
+		coincidencias = append(coincidencias, models.DuplicateMatch{
+			Entidad:       "factura",
+			ID:            m.ID,
+			Criterio:      criterio,
+			ProveedorID:   m.ProveedorID,
+			PuntoVenta:    m.PuntoVenta,
+			NumeroFactura: m.Numero,
+			Fecha:         m.Fecha,
+			Total:         m.Total,
+		})
+	}
+	return coincidencias, nil
+}
+
+// entradasDuplicadas busca entradas activas con el mismo número de factura (y
+// proveedor y punto de venta, si se indicaron) cargadas otro día o para el mismo
+// producto. Las líneas de una misma factura llegan por separado el mismo día, así
+// que esas no cuentan. Las entradas sin punto de venta coinciden con cualquiera.
+func entradasDuplicadas(tx *gorm.DB, mov *models.Movement) ([]models.DuplicateMatch, error) {
+	if err := bloquearNumeroCompra(tx, mov.NumeroFactura); err != nil {
+		return nil, err
+	}
+	query := tx.Where("cantidad > 0 AND estado = ? AND numero_factura = ? AND comprobante = ''", true, mov.NumeroFactura).
+		Where("fecha <> ? OR producto_id = ?", mov.Fecha, mov.ProductoID)
+	if mov.ProveedorID != nil {
+		query = query.Where("proveedor_id = ?", *mov.ProveedorID)
+	}
+	if mov.PuntoVenta > 0 {
+		query = query.Where("punto_venta IN ?", []int{0, mov.PuntoVenta})
+	}
+
+	var movimientos []models.Movement
+	if err := query.Order("id").Find(&movimientos).Error; err != nil {
+		return nil, err
+	}
+
+	coincidencias := []models.DuplicateMatch{}
+	for _, m := range movimientos {
+		criterio := criterioNumeroFecha
+		if m.ProductoID == mov.ProductoID && m.Fecha.Equal(mov.Fecha.Time) {
+			criterio = criterioNumeroProducto
+		}
+		coincidencias = append(coincidencias, models.DuplicateMatch{
+			Entidad:       "movimiento",
+			ID:            m.ID,
+			Criterio:      criterio,
+			ProveedorID:   m.ProveedorID,
+			PuntoVenta:    m.PuntoVenta,
+			NumeroFactura: m.NumeroFactura,
+			Fecha:         m.Fecha,
+		})
+	}
+	return coincidencias, nil
+}
+
+// controlarDuplicado rechaza la carga si hay coincidencias, salvo que se fuerce
+// con un motivo. Devuelve si hay que auditar el alta.
+func controlarDuplicado(coincidencias []models.DuplicateMatch, forzar bool, motivo string) (bool, error) {
+	if len(coincidencias) == 0 {
+		return false, nil
+	}
+	if !forzar {
+		return false, &errorDuplicado{
+			mensaje:       "La factura parece estar cargada. Para cargarla igual envíe forzar=true con un motivo",
+			coincidencias: coincidencias,
+		}
+	}
+	if motivo == "" {
+		return false, errorHTTP(400, "Indique el motivo para cargar una factura duplicada")
+	}
+	return true, nil
+}
+
+// auditarDuplicado deja constancia de quién forzó la carga y con qué coincidía
+func auditarDuplicado(tx *gorm.DB, c *fiber.Ctx, entidad string, id uint, motivo string, coincidencias []models.DuplicateMatch) error {
+	return auditar(tx, c, models.AuditoriaDuplicadoForzado, entidad, id, motivo, fiber.Map{
+		"coincidencias": coincidencias,
+	})
+}
@@ -0,0 +1,222 @@
+package controller
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+	"sanJoseProyect/planilla"
+)
+
+// movimientoExportado es un movimiento con los datos del producto, leído de a uno
+type movimientoExportado struct {
+	models.Movement
+	Codigo   int
+	Producto string
+	Unidad   string
+}
+
+// estadoTexto muestra el estado del movimiento en la planilla
+func estadoTexto(activo bool) string {
+	if activo {
+		return "Activo"
+	}
+	return "Anulado"
+}
+
+// enviarPlanilla valida el formato (csv por defecto, o xlsx) y escribe la
+// planilla directamente en la respuesta a medida que se leen las filas, sin
+// cargar la consulta entera en memoria. Una vez empezada la respuesta ya no se
+// puede cambiar el código HTTP: los errores se registran en el log.
+func enviarPlanilla(c *fiber.Ctx, nombre string, escribir func(hoja planilla.Hoja) error) error {
+	formato := c.Query("formato", "csv")
+	if formato != "csv" && formato != "xlsx" {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "El formato debe ser csv o xlsx"})
+	}
+
+	c.Set(fiber.HeaderContentType, planilla.TipoContenido(formato))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.%s"`, nombre, formato))
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		hoja, err := planilla.Nueva(formato, w, nombre)
+		if err == nil {
+			err = escribir(hoja)
+		}
+		if err == nil {
+			err = hoja.Cerrar()
+		}
+		if err != nil {
+			log.Printf("❌ Error exportando %s: %v", nombre, err)
+		}
+	})
+	return nil
+}
+
+// recorrerMovimientos lee la consulta de a un movimiento
+func recorrerMovimientos(query *gorm.DB, fn func(m *movimientoExportado) error) error {
+	rows, err := query.Rows()
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var m movimientoExportado
+		if err := database.DB.ScanRows(rows, &m); err != nil {
+			return err
+		}
+		if err := fn(&m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// consultaMovimientosExportados une los movimientos con su producto
+func consultaMovimientosExportados() *gorm.DB {
+	return database.DB.Table("movimientos").
+		Select("movimientos.*, productos.codigo AS codigo, productos.descripcion AS producto, " +
+			"productos.tipo_cantidad AS unidad").
+		Joins("JOIN productos ON productos.id = movimientos.producto_id")
+}
+
+// ============================================
+// EXPORTACIONES
+// ============================================
+
+// ExportProducts exporta el listado de productos con su stock valorizado
+func ExportProducts(c *fiber.Ctx) error {
+	return enviarPlanilla(c, "productos", func(hoja planilla.Hoja) error {
+		if err := hoja.Encabezado("Código", "Descripción", "Unidad", "Stock inicial", "Stock", "Reservado",
+			"Disponible", "Stock mínimo", "Stock máximo", "Alícuota IVA", "Costo", "Precio venta", "Valorizado"); err != nil {
+			return err
+		}
+		rows, err := database.DB.Model(&models.Product{}).Order("codigo").Rows()
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var p models.Product
+			if err := database.DB.ScanRows(rows, &p); err != nil {
+				return err
+			}
+			disponible := models.Round3(p.Stock - p.Reservado)
+			if err := hoja.Fila(p.Codigo, p.Descripcion, p.TipoCantidad, p.StockInicial, p.Stock, p.Reservado,
+				disponible, p.StockMinimo, p.StockMaximo, p.AlicuotaIVA,
+				planilla.Decimal(p.Costo), planilla.Decimal(p.PrecioVenta), planilla.Decimal(models.Round2(p.Stock*p.Costo))); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	})
+}
+
+// ExportMovements exporta los movimientos filtrados por tipo, producto
+// (producto_id o codigo), estado y fechas
+func ExportMovements(c *fiber.Ctx) error {
+	query := consultaMovimientosExportados().Order("movimientos.fecha, movimientos.id")
+
+	if tipo := c.Query("tipo"); tipo != "" {
+		query = query.Where("movimientos.tipo = ?", tipo)
+	}
+	if productoID := c.Query("producto_id"); productoID != "" {
+		query = query.Where("movimientos.producto_id = ?", productoID)
+	}
+	if codigo := c.Query("codigo"); codigo != "" {
+		query = query.Where("productos.codigo = ?", codigo)
+	}
+	switch c.Query("estado") {
+	case "":
+	case "activo":
+		query = query.Where("movimientos.estado = ?", true)
+	case "anulado":
+		query = query.Where("movimientos.estado = ?", false)
+	default:
+		return c.Status(400).JSON(models.ErrorResponse{Error: "El estado debe ser activo o anulado"})
+	}
+	if desde := c.Query("fecha_inicio"); desde != "" {
+		fecha, err := parseLocalDate(desde)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "fecha_inicio inválida"})
+		}
+		query = query.Where("movimientos.fecha >= ?", fecha)
+	}
+	if hasta := c.Query("fecha_fin"); hasta != "" {
+		fecha, err := parseLocalDate(hasta)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "fecha_fin inválida"})
+		}
+		query = query.Where("movimientos.fecha <= ?", fecha)
+	}
+
+	return enviarPlanilla(c, "movimientos", func(hoja planilla.Hoja) error {
+		if err := hoja.Encabezado("Fecha", "Tipo", "Comprobante", "Punto de venta", "Número", "Código", "Producto",
+			"Descripción", "Cantidad", "Unidad", "Cantidad original", "Unidad original", "Estado"); err != nil {
+			return err
+		}
+		return recorrerMovimientos(query, func(m *movimientoExportado) error {
+			var original interface{}
+			if m.UnidadOriginal != "" {
+				original = m.CantidadOriginal
+			}
+			return hoja.Fila(m.Fecha.Time, m.Tipo, m.Comprobante, m.PuntoVenta, m.NumeroFactura, m.Codigo, m.Producto,
+				m.Descripcion, m.Cantidad, m.Unidad, original, m.UnidadOriginal, estadoTexto(m.Estado))
+		})
+	})
+}
+
+// ExportKardex exporta la ficha de stock del producto con saldo acumulado
+func ExportKardex(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var producto models.Product
+	if err := database.DB.First(&producto, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Producto no encontrado"})
+	}
+	desde, hasta, err := rangoFechas(c)
+	if err != nil {
+		return responderError(c, err)
+	}
+	anteriores, err := movimientosAntesDe(desde, producto.ID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando el saldo inicial"})
+	}
+	saldo := models.Round3(producto.StockInicial + anteriores[producto.ID])
+
+	query := consultaMovimientosExportados().
+		Where("movimientos.producto_id = ? AND movimientos.estado = ? AND movimientos.fecha BETWEEN ? AND ?",
+			producto.ID, true, desde, hasta).
+		Order("movimientos.fecha, movimientos.id")
+
+	return enviarPlanilla(c, fmt.Sprintf("kardex-%d", producto.Codigo), func(hoja planilla.Hoja) error {
+		if err := hoja.Encabezado("Fecha", "Tipo", "Comprobante", "Punto de venta", "Número", "Descripción",
+			"Entrada", "Salida", "Saldo"); err != nil {
+			return err
+		}
+		if err := hoja.Fila(desde, "", "", nil, nil,
+			fmt.Sprintf("Saldo inicial %d - %s", producto.Codigo, producto.Descripcion), nil, nil, saldo); err != nil {
+			return err
+		}
+		entradas, salidas := 0.0, 0.0
+		err := recorrerMovimientos(query, func(m *movimientoExportado) error {
+			saldo = models.Round3(saldo + m.Cantidad)
+			var entrada, salida interface{}
+			if m.EsIngreso() {
+				entrada = m.Cantidad
+				entradas = models.Round3(entradas + m.Cantidad)
+			} else {
+				salida = -m.Cantidad
+				salidas = models.Round3(salidas - m.Cantidad)
+			}
+			return hoja.Fila(m.Fecha.Time, m.Tipo, m.Comprobante, m.PuntoVenta, m.NumeroFactura, m.Descripcion,
+				entrada, salida, saldo)
+		})
+		if err != nil {
+			return err
+		}
+		return hoja.Fila(hasta, "", "", nil, nil, "Saldo final", entradas, salidas, saldo)
+	})
+}
@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+	"sanJoseProyect/pronostico"
+)
+
+// diasHistoria es cuánto hacia atrás se lee para medir las temporadas del año anterior
+const diasHistoria = 400
+
+// historialVentas arma la venta diaria (SALIDAS activas) de cada producto entre las fechas
+func historialVentas(desde, hasta time.Time, productoID string) (map[uint]pronostico.Historial, error) {
+	var filas []struct {
+		ProductoID uint
+		Fecha      time.Time
+		Cantidad   float64
+	}
+	query := database.DB.Model(&models.Movement{}).
+		Select("producto_id, fecha, SUM(-cantidad) AS cantidad").
+		Where("tipo = ? AND estado = ? AND fecha BETWEEN ? AND ?", models.MovimientoSalida, true,
+			desde.Format("2006-01-02"), hasta.Format("2006-01-02"))
+	if productoID != "" {
+		query = query.Where("producto_id = ?", productoID)
+	}
+	if err := query.Group("producto_id, fecha").Scan(&filas).Error; err != nil {
+		return nil, err
+	}
+	historiales := map[uint]pronostico.Historial{}
+	for _, f := range filas {
+		h, ok := historiales[f.ProductoID]
+		if !ok {
+			h = pronostico.Historial{}
+			historiales[f.ProductoID] = h
+		}
+		h[f.Fecha.Format("2006-01-02")] = f.Cantidad
+	}
+	return historiales, nil
+}
+
+// semanasModelo lee cuántas semanas de historia usa el modelo (por defecto 8)
+func semanasModelo(c *fiber.Ctx) (int, error) {
+	semanas, err := strconv.Atoi(c.Query("semanas", "8"))
+	if err != nil || semanas <= 0 || semanas > 52 {
+		return 0, errorHTTP(400, "semanas inválidas")
+	}
+	return semanas, nil
+}
+
+// pronosticarDemanda suma la demanda estimada de cada producto para los `dias`
+// que empiezan en `desde`, con el modelo ajustado hasta el día anterior
+func pronosticarDemanda(desde time.Time, dias, semanas int) (map[uint]float64, error) {
+	historiales, err := historialVentas(desde.AddDate(0, 0, -diasHistoria), desde.AddDate(0, 0, -1), "")
+	if err != nil {
+		return nil, err
+	}
+	demanda := map[uint]float64{}
+	for productoID, h := range historiales {
+		m := pronostico.Ajustar(h, desde.AddDate(0, 0, -1), semanas)
+		for i := 0; i < dias; i++ {
+			demanda[productoID] += m.Pronosticar(desde.AddDate(0, 0, i))
+		}
+	}
+	return demanda, nil
+}
+
+// ============================================
+// PRONÓSTICO DE DEMANDA
+// ============================================
+
+// GetForecast estima la venta diaria de cada producto para los próximos días
+// (por defecto los 7 desde mañana)
+func GetForecast(c *fiber.Ctx) error {
+	semanas, err := semanasModelo(c)
+	if err != nil {
+		return responderError(c, err)
+	}
+	dias, err := strconv.Atoi(c.Query("dias", "7"))
+	if err != nil || dias <= 0 || dias > 60 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "dias inválidos"})
+	}
+	hoy := time.Now()
+	desde := time.Date(hoy.Year(), hoy.Month(), hoy.Day()+1, 0, 0, 0, 0, time.Local)
+	if d := c.Query("desde"); d != "" {
+		if desde, err = parseLocalDate(d); err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "desde inválida"})
+		}
+	}
+	productoID := c.Query("producto_id")
+
+	historiales, err := historialVentas(desde.AddDate(0, 0, -diasHistoria), desde.AddDate(0, 0, -1), productoID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error leyendo el historial de ventas"})
+	}
+
+	query := database.DB.Where("archivado = ?", false).Order("codigo")
+	if productoID != "" {
+		query = query.Where("id = ?", productoID)
+	}
+	var productos []models.Product
+	if err := query.Find(&productos).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error al obtener productos"})
+	}
+
+	filas := []models.ForecastRow{}
+	for _, p := range productos {
+		h, ok := historiales[p.ID]
+		if !ok {
+			continue // sin ventas no hay qué pronosticar
+		}
+		fila := models.ForecastRow{
+			ProductoID:  p.ID,
+			Codigo:      p.Codigo,
+			Descripcion: p.Descripcion,
+			Unidad:      p.TipoCantidad,
+			Modelo:      pronostico.Ajustar(h, desde.AddDate(0, 0, -1), semanas),
+		}
+		for i := 0; i < dias; i++ {
+			fecha := desde.AddDate(0, 0, i)
+			cantidad := models.Round2(fila.Modelo.Pronosticar(fecha))
+			fila.Dias = append(fila.Dias, models.ForecastDay{Fecha: models.CustomDate{Time: fecha}, Cantidad: cantidad})
+			fila.Total += cantidad
+		}
+		fila.Total = models.Round2(fila.Total)
+		filas = append(filas, fila)
+	}
+	return c.JSON(filas)
+}
+
+// GetForecastBacktest mide qué tan bien habría pronosticado el modelo las
+// ventas registradas del período, semana por semana y sin mirar el futuro
+func GetForecastBacktest(c *fiber.Ctx) error {
+	desde, hasta, err := rangoFechas(c)
+	if err != nil {
+		return responderError(c, err)
+	}
+	semanas, err := semanasModelo(c)
+	if err != nil {
+		return responderError(c, err)
+	}
+	productoID := c.Query("producto_id")
+
+	historiales, err := historialVentas(desde.AddDate(0, 0, -diasHistoria), hasta, productoID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error leyendo el historial de ventas"})
+	}
+
+	var productos []models.Product
+	if err := database.DB.Order("codigo").Find(&productos).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error al obtener productos"})
+	}
+
+	resp := models.BacktestResponse{
+		Desde:     c.Query("fecha_inicio"),
+		Hasta:     c.Query("fecha_fin"),
+		Semanas:   semanas,
+		Productos: []models.BacktestRow{},
+	}
+	for _, p := range productos {
+		h, ok := historiales[p.ID]
+		if !ok {
+			continue
+		}
+		e := pronostico.Backtest(h, desde, hasta, semanas)
+		resp.Total.Sumar(e)
+		resp.Productos = append(resp.Productos, models.BacktestRow{
+			ProductoID:  p.ID,
+			Codigo:      p.Codigo,
+			Descripcion: p.Descripcion,
+			Evaluacion:  e,
+		})
+	}
+	resp.Total.Cerrar()
+	return c.JSON(resp)
+}
@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"sanJoseProyect/models"
+	"sanJoseProyect/planilla"
+)
+
+// errorHTTP corta una operación (y hace rollback si está dentro de una
+// transacción) con el código y mensaje que se le devuelve al cliente
+func errorHTTP(status int, mensaje string) error {
+	return fiber.NewError(status, mensaje)
+}
+
+// errorDuplicado rechaza una carga que coincide con comprobantes ya cargados y
+// devuelve las coincidencias para que el usuario decida si la fuerza
+type errorDuplicado struct {
+	mensaje       string
+	coincidencias []models.DuplicateMatch
+}
+
+func (e *errorDuplicado) Error() string {
+	return e.mensaje
+}
+
+// responderError traduce un error devuelto por una transacción a la respuesta JSON
+func responderError(c *fiber.Ctx, err error) error {
+	var dup *errorDuplicado
+	if errors.As(err, &dup) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":         dup.mensaje,
+			"coincidencias": dup.coincidencias,
+		})
+	}
+	var fe *fiber.Error
+	if errors.As(err, &fe) {
+		return c.Status(fe.Code).JSON(models.ErrorResponse{Error: fe.Message})
+	}
+	log.Println("❌ Error interno:", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{Error: "Error interno del servidor"})
+}
+
+// enviarCSV responde las filas (ya armadas en memoria) como CSV para Excel
+func enviarCSV(c *fiber.Ctx, nombre string, filas [][]string) error {
+	var buf bytes.Buffer
+	hoja, err := planilla.NuevaCSV(&buf)
+	if err != nil {
+		return responderError(c, err)
+	}
+	for _, fila := range filas {
+		if err := hoja.Encabezado(fila...); err != nil {
+			return responderError(c, err)
+		}
+	}
+	if err := hoja.Cerrar(); err != nil {
+		return responderError(c, err)
+	}
+	c.Set(fiber.HeaderContentType, planilla.TipoContenido("csv"))
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s"`, nombre))
+	return c.Send(buf.Bytes())
+}
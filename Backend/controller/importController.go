@@ -0,0 +1,383 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+	"sanJoseProyect/planilla"
+)
+
+// columnasImportacion traduce los encabezados aceptados (normalizados) al campo
+// del producto. Incluye los de la exportación de productos, para poder editarla y volver a subirla.
+var columnasImportacion = map[string]string{
+	"codigo":        "codigo",
+	"descripcion":   "descripcion",
+	"tipo_cantidad": "tipo_cantidad",
+	"unidad":        "tipo_cantidad",
+	"stock_inicial": "stock_inicial",
+	"costo":         "costo",
+	"precio_costo":  "costo",
+	"precio_venta":  "precio_venta",
+	"precio":        "precio_venta",
+	"alicuota_iva":  "alicuota_iva",
+	"iva":           "alicuota_iva",
+	"stock_minimo":  "stock_minimo",
+	"stock_maximo":  "stock_maximo",
+}
+
+// errFilasConErrores cancela la transacción de la importación sin aplicar nada
+var errFilasConErrores = errors.New("la planilla tiene filas con errores")
+
+// productoImportado es una fila válida lista para crear o actualizar
+type productoImportado struct {
+	existente    *models.Product
+	codigo       int
+	descripcion  string
+	unidad       string
+	stockInicial *float64
+	costo        *float64
+	precioVenta  *float64
+	alicuota     string
+	minimo       *float64
+	maximo       *float64
+}
+
+// cantidadPlanilla lee una cantidad no negativa, hasta el gramo; vacío es nil
+func cantidadPlanilla(s string) (*float64, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	n, err := planilla.Numero(s)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("%q no es una cantidad válida", s)
+	}
+	v := models.Round3(n)
+	return &v, nil
+}
+
+// validarImportacion revisa cada fila contra las demás y contra los productos
+// ya cargados. Devuelve el detalle por fila y las filas válidas.
+func validarImportacion(db *gorm.DB, filas [][]string) (models.ImportReport, []productoImportado, error) {
+	var reporte models.ImportReport
+
+	indice := map[string]int{}
+	for i, titulo := range filas[0] {
+		if campo, ok := columnasImportacion[planilla.NormalizarEncabezado(titulo)]; ok {
+			if _, repetido := indice[campo]; !repetido {
+				indice[campo] = i
+			}
+		}
+	}
+	for _, obligatorio := range []string{"codigo", "descripcion"} {
+		if _, ok := indice[obligatorio]; !ok {
+			return reporte, nil, errorHTTP(400, "Falta la columna "+obligatorio)
+		}
+	}
+	celda := func(fila []string, campo string) string {
+		i, ok := indice[campo]
+		if !ok || i >= len(fila) {
+			return ""
+		}
+		return strings.TrimSpace(fila[i])
+	}
+
+	// Productos ya cargados con los códigos de la planilla
+	var codigos []int
+	for _, fila := range filas[1:] {
+		if n, err := planilla.Numero(celda(fila, "codigo")); err == nil {
+			codigos = append(codigos, int(n))
+		}
+	}
+	var existentes []models.Product
+	if len(codigos) > 0 {
+		if err := db.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("codigo IN ?", codigos).Find(&existentes).Error; err != nil {
+			return reporte, nil, err
+		}
+	}
+	porCodigo := map[int]*models.Product{}
+	for i := range existentes {
+		porCodigo[existentes[i].Codigo] = &existentes[i]
+	}
+
+	vistos := map[int]int{} // código -> fila donde apareció primero
+	var validos []productoImportado
+	for i, fila := range filas[1:] {
+		if strings.TrimSpace(strings.Join(fila, "")) == "" {
+			continue
+		}
+		det := models.ImportRow{Fila: i + 2, Descripcion: celda(fila, "descripcion")}
+		p := productoImportado{descripcion: det.Descripcion}
+		agregarError := func(format string, args ...interface{}) {
+			det.Errores = append(det.Errores, fmt.Sprintf(format, args...))
+		}
+
+		n, err := planilla.Numero(celda(fila, "codigo"))
+		switch {
+		case err != nil || n <= 0 || n != math.Trunc(n) || n > math.MaxInt32:
+			agregarError("Código inválido: %q", celda(fila, "codigo"))
+		default:
+			p.codigo = int(n)
+			det.Codigo = p.codigo
+			if primera, ok := vistos[p.codigo]; ok {
+				agregarError("Código %d repetido (ya figura en la fila %d)", p.codigo, primera)
+			} else {
+				vistos[p.codigo] = det.Fila
+			}
+			p.existente = porCodigo[p.codigo]
+		}
+
+		if p.descripcion == "" {
+			agregarError("Falta la descripción")
+		}
+
+		p.unidad = models.NormalizarUnidad(celda(fila, "tipo_cantidad"))
+		if p.unidad != "" && !models.TipoCantidadValido(p.unidad) {
+			agregarError("Unidad inválida: %q (unidades, cajas o kg)", celda(fila, "tipo_cantidad"))
+		}
+
+		if p.alicuota = celda(fila, "alicuota_iva"); p.alicuota != "" {
+			p.alicuota = strings.ToUpper(strings.TrimSuffix(strings.Replace(p.alicuota, ",", ".", 1), "%"))
+			if _, ok := models.TasaIVA(p.alicuota); !ok {
+				agregarError("Alícuota de IVA inválida: %q (21, 10,5 o EXENTO)", celda(fila, "alicuota_iva"))
+			}
+		}
+
+		if s := celda(fila, "costo"); s != "" {
+			costo, err := planilla.Numero(s)
+			if err != nil || costo < 0 {
+				agregarError("Costo inválido: %q", s)
+			} else {
+				costo = models.Round2(costo)
+				p.costo = &costo
+			}
+		}
+		if s := celda(fila, "precio_venta"); s != "" {
+			precio, err := planilla.Numero(s)
+			if err != nil || precio < 0 {
+				agregarError("Precio de venta inválido: %q", s)
+			} else {
+				precio = models.Round2(precio)
+				p.precioVenta = &precio
+			}
+		}
+
+		for _, c := range []struct {
+			campo   string
+			nombre  string
+			destino **float64
+		}{
+			{"stock_inicial", "Stock inicial", &p.stockInicial},
+			{"stock_minimo", "Stock mínimo", &p.minimo},
+			{"stock_maximo", "Stock máximo", &p.maximo},
+		} {
+			v, err := cantidadPlanilla(celda(fila, c.campo))
+			if err != nil {
+				agregarError("%s inválido: %v", c.nombre, err)
+			}
+			*c.destino = v
+		}
+
+		// El stock inicial va en la unidad con la que queda el producto
+		if p.stockInicial != nil && (p.unidad == "" || models.TipoCantidadValido(p.unidad)) {
+			unidad := p.unidad
+			if unidad == "" && p.existente != nil {
+				unidad = p.existente.TipoCantidad
+			}
+			if unidad == "" {
+				unidad = models.UnidadUnidades
+			}
+			if err := cantidadEntera(&models.Product{Descripcion: p.descripcion, TipoCantidad: unidad}, *p.stockInicial); err != nil {
+				agregarError("Stock inicial inválido: %v", err)
+			}
+		}
+
+		minimo, maximo := valorOCero(p.minimo), valorOCero(p.maximo)
+		if p.existente != nil {
+			// Como en la fusión, no se mezclan unidades: los movimientos y comprobantes
+			// ya cargados quedarían en la unidad anterior
+			if p.unidad != "" && p.unidad != p.existente.TipoCantidad {
+				usos, err := historialProducto(db, p.existente.ID)
+				if err != nil {
+					return reporte, nil, err
+				}
+				if len(usos) > 0 {
+					nombres := make([]string, len(usos))
+					for i, u := range usos {
+						nombres[i] = u.Nombre
+					}
+					agregarError("No se puede pasar de %s a %s: el producto ya tiene %s",
+						p.existente.TipoCantidad, p.unidad, strings.Join(nombres, ", "))
+				}
+			}
+			if p.minimo == nil {
+				minimo = p.existente.StockMinimo
+			}
+			if p.maximo == nil {
+				maximo = p.existente.StockMaximo
+			}
+			// Cambiar el stock inicial corre el stock actual en la misma diferencia
+			if p.stockInicial != nil {
+				stock := models.Round3(p.existente.Stock + *p.stockInicial - p.existente.StockInicial)
+				if stock < p.existente.Reservado {
+					agregarError("Con stock inicial %v el stock quedaría en %v, por debajo de lo reservado (%v)",
+						*p.stockInicial, stock, p.existente.Reservado)
+				}
+			}
+		}
+		if maximo > 0 && maximo < minimo {
+			agregarError("El stock máximo no puede ser menor que el mínimo")
+		}
+
+		reporte.Filas++
+		switch {
+		case len(det.Errores) > 0:
+			reporte.ConErrores++
+		case p.existente != nil:
+			det.Accion = models.ImportarActualizar
+			reporte.Actualizados++
+			validos = append(validos, p)
+		default:
+			det.Accion = models.ImportarCrear
+			reporte.Nuevos++
+			validos = append(validos, p)
+		}
+		reporte.Detalle = append(reporte.Detalle, det)
+	}
+	return reporte, validos, nil
+}
+
+func valorOCero(v *float64) float64 {
+	if v == nil {
+		return 0
+	}
+	return *v
+}
+
+// aplicarImportacion crea los productos nuevos y actualiza los existentes con
+// las columnas que vinieron completas
+func aplicarImportacion(tx *gorm.DB, validos []productoImportado) error {
+	for _, p := range validos {
+		if p.existente == nil {
+			producto := models.Product{
+				Codigo:       p.codigo,
+				Descripcion:  p.descripcion,
+				StockInicial: valorOCero(p.stockInicial),
+				Stock:        valorOCero(p.stockInicial),
+				TipoCantidad: p.unidad,
+				AlicuotaIVA:  p.alicuota,
+				StockMinimo:  valorOCero(p.minimo),
+				StockMaximo:  valorOCero(p.maximo),
+			}
+			if producto.TipoCantidad == "" {
+				producto.TipoCantidad = models.UnidadUnidades
+			}
+			if producto.AlicuotaIVA == "" {
+				producto.AlicuotaIVA = models.IVA21
+			}
+			if p.costo != nil {
+				producto.Costo = *p.costo
+			}
+			if p.precioVenta != nil {
+				producto.PrecioVenta = *p.precioVenta
+			}
+			if err := tx.Create(&producto).Error; err != nil {
+				return errorHTTP(500, fmt.Sprintf("Error al crear el producto %d", p.codigo))
+			}
+			continue
+		}
+
+		cambios := map[string]interface{}{"descripcion": p.descripcion}
+		if p.unidad != "" {
+			cambios["tipo_cantidad"] = p.unidad
+		}
+		if p.alicuota != "" {
+			cambios["alicuota_iva"] = p.alicuota
+		}
+		if p.costo != nil {
+			cambios["costo"] = *p.costo
+		}
+		if p.precioVenta != nil {
+			cambios["precio_venta"] = *p.precioVenta
+		}
+		if p.minimo != nil {
+			cambios["stock_minimo"] = *p.minimo
+		}
+		if p.maximo != nil {
+			cambios["stock_maximo"] = *p.maximo
+		}
+		if p.stockInicial != nil {
+			diferencia := models.Round3(*p.stockInicial - p.existente.StockInicial)
+			cambios["stock_inicial"] = *p.stockInicial
+			cambios["stock"] = gorm.Expr("stock + ?", diferencia)
+		}
+		if err := tx.Model(&models.Product{}).Where("id = ?", p.existente.ID).Updates(cambios).Error; err != nil {
+			return errorHTTP(500, fmt.Sprintf("Error al actualizar el producto %d", p.codigo))
+		}
+	}
+	return nil
+}
+
+// ============================================
+// IMPORTACIÓN DE PRODUCTOS
+// ============================================
+
+// ImportProducts recibe una planilla CSV o XLSX (campo "archivo") con una fila
+// por producto. Por defecto sólo valida y devuelve qué haría con cada fila;
+// con ?aplicar=true crea o actualiza todos en una transacción, y si alguna
+// fila tiene errores no aplica ninguna.
+func ImportProducts(c *fiber.Ctx) error {
+	archivo, err := c.FormFile("archivo")
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Adjunte la planilla en el campo archivo"})
+	}
+	f, err := archivo.Open()
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "No se pudo leer el archivo"})
+	}
+	defer f.Close()
+
+	filas, err := planilla.Leer(archivo.Filename, f)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+	}
+	if len(filas) < 2 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "La planilla no tiene productos"})
+	}
+
+	if !c.QueryBool("aplicar") {
+		reporte, _, err := validarImportacion(database.DB, filas)
+		if err != nil {
+			return responderError(c, err)
+		}
+		return c.JSON(reporte)
+	}
+
+	var reporte models.ImportReport
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var validos []productoImportado
+		var err error
+		if reporte, validos, err = validarImportacion(tx, filas); err != nil {
+			return err
+		}
+		if reporte.ConErrores > 0 {
+			return errFilasConErrores
+		}
+		return aplicarImportacion(tx, validos)
+	})
+	if err != nil {
+		if errors.Is(err, errFilasConErrores) {
+			return c.Status(400).JSON(reporte)
+		}
+		return responderError(c, err)
+	}
+	reporte.Aplicado = true
+	return c.JSON(reporte)
+}
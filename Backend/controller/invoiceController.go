@@ -0,0 +1,407 @@
+package controller
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// condicionEmisor es la condición de IVA del negocio (define la letra de las facturas de venta)
+func condicionEmisor() string {
+	condicion := os.Getenv("IVA_CONDICION_EMISOR")
+	if !models.CondicionIVAValida(condicion) {
+		return models.CondicionResponsableInscripto
+	}
+	return condicion
+}
+
+// puntoVentaPorDefecto devuelve el punto de venta configurado para los comprobantes de venta
+func puntoVentaPorDefecto() int {
+	pv, err := strconv.Atoi(os.Getenv("PUNTO_VENTA"))
+	if err != nil || pv <= 0 {
+		return 1
+	}
+	return pv
+}
+
+// siguienteNumeroComprobante reserva el número de la serie del tipo, letra y punto de venta
+func siguienteNumeroComprobante(tx *gorm.DB, tipo, letra string, puntoVenta int) (int, error) {
+	numero, err := siguienteNumero(tx, models.ClaveComprobante(tipo, letra), puntoVenta, func(tx *gorm.DB) (int, error) {
+		var ultimo int
+		err := tx.Model(&models.Invoice{}).
+			Where("tipo = ? AND letra = ? AND punto_venta = ?", tipo, letra, puntoVenta).
+			Select("COALESCE(MAX(numero), 0)").
+			Scan(&ultimo).Error
+		return ultimo, err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var usados int64
+	if err := tx.Model(&models.Invoice{}).
+		Where("tipo = ? AND letra = ? AND punto_venta = ? AND numero = ?", tipo, letra, puntoVenta, numero).
+		Count(&usados).Error; err != nil {
+		return 0, err
+	}
+	if usados > 0 {
+		return 0, errorHTTP(409, "El número de comprobante ya fue usado: revise el numerador")
+	}
+	return numero, nil
+}
+
+// nombreComprobante arma "Factura B 0001-00000012" o "Nota de crédito B 0001-00000003"
+// con el número fiscal
+func nombreComprobante(f *models.Invoice) string {
+	nombre := "Factura"
+	if f.Tipo == models.NotaCredito {
+		nombre = "Nota de crédito"
+	}
+	return fmt.Sprintf("%s %s %04d-%08d", nombre, f.Letra, f.PuntoVenta, f.NumeroFiscal())
+}
+
+// actualizarSaldo suma (o resta) un importe a la cuenta del cliente
+func actualizarSaldo(tx *gorm.DB, clienteID *uint, importe float64) error {
+	if clienteID == nil || importe == 0 {
+		return nil
+	}
+	return tx.Model(&models.Customer{}).Where("id = ?", *clienteID).
+		Update("saldo", gorm.Expr("saldo + ?", models.Round2(importe))).Error
+}
+
+// prepararFacturaVenta completa el cliente, la letra y el número; el punto de
+// venta queda el de la factura o, si no tiene, el configurado
+func prepararFacturaVenta(tx *gorm.DB, factura *models.Invoice, clienteID *uint) error {
+	factura.CondicionIVA = models.CondicionConsumidorFinal
+	if clienteID != nil {
+		var cliente models.Customer
+		if err := tx.First(&cliente, *clienteID).Error; err != nil {
+			return errorHTTP(404, "Cliente no encontrado")
+		}
+		factura.ClienteID = &cliente.ID
+		factura.CondicionIVA = cliente.CondicionIVA
+	}
+	factura.Letra = models.LetraFactura(condicionEmisor(), factura.CondicionIVA)
+	pv, err := puntoVentaValido(factura.PuntoVenta)
+	if err != nil {
+		return err
+	}
+	factura.PuntoVenta = pv
+	numero, err := siguienteNumeroComprobante(tx, factura.Tipo, factura.Letra, factura.PuntoVenta)
+	if err != nil {
+		return err
+	}
+	factura.Numero = numero
+	factura.EstadoAFIP = models.AFIPPendiente
+	return nil
+}
+
+// formaPagoVenta valida la forma de pago; si no se indica queda SIN_ESPECIFICAR
+func formaPagoVenta(forma string) (string, error) {
+	if forma == "" {
+		return models.FormaPagoSinEspecificar, nil
+	}
+	if !models.FormaPagoValida(forma) {
+		return "", errorHTTP(400, "Forma de pago inválida")
+	}
+	return forma, nil
+}
+
+// lineaFactura arma una línea usando la alícuota del producto si no se indica otra
+func lineaFactura(tx *gorm.DB, productoID uint, cantidad float64, precio float64, alicuota string) (models.InvoiceLine, error) {
+	var producto models.Product
+	if err := tx.First(&producto, productoID).Error; err != nil {
+		return models.InvoiceLine{}, errorHTTP(404, "Producto no encontrado")
+	}
+	if alicuota == "" {
+		alicuota = producto.AlicuotaIVA
+	}
+	if _, ok := models.TasaIVA(alicuota); !ok {
+		alicuota = models.IVA21
+	}
+	return models.InvoiceLine{
+		ProductoID:     producto.ID,
+		Cantidad:       cantidad,
+		PrecioUnitario: precio,
+		AlicuotaIVA:    alicuota,
+	}, nil
+}
+
+// ============================================
+// FACTURAS
+// ============================================
+
+func CreateInvoice(c *fiber.Ctx) error {
+	req := new(models.CreateInvoiceRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+
+	if req.Tipo != models.FacturaVenta && req.Tipo != models.FacturaCompra {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "El tipo debe ser VENTA o COMPRA"})
+	}
+	if len(req.Lineas) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "La factura debe tener al menos una línea"})
+	}
+	for _, l := range req.Lineas {
+		if l.Cantidad <= 0 || l.PrecioUnitario < 0 {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Cantidad o precio inválidos"})
+		}
+		if l.AlicuotaIVA != "" {
+			if _, ok := models.TasaIVA(l.AlicuotaIVA); !ok {
+				return c.Status(400).JSON(models.ErrorResponse{Error: "Alícuota de IVA inválida"})
+			}
+		}
+		// Las ventas se devuelven y acreditan por cantidad de stock
+		if l.Unidad != "" && req.Tipo == models.FacturaVenta {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "La unidad sólo se indica en facturas de compra"})
+		}
+	}
+
+	fecha, err := parseLocalDate(req.Fecha)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+	}
+
+	factura := models.Invoice{
+		Tipo:        req.Tipo,
+		Fecha:       models.CustomDate{Time: fecha},
+		Descripcion: req.Descripcion,
+		Estado:      true,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if req.Tipo == models.FacturaVenta {
+			forma, err := formaPagoVenta(req.FormaPago)
+			if err != nil {
+				return err
+			}
+			factura.FormaPago = forma
+			factura.PuntoVenta = req.PuntoVenta
+			if err := prepararFacturaVenta(tx, &factura, req.ClienteID); err != nil {
+				return err
+			}
+		} else {
+			if req.ProveedorID == nil {
+				return errorHTTP(400, "El proveedor es obligatorio en facturas de compra")
+			}
+			if req.Letra != "A" && req.Letra != "B" && req.Letra != "C" {
+				return errorHTTP(400, "La letra de la factura debe ser A, B o C")
+			}
+			if req.PuntoVenta <= 0 || req.Numero <= 0 {
+				return errorHTTP(400, "Punto de venta o número de factura inválido")
+			}
+			var proveedor models.Supplier
+			if err := tx.First(&proveedor, *req.ProveedorID).Error; err != nil {
+				return errorHTTP(404, "Proveedor no encontrado")
+			}
+
+			factura.ProveedorID = &proveedor.ID
+			factura.CondicionIVA = proveedor.CondicionIVA
+			factura.Letra = req.Letra
+			factura.PuntoVenta = req.PuntoVenta
+			factura.Numero = req.Numero
+		}
+
+		for _, l := range req.Lineas {
+			linea, err := lineaFactura(tx, l.ProductoID, l.Cantidad, l.PrecioUnitario, l.AlicuotaIVA)
+			if err != nil {
+				return err
+			}
+			linea.Unidad = l.Unidad
+			factura.Lineas = append(factura.Lineas, linea)
+		}
+		factura.CalcularTotales()
+
+		// Una compra con el mismo número que otra ya cargada duplicaría el stock
+		var coincidencias []models.DuplicateMatch
+		auditarAlta := false
+		if factura.Tipo == models.FacturaCompra {
+			var err error
+			if coincidencias, err = facturasCompraDuplicadas(tx, &factura); err != nil {
+				return err
+			}
+			if auditarAlta, err = controlarDuplicado(coincidencias, req.Forzar, req.Motivo); err != nil {
+				return err
+			}
+		}
+
+		// Cada línea mueve stock igual que una entrada o salida manual
+		descripcion := factura.Descripcion
+		if descripcion == "" {
+			descripcion = nombreComprobante(&factura)
+		}
+		for i := range factura.Lineas {
+			l := &factura.Lineas[i]
+			mov := models.Movement{
+				ProductoID:    l.ProductoID,
+				NumeroFactura: factura.Numero,
+				Fecha:         factura.Fecha,
+				Descripcion:   descripcion,
+				Cantidad:      l.Cantidad,
+			}
+			if factura.Tipo == models.FacturaVenta {
+				mov.PuntoVenta = factura.PuntoVenta
+				mov.Comprobante = models.ClaveComprobante(factura.Tipo, factura.Letra)
+				err = registrarSalida(tx, &mov)
+			} else {
+				// Lo facturado en otra unidad (por ejemplo cajas) entra convertido al stock
+				mov.ProveedorID = factura.ProveedorID
+				mov.UnidadOriginal = l.Unidad
+				if err = registrarEntrada(tx, &mov); err == nil {
+					l.Unidad = mov.UnidadOriginal
+					err = actualizarCosto(tx, l.ProductoID, l.PrecioUnitario*l.Cantidad/mov.Cantidad)
+				}
+			}
+			if err != nil {
+				return err
+			}
+			l.MovimientoID = &mov.ID
+		}
+
+		if err := tx.Create(&factura).Error; err != nil {
+			return errorHTTP(500, "Error al crear la factura")
+		}
+		if factura.Tipo == models.FacturaVenta {
+			return actualizarSaldo(tx, factura.ClienteID, factura.Total)
+		}
+		if auditarAlta {
+			return auditarDuplicado(tx, c, "factura", factura.ID, req.Motivo, coincidencias)
+		}
+		return nil
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	if factura.Tipo == models.FacturaVenta {
+		solicitarCAE(&factura)
+	}
+
+	return c.Status(201).JSON(factura)
+}
+
+func GetInvoices(c *fiber.Ctx) error {
+	query := database.DB.Preload("Cliente").Preload("Proveedor").Order("fecha desc, id desc")
+
+	if tipo := c.Query("tipo"); tipo != "" {
+		query = query.Where("tipo = ?", tipo)
+	}
+	if desde := c.Query("fecha_inicio"); desde != "" {
+		fecha, err := parseLocalDate(desde)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+		}
+		query = query.Where("fecha >= ?", fecha)
+	}
+	if hasta := c.Query("fecha_fin"); hasta != "" {
+		fecha, err := parseLocalDate(hasta)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+		}
+		query = query.Where("fecha <= ?", fecha)
+	}
+
+	var facturas []models.Invoice
+	if err := query.Find(&facturas).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo facturas"})
+	}
+	return c.JSON(facturas)
+}
+
+func GetInvoiceByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var factura models.Invoice
+	if err := database.DB.Preload("Cliente").Preload("Proveedor").
+		Preload("Lineas").Preload("Lineas.Producto").
+		Preload("FacturaAsociada").Preload("NotasCredito").
+		First(&factura, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Factura no encontrada"})
+	}
+	return c.JSON(factura)
+}
+
+// CancelInvoice anula la factura (o nota de crédito) y los movimientos de stock que generó
+func CancelInvoice(c *fiber.Ctx) error {
+	id := c.Params("id")
+	// Con la cola tomada ningún pedido de CAE puede autorizarla mientras se anula
+	colaAFIP.Lock()
+	defer colaAFIP.Unlock()
+	if err := verificarPendienteAFIP(id); err != nil {
+		return responderError(c, err)
+	}
+
+	var factura models.Invoice
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lineas").First(&factura, id).Error; err != nil {
+			return errorHTTP(404, "Factura no encontrada")
+		}
+		if !factura.Estado {
+			return errorHTTP(400, "La factura ya está anulada")
+		}
+		if factura.EstadoAFIP == models.AFIPAutorizada {
+			if factura.Tipo == models.NotaCredito {
+				return errorHTTP(400, "La nota de crédito tiene CAE y no se puede anular")
+			}
+			return errorHTTP(400, "La factura tiene CAE: debe anularse con una nota de crédito")
+		}
+		if factura.Tipo == models.FacturaVenta {
+			var notas int64
+			if err := tx.Model(&models.Invoice{}).
+				Where("factura_asociada_id = ? AND estado = ?", factura.ID, true).
+				Count(&notas).Error; err != nil {
+				return err
+			}
+			if notas > 0 {
+				return errorHTTP(400, "La factura tiene notas de crédito activas: anúlelas primero")
+			}
+		}
+
+		for _, l := range factura.Lineas {
+			if l.RemitoLineaID != nil {
+				// La mercadería salió con el remito: vuelve a quedar pendiente de facturar
+				if err := devolverFacturadoRemito(tx, l); err != nil {
+					return err
+				}
+				continue
+			}
+			// La merma se revierte antes que la devolución para no quedar sin stock
+			for _, movID := range []*uint{l.MovimientoMermaID, l.MovimientoID} {
+				if movID == nil {
+					continue
+				}
+				var mov models.Movement
+				if err := tx.First(&mov, *movID).Error; err != nil {
+					return errorHTTP(404, "Movimiento no encontrado")
+				}
+				if !mov.Estado {
+					continue
+				}
+				if err := anularMovimiento(tx, &mov); err != nil {
+					return err
+				}
+			}
+		}
+
+		if factura.EsElectronica() {
+			if err := actualizarSaldo(tx, factura.ClienteID, -factura.Signo()*factura.Total); err != nil {
+				return err
+			}
+		}
+
+		factura.Estado = false
+		return tx.Model(&factura).Update("estado", false).Error
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.JSON(factura)
+}
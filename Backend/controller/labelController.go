@@ -0,0 +1,312 @@
+package controller
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"sanJoseProyect/balanza"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+	"sanJoseProyect/pdf"
+	"sanJoseProyect/zpl"
+)
+
+// plantillaEstandar se usa si no hay ninguna plantilla predeterminada cargada
+var plantillaEstandar = models.LabelTemplate{Nombre: "Estándar", AnchoMM: 60, AltoMM: 40}
+
+// plantillaEtiqueta busca la plantilla pedida (?plantilla_id=) o la predeterminada
+func plantillaEtiqueta(c *fiber.Ctx) (models.LabelTemplate, error) {
+	var plantilla models.LabelTemplate
+	if id := c.Query("plantilla_id"); id != "" {
+		if err := database.DB.First(&plantilla, id).Error; err != nil {
+			return plantilla, errorHTTP(404, "Plantilla no encontrada")
+		}
+		return plantilla, nil
+	}
+	if err := database.DB.Where("predeterminada = ?", true).First(&plantilla).Error; err != nil {
+		return plantillaEstandar, nil
+	}
+	return plantilla, nil
+}
+
+// armarEtiqueta completa los datos de la etiqueta del producto. Si se vende
+// por peso y tiene PLU, el código es la etiqueta de balanza con el peso, así
+// se escanea en la venta como las de la balanza; si no, el EAN del envase o
+// el código interno del producto.
+func armarEtiqueta(tx *gorm.DB, producto *models.Product, cantidad float64, lote string, envasado time.Time, copias int) (models.Label, error) {
+	e := models.Label{
+		Codigo:      producto.Codigo,
+		Descripcion: producto.Descripcion,
+		ZonaCaptura: producto.ZonaCaptura,
+		Origen:      producto.Origen,
+		Congelado:   producto.Congelado,
+		Cantidad:    cantidad,
+		Unidad:      producto.TipoCantidad,
+		Lote:        lote,
+		Envasado:    envasado,
+		Copias:      copias,
+	}
+	if producto.EspecieID != nil {
+		var especie models.Species
+		if err := tx.First(&especie, *producto.EspecieID).Error; err == nil {
+			e.Especie, e.NombreCientifico = especie.Nombre, especie.NombreCientifico
+		}
+	}
+	if producto.PrecioVenta > 0 && cantidad > 0 {
+		e.Importe = models.Round2(cantidad * producto.PrecioVenta)
+	}
+	if producto.VidaUtilDias > 0 {
+		vto := envasado.AddDate(0, 0, int(producto.VidaUtilDias))
+		e.Vencimiento = &vto
+	}
+
+	var codigos []models.ProductBarcode
+	if err := tx.Where("producto_id = ?", producto.ID).Order("id").Find(&codigos).Error; err != nil {
+		return e, err
+	}
+	if models.NormalizarUnidad(producto.TipoCantidad) == models.UnidadKg && cantidad > 0 {
+		formato, err := formatoBalanza()
+		if err != nil {
+			return e, err
+		}
+		valor := cantidad
+		if formato.Valor == balanza.ValorPrecio {
+			valor = e.Importe
+		}
+		for _, cb := range codigos {
+			if cb.Tipo != models.CodigoBarraPLU || valor <= 0 {
+				continue
+			}
+			codigo, err := formato.Codificar(cb.Codigo, valor)
+			if err != nil {
+				return e, errorHTTP(400, fmt.Sprintf("%s: %v", producto.Descripcion, err))
+			}
+			e.CodigoBarra, e.TipoBarra = codigo, models.BarraEAN13
+			return e, nil
+		}
+	}
+	for _, cb := range codigos {
+		if cb.Tipo == models.CodigoBarraEAN {
+			e.CodigoBarra, e.TipoBarra = cb.Codigo, models.BarraCode128
+			if len(cb.Codigo) == 13 {
+				e.TipoBarra = models.BarraEAN13
+			}
+			return e, nil
+		}
+	}
+	e.CodigoBarra, e.TipoBarra = fmt.Sprint(producto.Codigo), models.BarraCode128
+	return e, nil
+}
+
+// enviarEtiquetas responde las etiquetas en ZPL (por defecto) o en PDF
+func enviarEtiquetas(c *fiber.Ctx, nombre string, plantilla models.LabelTemplate, etiquetas []models.Label) error {
+	switch c.Query("formato", "zpl") {
+	case "zpl":
+		contenido, err := zpl.Generar(plantilla.ZPL, plantilla.AnchoMM, plantilla.AltoMM, etiquetas)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: err.Error()})
+		}
+		c.Set(fiber.HeaderContentType, "text/plain; charset=utf-8")
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="%s.zpl"`, nombre))
+		return c.Send(contenido)
+	case "pdf":
+		contenido, err := pdf.Etiquetas(etiquetas, plantilla.AnchoMM, plantilla.AltoMM)
+		if err != nil {
+			log.Println("❌ Error generando etiquetas:", err)
+			return c.Status(500).JSON(models.ErrorResponse{Error: "Error generando las etiquetas"})
+		}
+		return enviarPDF(c, nombre+".pdf", contenido)
+	}
+	return c.Status(400).JSON(models.ErrorResponse{Error: "El formato debe ser zpl o pdf"})
+}
+
+// copiasEtiqueta lee ?copias= (por defecto 1)
+func copiasEtiqueta(c *fiber.Ctx) (int, error) {
+	copias := c.QueryInt("copias", 1)
+	if copias < 1 || copias > 500 {
+		return 0, errorHTTP(400, "Las copias deben ser entre 1 y 500")
+	}
+	return copias, nil
+}
+
+// ============================================
+// ETIQUETAS
+// ============================================
+
+// GetProductLabels imprime etiquetas de un producto envasado: ?cantidad=
+// (kilos o unidades), lote, envasado (por defecto hoy; el lote por defecto
+// es la fecha de envasado AAMMDD), copias y plantilla_id
+func GetProductLabels(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var producto models.Product
+	if err := database.DB.First(&producto, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Producto no encontrado"})
+	}
+	cantidad := c.QueryFloat("cantidad", 0)
+	if cantidad < 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Cantidad inválida"})
+	}
+	envasado := time.Now()
+	if s := c.Query("envasado"); s != "" {
+		var err error
+		if envasado, err = parseLocalDate(s); err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha de envasado inválido"})
+		}
+	}
+	lote := c.Query("lote", envasado.Format("060102"))
+	copias, err := copiasEtiqueta(c)
+	if err != nil {
+		return responderError(c, err)
+	}
+	plantilla, err := plantillaEtiqueta(c)
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	etiqueta, err := armarEtiqueta(database.DB, &producto, cantidad, lote, envasado, copias)
+	if err != nil {
+		return responderError(c, err)
+	}
+	return enviarEtiquetas(c, fmt.Sprintf("etiqueta-%d", producto.Codigo), plantilla, []models.Label{etiqueta})
+}
+
+// GetTransformationLabels imprime una etiqueta por producto obtenido en la
+// transformación, con el número de transformación como lote y su fecha como envasado
+func GetTransformationLabels(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var t models.Transformation
+	if err := database.DB.Preload("Productos").Preload("Productos.Producto").First(&t, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Transformación no encontrada"})
+	}
+	if !t.Estado {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "La transformación está anulada"})
+	}
+	copias, err := copiasEtiqueta(c)
+	if err != nil {
+		return responderError(c, err)
+	}
+	plantilla, err := plantillaEtiqueta(c)
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	lote := fmt.Sprintf("T%06d", t.ID)
+	var etiquetas []models.Label
+	for _, out := range t.Productos {
+		if out.Producto == nil {
+			continue
+		}
+		e, err := armarEtiqueta(database.DB, out.Producto, out.Cantidad, lote, t.Fecha.Time, copias)
+		if err != nil {
+			return responderError(c, err)
+		}
+		etiquetas = append(etiquetas, e)
+	}
+	return enviarEtiquetas(c, "etiquetas-"+lote, plantilla, etiquetas)
+}
+
+// ============================================
+// PLANTILLAS DE ETIQUETA
+// ============================================
+
+// validarPlantilla controla el tamaño y que el ZPL se pueda armar
+func validarPlantilla(p *models.LabelTemplate) error {
+	if p.AnchoMM < 20 || p.AnchoMM > 200 || p.AltoMM < 15 || p.AltoMM > 300 {
+		return errorHTTP(400, "Tamaño de etiqueta inválido (ancho de 20 a 200 mm, alto de 15 a 300 mm)")
+	}
+	if err := zpl.Validar(p.ZPL); err != nil {
+		return errorHTTP(400, err.Error())
+	}
+	return nil
+}
+
+// guardarPlantilla guarda la plantilla y, si es la predeterminada, desmarca las demás
+func guardarPlantilla(p *models.LabelTemplate) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(p).Error; err != nil {
+			return errorHTTP(500, "Error al guardar la plantilla")
+		}
+		if !p.Predeterminada {
+			return nil
+		}
+		return tx.Model(&models.LabelTemplate{}).Where("id <> ? AND predeterminada = ?", p.ID, true).
+			Update("predeterminada", false).Error
+	})
+}
+
+func CreateLabelTemplate(c *fiber.Ctx) error {
+	req := new(models.CreateLabelTemplateRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+	if req.Nombre == "" {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "El nombre es obligatorio"})
+	}
+
+	plantilla := models.LabelTemplate{
+		Nombre:         req.Nombre,
+		AnchoMM:        req.AnchoMM,
+		AltoMM:         req.AltoMM,
+		ZPL:            req.ZPL,
+		Predeterminada: req.Predeterminada,
+	}
+	if err := validarPlantilla(&plantilla); err != nil {
+		return responderError(c, err)
+	}
+	if err := guardarPlantilla(&plantilla); err != nil {
+		return responderError(c, err)
+	}
+	return c.Status(201).JSON(plantilla)
+}
+
+// GetLabelTemplates lista las plantillas y el ZPL estándar, para copiarlo y modificarlo
+func GetLabelTemplates(c *fiber.Ctx) error {
+	var plantillas []models.LabelTemplate
+	if err := database.DB.Order("nombre").Find(&plantillas).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo plantillas"})
+	}
+	return c.JSON(fiber.Map{
+		"plantillas":   plantillas,
+		"zpl_estandar": zpl.PlantillaPorDefecto,
+	})
+}
+
+func UpdateLabelTemplate(c *fiber.Ctx) error {
+	id := c.Params("id")
+	req := new(models.UpdateLabelTemplateRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+
+	var plantilla models.LabelTemplate
+	if err := database.DB.First(&plantilla, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Plantilla no encontrada"})
+	}
+	if req.Nombre != "" {
+		plantilla.Nombre = req.Nombre
+	}
+	if req.AnchoMM != nil {
+		plantilla.AnchoMM = *req.AnchoMM
+	}
+	if req.AltoMM != nil {
+		plantilla.AltoMM = *req.AltoMM
+	}
+	if req.ZPL != nil {
+		plantilla.ZPL = *req.ZPL
+	}
+	if req.Predeterminada != nil {
+		plantilla.Predeterminada = *req.Predeterminada
+	}
+	if err := validarPlantilla(&plantilla); err != nil {
+		return responderError(c, err)
+	}
+	if err := guardarPlantilla(&plantilla); err != nil {
+		return responderError(c, err)
+	}
+	return c.JSON(plantilla)
+}
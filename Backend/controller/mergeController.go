@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// costoPromedio pondera el costo de los dos productos por su stock
+func costoPromedio(a, b *models.Product) float64 {
+	if a.Costo == 0 {
+		return b.Costo
+	}
+	if b.Costo == 0 || a.Stock+b.Stock == 0 {
+		return a.Costo
+	}
+	return models.Round2((a.Costo*a.Stock + b.Costo*b.Stock) / (a.Stock + b.Stock))
+}
+
+// ============================================
+// FUSIÓN DE PRODUCTOS
+// ============================================
+
+// MergeProducts fusiona un producto duplicado (producto_id) en el de la URL,
+// en una sola transacción: le pasa los movimientos, comprobantes, lotes de
+// transformaciones, códigos de barras y conversiones, le suma el stock
+// inicial, físico y reservado, toma su costo (promedio ponderado) y precio si
+// faltan, archiva el duplicado en cero y deja la fusión en la auditoría
+func MergeProducts(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "ID inválido"})
+	}
+	req := new(models.MergeProductRequest)
+	if err := c.BodyParser(req); err != nil || req.ProductoID == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Indique en producto_id el producto a fusionar"})
+	}
+	if req.ProductoID == uint(id) {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "No se puede fusionar un producto consigo mismo"})
+	}
+
+	var res models.MergeProductResult
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Se bloquean en orden de ID para no trabarse con otra fusión de los mismos
+		var productos []models.Product
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id IN ?", []uint{uint(id), req.ProductoID}).Order("id").Find(&productos).Error; err != nil {
+			return errorHTTP(500, "Error al obtener los productos")
+		}
+		var destino, origen *models.Product
+		for i := range productos {
+			if productos[i].ID == uint(id) {
+				destino = &productos[i]
+			} else {
+				origen = &productos[i]
+			}
+		}
+		if destino == nil {
+			return errorHTTP(404, "Producto no encontrado")
+		}
+		if origen == nil {
+			return errorHTTP(404, "Producto a fusionar no encontrado")
+		}
+		if err := productoActivo(destino); err != nil {
+			return err
+		}
+		if models.NormalizarUnidad(destino.TipoCantidad) != models.NormalizarUnidad(origen.TipoCantidad) {
+			return errorHTTP(400, fmt.Sprintf("No se pueden fusionar productos en distintas unidades (%s y %s)",
+				destino.TipoCantidad, origen.TipoCantidad))
+		}
+
+		// Historial: movimientos, pedidos, remitos, facturas, compras y transformaciones
+		usos, err := historialProducto(tx, origen.ID)
+		if err != nil {
+			return err
+		}
+		for _, u := range usos {
+			if err := tx.Table(u.Tabla).Where("producto_id = ?", origen.ID).
+				Update("producto_id", destino.ID).Error; err != nil {
+				return errorHTTP(500, "Error reasignando "+u.Nombre)
+			}
+		}
+		res.Reasignados = usos
+
+		// Los códigos de barras son únicos, así que pasan todos
+		barras := tx.Model(&models.ProductBarcode{}).Where("producto_id = ?", origen.ID).Update("producto_id", destino.ID)
+		if barras.Error != nil {
+			return errorHTTP(500, "Error reasignando los códigos de barras")
+		}
+		res.CodigosBarra = barras.RowsAffected
+
+		// Las conversiones pasan si el destino no tiene una para esa unidad
+		conversiones := tx.Model(&models.UnitConversion{}).
+			Where("producto_id = ? AND unidad NOT IN (?)", origen.ID,
+				tx.Model(&models.UnitConversion{}).Select("unidad").Where("producto_id = ?", destino.ID)).
+			Update("producto_id", destino.ID)
+		if conversiones.Error != nil {
+			return errorHTTP(500, "Error reasignando las conversiones")
+		}
+		res.Conversiones = conversiones.RowsAffected
+		if err := tx.Where("producto_id = ?", origen.ID).Delete(&models.UnitConversion{}).Error; err != nil {
+			return errorHTTP(500, "Error reasignando las conversiones")
+		}
+
+		destino.Costo = costoPromedio(destino, origen)
+		if destino.PrecioVenta == 0 {
+			destino.PrecioVenta = origen.PrecioVenta
+		}
+		destino.StockInicial = models.Round3(destino.StockInicial + origen.StockInicial)
+		destino.Stock = models.Round3(destino.Stock + origen.Stock)
+		destino.Reservado = models.Round3(destino.Reservado + origen.Reservado)
+		if err := tx.Model(destino).Select("costo", "precio_venta", "stock_inicial", "stock", "reservado").
+			Updates(destino).Error; err != nil {
+			return errorHTTP(500, "Error actualizando el producto")
+		}
+
+		res.StockSumado = origen.Stock
+		ahora := time.Now()
+		origen.StockInicial, origen.Stock, origen.Reservado = 0, 0, 0
+		origen.Archivado, origen.ArchivadoEn = true, &ahora
+		if err := tx.Model(origen).Select("stock_inicial", "stock", "reservado", "archivado", "archivado_en").
+			Updates(origen).Error; err != nil {
+			return errorHTTP(500, "Error archivando el producto fusionado")
+		}
+		destino.Disponible = models.Round3(destino.Stock - destino.Reservado)
+		origen.Disponible = 0
+		res.Producto, res.Fusionado = *destino, *origen
+
+		return auditar(tx, c, models.AuditoriaProductoFusionado, "producto", destino.ID, req.Motivo, fiber.Map{
+			"fusionado_id":          origen.ID,
+			"fusionado_codigo":      origen.Codigo,
+			"fusionado_descripcion": origen.Descripcion,
+			"stock_sumado":          res.StockSumado,
+			"reasignados":           res.Reasignados,
+			"codigos_barra":         res.CodigosBarra,
+			"conversiones":          res.Conversiones,
+		})
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+	return c.JSON(res)
+}
@@ -1,10 +1,14 @@
 package controller
 
 import (
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 	"sanJoseProyect/database"
 	"sanJoseProyect/models"
 )
@@ -20,6 +24,94 @@ func parseLocalDate(dateStr string) (time.Time, error) {
 	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local), nil
 }
 
+// fechaHoy es la fecha de hoy a las 00:00, igual que las que llegan con parseLocalDate
+func fechaHoy() time.Time {
+	ahora := time.Now()
+	return time.Date(ahora.Year(), ahora.Month(), ahora.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// registrarMovimiento aplica el movimiento al stock del producto y lo crea.
+// El tipo define el signo: ENTRADA, DEVOLUCION y PRODUCCION suman; SALIDA,
+// MERMA y CONSUMO restan. SALIDA y CONSUMO sólo pueden usar el stock que no
+// está reservado. Si el movimiento trae UnidadOriginal, la cantidad se
+// convierte a la unidad de stock del producto.
+// Debe llamarse dentro de una transacción.
+func registrarMovimiento(tx *gorm.DB, mov *models.Movement) error {
+	var producto models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&producto, mov.ProductoID).Error; err != nil {
+		return errorHTTP(404, "Producto no encontrado")
+	}
+	// Lo que vuelve con una nota de crédito se recibe aunque el producto ya esté archivado
+	if !esDeNotaCredito(mov) {
+		if err := productoActivo(&producto); err != nil {
+			return err
+		}
+	}
+	if err := convertirUnidad(tx, &producto, mov); err != nil {
+		return err
+	}
+	if err := cantidadEntera(&producto, mov.Cantidad); err != nil {
+		return err
+	}
+
+	cant := abs(mov.Cantidad)
+	switch mov.Tipo {
+	case models.MovimientoEntrada, models.MovimientoDevolucion, models.MovimientoProduccion:
+		mov.Cantidad = cant // positiva
+		producto.Stock = models.Round3(producto.Stock + cant)
+	case models.MovimientoSalida, models.MovimientoMerma, models.MovimientoConsumo:
+		if producto.Stock < cant {
+			return errorHTTP(400, "Stock insuficiente")
+		}
+		// Lo reservado en pedidos no se le puede vender a otro cliente ni procesar
+		if mov.Tipo != models.MovimientoMerma && models.Round3(producto.Stock-producto.Reservado) < cant {
+			return errorHTTP(400, fmt.Sprintf("Stock insuficiente: hay %v disponibles (%v reservados en pedidos)",
+				models.Round3(producto.Stock-producto.Reservado), producto.Reservado))
+		}
+		mov.Cantidad = -cant // NEGATIVA
+		producto.Stock = models.Round3(producto.Stock - cant)
+	default:
+		return errorHTTP(400, "Tipo de movimiento inválido")
+	}
+	mov.Estado = true
+
+	if err := tx.Save(&producto).Error; err != nil {
+		return errorHTTP(500, "Error actualizando stock")
+	}
+	if err := tx.Create(mov).Error; err != nil {
+		return errorHTTP(500, "Error creando movimiento")
+	}
+	return nil
+}
+
+// quitarStock descuenta cant del stock del producto sin tocar lo reservado en
+// pedidos: se usa al revertir o achicar un ingreso y al agrandar un egreso
+func quitarStock(producto *models.Product, cant float64, sinStock string) error {
+	if producto.Stock < cant {
+		return errorHTTP(400, sinStock)
+	}
+	if disponible := models.Round3(producto.Stock - producto.Reservado); disponible < cant {
+		return errorHTTP(400, fmt.Sprintf("%s: hay %v disponibles (%v reservados en pedidos)",
+			sinStock, disponible, producto.Reservado))
+	}
+	producto.Stock = models.Round3(producto.Stock - cant)
+	return nil
+}
+
+// registrarEntrada crea un movimiento ENTRADA. Debe llamarse dentro de una transacción.
+func registrarEntrada(tx *gorm.DB, mov *models.Movement) error {
+	mov.Tipo = models.MovimientoEntrada
+	return registrarMovimiento(tx, mov)
+}
+
+// registrarSalida crea un movimiento SALIDA. Debe llamarse dentro de una transacción.
+func registrarSalida(tx *gorm.DB, mov *models.Movement) error {
+	mov.Tipo = models.MovimientoSalida
+	return registrarMovimiento(tx, mov)
+}
+
+// CreateInMovement registra una entrada. Si el número de factura ya se cargó
+// otro día (o para el mismo producto) responde 409 salvo que se fuerce.
 func CreateInMovement(c *fiber.Ctx) error {
 	req := new(models.CreateInMovementRequest)
 
@@ -31,36 +123,56 @@ func CreateInMovement(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
 	}
+	if req.PuntoVenta < 0 || req.PuntoVenta > 99999 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Punto de venta inválido"})
+	}
 
-	return database.DB.Transaction(func(tx *gorm.DB) error {
-		var producto models.Product
-		if err := tx.First(&producto, req.ProductoID).Error; err != nil {
-			return c.Status(404).JSON(models.ErrorResponse{Error: "Producto no encontrado"})
-		}
+	mov := models.Movement{
+		ProductoID:     req.ProductoID,
+		NumeroFactura:  req.NumeroFactura,
+		PuntoVenta:     req.PuntoVenta,
+		ProveedorID:    req.ProveedorID,
+		Fecha:          models.CustomDate{Time: fecha}, // ✨ Usar CustomDate
+		Descripcion:    req.Descripcion,
+		Cantidad:       req.Cantidad,
+		UnidadOriginal: req.Unidad,
+	}
 
-		mov := models.Movement{
-			ProductoID:    producto.ID,
-			NumeroFactura: req.NumeroFactura,
-			Fecha:         models.CustomDate{Time: fecha}, // ✨ Usar CustomDate
-			Descripcion:   req.Descripcion,
-			Cantidad:      req.Cantidad, // positiva
-			Tipo:          "ENTRADA",
-			Estado:        true,
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if mov.ProveedorID != nil {
+			var proveedor models.Supplier
+			if err := tx.First(&proveedor, *mov.ProveedorID).Error; err != nil {
+				return errorHTTP(404, "Proveedor no encontrado")
+			}
 		}
 
-		producto.Stock += uint(req.Cantidad)
+		var coincidencias []models.DuplicateMatch
+		if mov.NumeroFactura > 0 {
+			var err error
+			if coincidencias, err = entradasDuplicadas(tx, &mov); err != nil {
+				return err
+			}
+		}
+		auditarAlta, err := controlarDuplicado(coincidencias, req.Forzar, req.Motivo)
+		if err != nil {
+			return err
+		}
 
-		if err := tx.Save(&producto).Error; err != nil {
-			return c.Status(500).JSON(models.ErrorResponse{Error: "Error actualizando stock"})
+		if err := registrarEntrada(tx, &mov); err != nil {
+			return err
 		}
-		if err := tx.Create(&mov).Error; err != nil {
-			return c.Status(500).JSON(models.ErrorResponse{Error: "Error creando movimiento"})
+		if auditarAlta {
+			return auditarDuplicado(tx, c, "movimiento", mov.ID, req.Motivo, coincidencias)
 		}
+		return nil
+	}); err != nil {
+		return responderError(c, err)
+	}
 
-		return c.Status(201).JSON(mov)
-	})
+	return c.Status(201).JSON(mov)
 }
 
+// CreateOutMovement registra una salida suelta con su propio número de ticket
 func CreateOutMovement(c *fiber.Ctx) error {
 	req := new(models.CreateOutMovementRequest)
 
@@ -72,79 +184,190 @@ func CreateOutMovement(c *fiber.Ctx) error {
 	if err != nil {
 		return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
 	}
+	pv, err := puntoVentaValido(req.PuntoVenta)
+	if err != nil {
+		return responderError(c, err)
+	}
 
-	return database.DB.Transaction(func(tx *gorm.DB) error {
-		var producto models.Product
-		if err := tx.First(&producto, req.ProductoID).Error; err != nil {
-			return c.Status(404).JSON(models.ErrorResponse{Error: "Producto no encontrado"})
-		}
+	mov := models.Movement{
+		ProductoID:     req.ProductoID,
+		PuntoVenta:     pv,
+		Comprobante:    models.ComprobanteTicket,
+		Fecha:          models.CustomDate{Time: fecha}, // ✨ Usar CustomDate
+		Descripcion:    req.Descripcion,
+		Cantidad:       req.Cantidad,
+		UnidadOriginal: req.Unidad,
+	}
 
-		if producto.Stock < uint(req.Cantidad) {
-			return c.Status(400).JSON(models.ErrorResponse{Error: "Stock insuficiente"})
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		numero, err := siguienteNumeroTicket(tx, pv)
+		if err != nil {
+			return err
 		}
+		mov.NumeroFactura = numero
+		return registrarSalida(tx, &mov)
+	}); err != nil {
+		return responderError(c, err)
+	}
+
+	return c.JSON(mov)
+}
 
-		mov := models.Movement{
-			ProductoID:  producto.ID,
-			Fecha:       models.CustomDate{Time: fecha}, // ✨ Usar CustomDate
-			Descripcion: req.Descripcion,
-			Cantidad:    -req.Cantidad, // NEGATIVA
-			Tipo:        "SALIDA",
-			Estado:      true,
+// CreateSaleTicket registra varias salidas con un mismo número de ticket, para
+// poder agruparlas como las entradas de una factura de compra
+func CreateSaleTicket(c *fiber.Ctx) error {
+	req := new(models.CreateSaleTicketRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+	if len(req.Lineas) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "El ticket debe tener al menos una línea"})
+	}
+	for _, l := range req.Lineas {
+		if l.Cantidad <= 0 {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Cantidad inválida"})
 		}
+	}
 
-		producto.Stock -= uint(req.Cantidad)
+	fecha, err := parseLocalDate(req.Fecha)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+	}
+	pv, err := puntoVentaValido(req.PuntoVenta)
+	if err != nil {
+		return responderError(c, err)
+	}
 
-		if err := tx.Save(&producto).Error; err != nil {
-			return c.Status(500).JSON(models.ErrorResponse{Error: "Error actualizando stock"})
-		}
-		if err := tx.Create(&mov).Error; err != nil {
-			return c.Status(500).JSON(models.ErrorResponse{Error: "Error creando movimiento"})
+	ticket := models.SaleTicketResponse{
+		Comprobante: models.ComprobanteTicket,
+		PuntoVenta:  pv,
+		Fecha:       models.CustomDate{Time: fecha},
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		numero, err := siguienteNumeroTicket(tx, pv)
+		if err != nil {
+			return err
 		}
+		ticket.Numero = numero
 
-		return c.JSON(mov)
+		descripcion := req.Descripcion
+		if descripcion == "" {
+			descripcion = fmt.Sprintf("Ticket %04d-%08d", pv, numero)
+		}
+		for _, l := range req.Lineas {
+			mov := models.Movement{
+				ProductoID:     l.ProductoID,
+				NumeroFactura:  numero,
+				PuntoVenta:     pv,
+				Comprobante:    models.ComprobanteTicket,
+				Fecha:          ticket.Fecha,
+				Descripcion:    descripcion,
+				Cantidad:       l.Cantidad,
+				UnidadOriginal: l.Unidad,
+			}
+			if err := registrarSalida(tx, &mov); err != nil {
+				return err
+			}
+			ticket.Movimientos = append(ticket.Movimientos, mov)
+		}
+		return nil
 	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.Status(201).JSON(ticket)
 }
 
-// ✨ ACTUALIZADO: Solo se puede anular si Estado es TRUE
-func CancelMovement(c *fiber.Ctx) error {
-	id := c.Params("id")
+// anularMovimiento revierte el efecto del movimiento sobre el stock y lo marca
+// como anulado. Debe llamarse dentro de una transacción.
+func anularMovimiento(tx *gorm.DB, mov *models.Movement) error {
+	// ✨ SOLO SE PUEDE ANULAR SI ESTÁ ACTIVO (Estado = true)
+	if !mov.Estado {
+		return errorHTTP(400, "El movimiento ya está anulado")
+	}
 
-	return database.DB.Transaction(func(tx *gorm.DB) error {
-		var mov models.Movement
-		if err := tx.First(&mov, id).Error; err != nil {
-			return c.Status(404).JSON(models.ErrorResponse{Error: "Movimiento no encontrado"})
-		}
+	var producto models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&producto, mov.ProductoID).Error; err != nil {
+		return errorHTTP(404, "Producto no encontrado")
+	}
 
-		// ✨ SOLO SE PUEDE ANULAR SI ESTÁ ACTIVO (Estado = true)
-		if !mov.Estado {
-			return c.Status(400).JSON(models.ErrorResponse{Error: "El movimiento ya está anulado"})
-		}
+	cant := abs(mov.Cantidad)
 
-		var producto models.Product
-		if err := tx.First(&producto, mov.ProductoID).Error; err != nil {
-			return c.Status(404).JSON(models.ErrorResponse{Error: "Producto no encontrado"})
+	// ANULAR - devolver el stock
+	if mov.EsIngreso() {
+		if err := quitarStock(&producto, cant, "Stock insuficiente para anular"); err != nil {
+			return err
 		}
+	} else {
+		producto.Stock = models.Round3(producto.Stock + cant)
+	}
 
-		cant := abs(mov.Cantidad)
+	// Marcar como anulado
+	ahora := time.Now()
+	mov.Estado = false
+	mov.AnuladoEn = &ahora
 
-		// ANULAR - devolver el stock
-		if mov.Tipo == "ENTRADA" {
-			if producto.Stock < uint(cant) {
-				return c.Status(400).JSON(models.ErrorResponse{Error: "Stock insuficiente para anular"})
-			}
-			producto.Stock -= uint(cant)
-		} else {
-			producto.Stock += uint(cant)
+	if err := tx.Save(&producto).Error; err != nil {
+		return errorHTTP(500, "Error actualizando stock")
+	}
+	if err := tx.Save(mov).Error; err != nil {
+		return errorHTTP(500, "Error anulando movimiento")
+	}
+	return nil
+}
+
+// documentosMovimiento son las líneas de los comprobantes que generan
+// movimientos y tienen su propia anulación
+var documentosMovimiento = []struct{ Tabla, Columna, Documento, Nombre string }{
+	{"remito_lineas", "movimiento_id", "remito_id", "el remito"},
+	{"factura_lineas", "movimiento_id", "factura_id", "la factura o nota de crédito"},
+	{"factura_lineas", "movimiento_merma_id", "factura_id", "la nota de crédito"},
+	{"transformacion_insumos", "movimiento_id", "transformacion_id", "la transformación"},
+	{"transformacion_productos", "movimiento_id", "transformacion_id", "la transformación"},
+	{"recepcion_lineas", "movimiento_id", "recepcion_id", "la recepción"},
+}
+
+// movimientoDeDocumento rechaza anular o editar suelto un movimiento que generó
+// un comprobante: hay que anular el comprobante, que además revierte lo
+// remitido, facturado, transformado o recibido. Los tickets no tienen anulación propia.
+func movimientoDeDocumento(tx *gorm.DB, mov *models.Movement) error {
+	for _, d := range documentosMovimiento {
+		var documentoID uint
+		res := tx.Table(d.Tabla).Select(d.Documento).Where(d.Columna+" = ?", mov.ID).Limit(1).Scan(&documentoID)
+		if res.Error != nil {
+			return errorHTTP(500, "Error verificando el comprobante del movimiento")
+		}
+		if res.RowsAffected > 0 {
+			return errorHTTP(400, fmt.Sprintf("El movimiento lo generó %s #%d: anule %s para revertirlo",
+				d.Nombre, documentoID, d.Nombre))
 		}
+	}
+	if mov.Comprobante != "" && mov.Comprobante != models.ComprobanteTicket {
+		return errorHTTP(400, fmt.Sprintf("El movimiento es del comprobante %s %04d-%08d: anule el comprobante para revertirlo",
+			mov.Comprobante, mov.PuntoVenta, mov.NumeroFactura))
+	}
+	return nil
+}
 
-		// Marcar como anulado
-		mov.Estado = false
+// ✨ ACTUALIZADO: Solo se puede anular si Estado es TRUE
+func CancelMovement(c *fiber.Ctx) error {
+	id := c.Params("id")
 
-		tx.Save(&producto)
-		tx.Save(&mov)
+	var mov models.Movement
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&mov, id).Error; err != nil {
+			return errorHTTP(404, "Movimiento no encontrado")
+		}
+		if err := movimientoDeDocumento(tx, &mov); err != nil {
+			return err
+		}
+		return anularMovimiento(tx, &mov)
+	}); err != nil {
+		return responderError(c, err)
+	}
 
-		return c.JSON(mov)
-	})
+	return c.JSON(mov)
 }
 
 func UpdateMovementQuantity(c *fiber.Ctx) error {
@@ -155,42 +378,63 @@ func UpdateMovementQuantity(c *fiber.Ctx) error {
 		return c.Status(400).JSON(models.ErrorResponse{Error: "Cantidad inválida"})
 	}
 
-	return database.DB.Transaction(func(tx *gorm.DB) error {
-		var mov models.Movement
+	var mov models.Movement
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.First(&mov, id).Error; err != nil {
-			return c.Status(404).JSON(models.ErrorResponse{Error: "Movimiento no encontrado"})
+			return errorHTTP(404, "Movimiento no encontrado")
 		}
 		if !mov.Estado {
-			return c.Status(400).JSON(models.ErrorResponse{Error: "No se puede modificar un movimiento anulado"})
+			return errorHTTP(400, "No se puede modificar un movimiento anulado")
+		}
+		if err := movimientoDeDocumento(tx, &mov); err != nil {
+			return err
 		}
 
 		var producto models.Product
-		tx.First(&producto, mov.ProductoID)
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&producto, mov.ProductoID).Error; err != nil {
+			return errorHTTP(404, "Producto no encontrado")
+		}
+		if err := cantidadEntera(&producto, req.Cantidad); err != nil {
+			return err
+		}
 
 		oldAbs := abs(mov.Cantidad)
 		newAbs := req.Cantidad
+		diff := models.Round3(newAbs - oldAbs)
 
-		if mov.Tipo == "ENTRADA" {
-			diff := newAbs - oldAbs
-			if diff < 0 && producto.Stock < uint(-diff) {
-				return c.Status(400).JSON(models.ErrorResponse{Error: "Stock insuficiente"})
+		// Achicar un ingreso o agrandar un egreso no puede tomar lo reservado,
+		// salvo la merma, que como al registrarla puede ser mercadería apartada
+		if mov.EsIngreso() {
+			diff = -diff
+		}
+		switch {
+		case diff > 0 && mov.Tipo != models.MovimientoMerma:
+			if err := quitarStock(&producto, diff, "Stock insuficiente"); err != nil {
+				return err
 			}
-			producto.Stock += uint(diff)
+		case diff > producto.Stock:
+			return errorHTTP(400, "Stock insuficiente")
+		default:
+			producto.Stock = models.Round3(producto.Stock - diff)
+		}
+		if mov.EsIngreso() {
 			mov.Cantidad = newAbs
 		} else {
-			diff := newAbs - oldAbs
-			if diff > 0 && producto.Stock < uint(diff) {
-				return c.Status(400).JSON(models.ErrorResponse{Error: "Stock insuficiente"})
-			}
-			producto.Stock -= uint(diff)
 			mov.Cantidad = -newAbs
 		}
+		// La cantidad nueva viene en unidad de stock: lo cargado originalmente ya no aplica
+		mov.UnidadOriginal, mov.CantidadOriginal = "", 0
 
-		tx.Save(&producto)
-		tx.Save(&mov)
-
-		return c.JSON(mov)
+		if err := tx.Save(&producto).Error; err != nil {
+			return errorHTTP(500, "Error actualizando stock")
+		}
+		return tx.Save(&mov).Error
 	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.JSON(mov)
 }
 
 // ✨ NUEVO: Cambiar el producto dentro de un movimiento
@@ -198,63 +442,89 @@ func UpdateMovementProduct(c *fiber.Ctx) error {
 	id := c.Params("id")
 	req := new(models.UpdateMovementProductRequest)
 
-	if err := c.BodyParser(req); err != nil {
+	if err := c.BodyParser(req); err != nil || req.Cantidad <= 0 {
 		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
 	}
 
-	return database.DB.Transaction(func(tx *gorm.DB) error {
-		var mov models.Movement
+	var mov models.Movement
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
 		if err := tx.First(&mov, id).Error; err != nil {
-			return c.Status(404).JSON(models.ErrorResponse{Error: "Movimiento no encontrado"})
+			return errorHTTP(404, "Movimiento no encontrado")
 		}
 
 		if !mov.Estado {
-			return c.Status(400).JSON(models.ErrorResponse{Error: "No se puede modificar un movimiento anulado"})
+			return errorHTTP(400, "No se puede modificar un movimiento anulado")
+		}
+		if err := movimientoDeDocumento(tx, &mov); err != nil {
+			return err
 		}
 
 		// Devolver stock del producto anterior
 		var productoAnterior models.Product
-		if err := tx.First(&productoAnterior, mov.ProductoID).Error; err != nil {
-			return c.Status(404).JSON(models.ErrorResponse{Error: "Producto anterior no encontrado"})
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&productoAnterior, mov.ProductoID).Error; err != nil {
+			return errorHTTP(404, "Producto anterior no encontrado")
 		}
 
+		ingreso := mov.EsIngreso()
 		cantAnterior := abs(mov.Cantidad)
-		if mov.Tipo == "ENTRADA" {
-			if productoAnterior.Stock < uint(cantAnterior) {
-				return c.Status(400).JSON(models.ErrorResponse{Error: "Stock insuficiente en producto anterior"})
+		if ingreso {
+			if err := quitarStock(&productoAnterior, cantAnterior, "Stock insuficiente en producto anterior"); err != nil {
+				return err
 			}
-			productoAnterior.Stock -= uint(cantAnterior)
 		} else {
-			productoAnterior.Stock += uint(cantAnterior)
+			productoAnterior.Stock = models.Round3(productoAnterior.Stock + cantAnterior)
+		}
+		if err := tx.Save(&productoAnterior).Error; err != nil {
+			return errorHTTP(500, "Error actualizando stock")
 		}
-		tx.Save(&productoAnterior)
 
 		// Aplicar al nuevo producto
 		var productoNuevo models.Product
-		if err := tx.First(&productoNuevo, req.ProductoID).Error; err != nil {
-			return c.Status(404).JSON(models.ErrorResponse{Error: "Producto nuevo no encontrado"})
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&productoNuevo, req.ProductoID).Error; err != nil {
+			return errorHTTP(404, "Producto nuevo no encontrado")
+		}
+		if err := productoActivo(&productoNuevo); err != nil {
+			return err
+		}
+		if err := cantidadEntera(&productoNuevo, req.Cantidad); err != nil {
+			return err
 		}
 
-		if mov.Tipo == "ENTRADA" {
-			productoNuevo.Stock += uint(req.Cantidad)
+		switch {
+		case ingreso:
+			productoNuevo.Stock = models.Round3(productoNuevo.Stock + req.Cantidad)
 			mov.Cantidad = req.Cantidad
-		} else {
-			if productoNuevo.Stock < uint(req.Cantidad) {
-				return c.Status(400).JSON(models.ErrorResponse{Error: "Stock insuficiente en producto nuevo"})
+		case mov.Tipo == models.MovimientoMerma:
+			if productoNuevo.Stock < req.Cantidad {
+				return errorHTTP(400, "Stock insuficiente en producto nuevo")
+			}
+			productoNuevo.Stock = models.Round3(productoNuevo.Stock - req.Cantidad)
+			mov.Cantidad = -req.Cantidad
+		default:
+			if err := quitarStock(&productoNuevo, req.Cantidad, "Stock insuficiente en producto nuevo"); err != nil {
+				return err
 			}
-			productoNuevo.Stock -= uint(req.Cantidad)
 			mov.Cantidad = -req.Cantidad
 		}
 
 		mov.ProductoID = req.ProductoID
-		tx.Save(&productoNuevo)
-		tx.Save(&mov)
+		mov.Producto = nil
+		mov.UnidadOriginal, mov.CantidadOriginal = "", 0
+		if err := tx.Save(&productoNuevo).Error; err != nil {
+			return errorHTTP(500, "Error actualizando stock")
+		}
+		if err := tx.Save(&mov).Error; err != nil {
+			return errorHTTP(500, "Error actualizando movimiento")
+		}
 
 		// Cargar el producto en la respuesta
-		tx.Preload("Producto").First(&mov, mov.ID)
-
-		return c.JSON(mov)
+		return tx.Preload("Producto").First(&mov, mov.ID).Error
 	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.JSON(mov)
 }
 
 // ============================================
@@ -271,6 +541,39 @@ func GetMovements(c *fiber.Ctx) error {
 	return c.JSON(movimientos)
 }
 
+// GetMovementsByDocument busca los movimientos de un comprobante de venta por
+// su número. Por defecto busca tickets del punto de venta configurado.
+func GetMovementsByDocument(c *fiber.Ctx) error {
+	numero, err := strconv.Atoi(c.Params("numero"))
+	if err != nil || numero <= 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Número inválido"})
+	}
+	pv, err := puntoVentaValido(c.QueryInt("punto_venta"))
+	if err != nil {
+		return responderError(c, err)
+	}
+	comprobante := strings.ToUpper(c.Query("comprobante", models.ComprobanteTicket))
+
+	var movimientos []models.Movement
+	if err := database.DB.Preload("Producto").
+		Where("comprobante = ? AND punto_venta = ? AND numero_factura = ?", comprobante, pv, numero).
+		Order("id").
+		Find(&movimientos).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo movimientos"})
+	}
+	if len(movimientos) == 0 {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Comprobante no encontrado"})
+	}
+
+	return c.JSON(models.SaleTicketResponse{
+		Comprobante: comprobante,
+		PuntoVenta:  pv,
+		Numero:      numero,
+		Fecha:       movimientos[0].Fecha,
+		Movimientos: movimientos,
+	})
+}
+
 func GetMovementByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 
@@ -282,7 +585,7 @@ func GetMovementByID(c *fiber.Ctx) error {
 	return c.JSON(movimiento)
 }
 
-func abs(x int) int {
+func abs(x float64) float64 {
 	if x < 0 {
 		return -x
 	}
@@ -0,0 +1,107 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"sanJoseProyect/afip"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+	"sanJoseProyect/pdf"
+)
+
+// enviarPDF responde el documento para verlo en el navegador o descargarlo
+func enviarPDF(c *fiber.Ctx, nombre string, contenido []byte) error {
+	c.Set(fiber.HeaderContentType, "application/pdf")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`inline; filename="%s"`, nombre))
+	return c.Send(contenido)
+}
+
+// qrFactura arma la URL del QR de AFIP; vacía si la factura todavía no tiene CAE
+func qrFactura(f *models.Invoice, emp pdf.Empresa) string {
+	if f.CAE == "" {
+		return ""
+	}
+	cuitEmisor, _ := strconv.ParseInt(soloDigitos(emp.CUIT), 10, 64)
+	datos := afip.DatosQR{
+		Fecha:           f.Fecha.Time,
+		CUITEmisor:      cuitEmisor,
+		PuntoVenta:      f.PuntoVenta,
+		TipoComprobante: tipoComprobanteAFIP(f.Tipo, f.Letra),
+		Numero:          f.NumeroComprobante,
+		Importe:         f.Total,
+		DocTipo:         afip.DocConsumidorFinal,
+		CAE:             f.CAE,
+	}
+	if f.Cliente != nil {
+		if cuit := soloDigitos(f.Cliente.CUIT); cuit != "" {
+			datos.DocTipo = afip.DocCUIT
+			datos.DocNro, _ = strconv.ParseInt(cuit, 10, 64)
+		}
+	}
+	return afip.URLQR(datos)
+}
+
+// ============================================
+// PDF
+// ============================================
+
+func GetInvoicePDF(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var factura models.Invoice
+	if err := database.DB.Preload("Cliente").Preload("Lineas").Preload("Lineas.Producto").
+		Preload("FacturaAsociada").
+		First(&factura, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Factura no encontrada"})
+	}
+	if !factura.EsElectronica() {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Sólo se imprimen facturas de venta y notas de crédito"})
+	}
+
+	emp := pdf.EmpresaDesdeEntorno()
+	contenido, err := pdf.Factura(&factura, emp, qrFactura(&factura, emp))
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error generando el PDF"})
+	}
+	nombre := "factura"
+	if factura.Tipo == models.NotaCredito {
+		nombre = "nota-credito"
+	}
+	return enviarPDF(c, fmt.Sprintf("%s-%s-%04d-%08d.pdf", nombre, factura.Letra, factura.PuntoVenta, factura.NumeroFiscal()), contenido)
+}
+
+func GetDeliveryNotePDF(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var remito models.DeliveryNote
+	if err := database.DB.Preload("Cliente").Preload("Lineas").Preload("Lineas.Producto").
+		First(&remito, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Remito no encontrado"})
+	}
+
+	contenido, err := pdf.Remito(&remito, pdf.EmpresaDesdeEntorno())
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error generando el PDF"})
+	}
+	return enviarPDF(c, fmt.Sprintf("remito-%04d-%08d.pdf", remito.PuntoVenta, remito.Numero), contenido)
+}
+
+// GetProductsPDF imprime el mismo listado que devuelve GetProducts
+func GetProductsPDF(c *fiber.Ctx) error {
+	query, err := filtrosProductos(c)
+	if err != nil {
+		return responderError(c, err)
+	}
+	var productos []models.Product
+	if err := query.Order("codigo").Find(&productos).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error al obtener productos"})
+	}
+
+	contenido, err := pdf.Stock(productos, pdf.EmpresaDesdeEntorno())
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error generando el PDF"})
+	}
+	return enviarPDF(c, "stock.pdf", contenido)
+}
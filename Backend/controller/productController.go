@@ -1,11 +1,60 @@
 package controller
 
 import (
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 	"sanJoseProyect/database"
 	"sanJoseProyect/models"
 )
 
+// actualizarCosto guarda el último costo unitario conocido del producto (compras y transformaciones)
+func actualizarCosto(tx *gorm.DB, productoID uint, costo float64) error {
+	if costo <= 0 {
+		return nil
+	}
+	return tx.Model(&models.Product{}).Where("id = ?", productoID).Update("costo", models.Round2(costo)).Error
+}
+
+// validarNivelesStock controla que los niveles no sean negativos, que el
+// máximo no quede por debajo del mínimo y que exista el proveedor habitual
+func validarNivelesStock(minimo, maximo float64, proveedorID *uint) error {
+	if minimo < 0 || maximo < 0 {
+		return errorHTTP(400, "El stock mínimo y el máximo no pueden ser negativos")
+	}
+	if maximo > 0 && maximo < minimo {
+		return errorHTTP(400, "El stock máximo no puede ser menor que el mínimo")
+	}
+	if proveedorID != nil {
+		var proveedor models.Supplier
+		if err := database.DB.First(&proveedor, *proveedorID).Error; err != nil {
+			return errorHTTP(404, "Proveedor no encontrado")
+		}
+	}
+	return nil
+}
+
+// validarCatalogo controla la categoría, presentación y origen, y que exista la especie
+func validarCatalogo(categoria, presentacion, origen string, especieID *uint) error {
+	if categoria != "" && !models.CategoriaValida(categoria) {
+		return errorHTTP(400, "Categoría inválida (PESCADO_FRESCO, CONGELADO, MARISCOS o ELABORADOS)")
+	}
+	if presentacion != "" && !models.PresentacionValida(presentacion) {
+		return errorHTTP(400, "Presentación inválida (ENTERO, FILET o DESPINADO)")
+	}
+	if origen != "" && !models.OrigenValido(origen) {
+		return errorHTTP(400, "Origen inválido (MAR o CRIADERO)")
+	}
+	if especieID != nil {
+		var especie models.Species
+		if err := database.DB.First(&especie, *especieID).Error; err != nil {
+			return errorHTTP(404, "Especie no encontrada")
+		}
+	}
+	return nil
+}
+
 // ============================================
 // PRODUCTOS
 // ============================================
@@ -22,11 +71,48 @@ func CreateProduct(c *fiber.Ctx) error {
 			Error: "La descripción es obligatoria",
 		})
 	}
+	req.TipoCantidad = models.NormalizarUnidad(req.TipoCantidad)
+	if req.TipoCantidad == "" {
+		req.TipoCantidad = models.UnidadUnidades
+	}
+	if !models.TipoCantidadValido(req.TipoCantidad) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Unidad inválida: use unidades, cajas o kg",
+		})
+	}
+	if req.AlicuotaIVA == "" {
+		req.AlicuotaIVA = models.IVA21
+	}
+	if _, ok := models.TasaIVA(req.AlicuotaIVA); !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Alícuota de IVA inválida",
+		})
+	}
+	if err := validarNivelesStock(req.StockMinimo, req.StockMaximo, req.ProveedorID); err != nil {
+		return responderError(c, err)
+	}
+	if err := validarCatalogo(req.Categoria, req.Presentacion, req.Origen, req.EspecieID); err != nil {
+		return responderError(c, err)
+	}
+	if req.PrecioVenta < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "El precio de venta no puede ser negativo",
+		})
+	}
+	if req.Stock < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "El stock no puede ser negativo",
+		})
+	}
 	// Verificar que el código no exista
 	var existente models.Product
 	if err := database.DB.Where("codigo = ?", req.Codigo).First(&existente).Error; err == nil {
+		mensaje := "El código de producto ya existe"
+		if existente.Archivado {
+			mensaje += " en un producto archivado: restáurelo en lugar de crearlo de nuevo"
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
-			Error: "El código de producto ya existe",
+			Error: mensaje,
 		})
 	}
 
@@ -34,9 +120,21 @@ func CreateProduct(c *fiber.Ctx) error {
 	producto := models.Product{
 		Codigo:       req.Codigo,
 		Descripcion:  req.Descripcion,
-		StockInicial: req.Stock, // ⭐ El stock inicial es el valor que ingresa
-		Stock:        req.Stock, // ⭐ El stock actual empieza igual
+		StockInicial: models.Round3(req.Stock), // ⭐ El stock inicial es el valor que ingresa
+		Stock:        models.Round3(req.Stock), // ⭐ El stock actual empieza igual
 		TipoCantidad: req.TipoCantidad,
+		AlicuotaIVA:  req.AlicuotaIVA,
+		PrecioVenta:  models.Round2(req.PrecioVenta),
+		StockMinimo:  models.Round3(req.StockMinimo),
+		StockMaximo:  models.Round3(req.StockMaximo),
+		ProveedorID:  req.ProveedorID,
+		Categoria:    req.Categoria,
+		EspecieID:    req.EspecieID,
+		Presentacion: req.Presentacion,
+		Origen:       req.Origen,
+		ZonaCaptura:  req.ZonaCaptura,
+		Congelado:    req.Congelado,
+		VidaUtilDias: req.VidaUtilDias,
 	}
 
 	if err := database.DB.Create(&producto).Error; err != nil {
@@ -47,9 +145,67 @@ func CreateProduct(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusCreated).JSON(producto)
 }
 
+// filtrosProductos arma la consulta de productos con los filtros del
+// catálogo: categoria, especie_id, presentacion, origen, zona_captura y
+// congelado. Los archivados sólo aparecen con ?archivados=true (únicamente
+// ellos) o ?archivados=todos.
+func filtrosProductos(c *fiber.Ctx) (*gorm.DB, error) {
+	query := database.DB.Model(&models.Product{})
+
+	switch c.Query("archivados") {
+	case "", "false":
+		query = query.Where("productos.archivado = ?", false)
+	case "true":
+		query = query.Where("productos.archivado = ?", true)
+	case "todos":
+	default:
+		return nil, errorHTTP(fiber.StatusBadRequest, "archivados debe ser true, false o todos")
+	}
+
+	categoria, presentacion, origen := c.Query("categoria"), c.Query("presentacion"), c.Query("origen")
+	if err := validarCatalogo(categoria, presentacion, origen, nil); err != nil {
+		return nil, err
+	}
+	if categoria != "" {
+		query = query.Where("productos.categoria = ?", categoria)
+	}
+	if presentacion != "" {
+		query = query.Where("productos.presentacion = ?", presentacion)
+	}
+	if origen != "" {
+		query = query.Where("productos.origen = ?", origen)
+	}
+	if especieID := c.Query("especie_id"); especieID != "" {
+		query = query.Where("productos.especie_id = ?", especieID)
+	}
+	if zona := c.Query("zona_captura"); zona != "" {
+		query = query.Where("productos.zona_captura ILIKE ?", "%"+zona+"%")
+	}
+	switch c.Query("congelado") {
+	case "":
+	case "true":
+		query = query.Where("productos.congelado = ?", true)
+	case "false":
+		query = query.Where("productos.congelado = ?", false)
+	default:
+		return nil, errorHTTP(fiber.StatusBadRequest, "congelado debe ser true o false")
+	}
+	return query, nil
+}
+
+// GetProducts lista los productos, opcionalmente filtrados por catálogo. Con
+// ?q= busca por descripción, código y códigos de barras (ver buscarProductos).
 func GetProducts(c *fiber.Ctx) error {
+	query, err := filtrosProductos(c)
+	if err != nil {
+		return responderError(c, err)
+	}
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		return buscarProductos(c, query, q)
+	}
+
 	var productos []models.Product
-	if err := database.DB.Find(&productos).Error; err != nil {
+	if err := query.Preload("Especie").Order("codigo").Find(&productos).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
 			Error: "Error al obtener productos",
 		})
@@ -60,7 +216,7 @@ func GetProducts(c *fiber.Ctx) error {
 func GetProductByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 	var producto models.Product
-	if err := database.DB.First(&producto, id).Error; err != nil {
+	if err := database.DB.Preload("Conversiones").Preload("CodigosBarra").Preload("Especie").First(&producto, id).Error; err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
 			Error: "Producto no encontrado",
 		})
@@ -95,6 +251,9 @@ func UpdateProduct(c *fiber.Ctx) error {
 		})
 	}
 
+	// Sólo se escriben las columnas que vienen en la solicitud
+	cambios := map[string]interface{}{}
+
 	// Si se envía un nuevo código, verificar que no exista
 	if req.Codigo != nil && *req.Codigo != producto.Codigo {
 		var existente models.Product
@@ -103,21 +262,91 @@ func UpdateProduct(c *fiber.Ctx) error {
 				Error: "El código de producto ya existe",
 			})
 		}
-		producto.Codigo = *req.Codigo
+		cambios["codigo"] = *req.Codigo
 	}
 
 	if req.Descripcion != "" {
-		producto.Descripcion = req.Descripcion
+		cambios["descripcion"] = req.Descripcion
 	}
 
-	if req.Stock > 0 {
-		producto.Stock = req.Stock
+	// El stock sólo cambia con movimientos, que quedan en el kardex y respetan lo reservado
+	if req.Stock != 0 && models.Round3(req.Stock) != producto.Stock {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "El stock no se edita: registre una entrada, una salida o una merma",
+		})
 	}
 
-	// ⚠️ NOTA: El stock_inicial NO se actualiza aquí
-	// Solo se actualiza el stock actual
+	if req.AlicuotaIVA != "" {
+		if _, ok := models.TasaIVA(req.AlicuotaIVA); !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "Alícuota de IVA inválida",
+			})
+		}
+		cambios["alicuota_iva"] = req.AlicuotaIVA
+	}
 
-	if err := database.DB.Save(&producto).Error; err != nil {
+	if req.PrecioVenta != nil {
+		if *req.PrecioVenta < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "El precio de venta no puede ser negativo",
+			})
+		}
+		cambios["precio_venta"] = models.Round2(*req.PrecioVenta)
+	}
+
+	if req.StockMinimo != nil {
+		producto.StockMinimo = models.Round3(*req.StockMinimo)
+		cambios["stock_minimo"] = producto.StockMinimo
+	}
+	if req.StockMaximo != nil {
+		producto.StockMaximo = models.Round3(*req.StockMaximo)
+		cambios["stock_maximo"] = producto.StockMaximo
+	}
+	if req.ProveedorID != nil {
+		producto.ProveedorID = req.ProveedorID
+		cambios["proveedor_id"] = *req.ProveedorID
+	}
+	if err := validarNivelesStock(producto.StockMinimo, producto.StockMaximo, producto.ProveedorID); err != nil {
+		return responderError(c, err)
+	}
+
+	if err := validarCatalogo(req.Categoria, req.Presentacion, req.Origen, req.EspecieID); err != nil {
+		return responderError(c, err)
+	}
+	if req.Categoria != "" {
+		cambios["categoria"] = req.Categoria
+	}
+	if req.EspecieID != nil {
+		cambios["especie_id"] = *req.EspecieID
+	}
+	if req.Presentacion != "" {
+		cambios["presentacion"] = req.Presentacion
+	}
+	if req.Origen != "" {
+		cambios["origen"] = req.Origen
+	}
+	if req.ZonaCaptura != "" {
+		cambios["zona_captura"] = req.ZonaCaptura
+	}
+	if req.Congelado != nil {
+		cambios["congelado"] = *req.Congelado
+	}
+	if req.VidaUtilDias != nil {
+		cambios["vida_util_dias"] = *req.VidaUtilDias
+	}
+
+	// ⚠️ NOTA: No se guarda el producto entero: el stock, lo reservado, el costo
+	// y el archivado cambian con movimientos, compras y archivos registrados
+	// mientras tanto, y un Save los pisaría con lo leído al principio
+
+	if len(cambios) > 0 {
+		if err := database.DB.Model(&models.Product{}).Where("id = ?", producto.ID).Updates(cambios).Error; err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+				Error: "Error al actualizar producto",
+			})
+		}
+	}
+	if err := database.DB.First(&producto, producto.ID).Error; err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
 			Error: "Error al actualizar producto",
 		})
@@ -126,6 +355,9 @@ func UpdateProduct(c *fiber.Ctx) error {
 	return c.JSON(producto)
 }
 
+// DeleteProduct borra un producto cargado por error. Si ya tiene historial
+// (movimientos, comprobantes, transformaciones) responde 409 con el detalle:
+// esos productos se archivan.
 func DeleteProduct(c *fiber.Ctx) error {
 	id := c.Params("id")
 
@@ -136,7 +368,35 @@ func DeleteProduct(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := database.DB.Delete(&producto).Error; err != nil {
+	usos, err := historialProducto(database.DB, producto.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Error al verificar el historial del producto",
+		})
+	}
+	if len(usos) > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":       "El producto tiene historial y no se puede eliminar: archívelo para dejar de usarlo",
+			"referencias": usos,
+		})
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("producto_id = ?", producto.ID).Delete(&models.UnitConversion{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("producto_id = ?", producto.ID).Delete(&models.ProductBarcode{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Delete(&producto).Error; err != nil {
+			return err
+		}
+		return auditar(tx, c, models.AuditoriaProductoEliminado, "producto", producto.ID, "", fiber.Map{
+			"codigo":      producto.Codigo,
+			"descripcion": producto.Descripcion,
+		})
+	})
+	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
 			Error: "Error al eliminar producto",
 		})
@@ -0,0 +1,402 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// ============================================
+// ÓRDENES DE COMPRA
+// ============================================
+
+func CreatePurchaseOrder(c *fiber.Ctx) error {
+	req := new(models.CreatePurchaseOrderRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+	if len(req.Lineas) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "La orden debe tener al menos una línea"})
+	}
+	for _, l := range req.Lineas {
+		if l.Cantidad <= 0 || l.PrecioUnitario < 0 {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Cantidad o precio inválidos"})
+		}
+	}
+
+	fecha := fechaHoy()
+	if req.Fecha != "" {
+		var err error
+		if fecha, err = parseLocalDate(req.Fecha); err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+		}
+	}
+	orden := models.PurchaseOrder{
+		Fecha:       models.CustomDate{Time: fecha},
+		Descripcion: req.Descripcion,
+		Estado:      models.OrdenPendiente,
+	}
+	if req.FechaEsperada != "" {
+		esperada, err := parseLocalDate(req.FechaEsperada)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha esperada inválido"})
+		}
+		orden.FechaEsperada = &models.CustomDate{Time: esperada}
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var proveedor models.Supplier
+		if err := tx.First(&proveedor, req.ProveedorID).Error; err != nil {
+			return errorHTTP(404, "Proveedor no encontrado")
+		}
+		orden.ProveedorID = proveedor.ID
+
+		for _, l := range req.Lineas {
+			var producto models.Product
+			if err := tx.First(&producto, l.ProductoID).Error; err != nil {
+				return errorHTTP(404, "Producto no encontrado")
+			}
+			if err := productoActivo(&producto); err != nil {
+				return err
+			}
+			orden.Lineas = append(orden.Lineas, models.PurchaseOrderLine{
+				ProductoID:     producto.ID,
+				CantidadPedida: l.Cantidad,
+				PrecioUnitario: models.Round2(l.PrecioUnitario),
+			})
+		}
+
+		if err := tx.Create(&orden).Error; err != nil {
+			return errorHTTP(500, "Error al crear la orden de compra")
+		}
+		return nil
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.Status(201).JSON(orden)
+}
+
+func GetPurchaseOrders(c *fiber.Ctx) error {
+	query := database.DB.Preload("Proveedor").Preload("Lineas").Order("fecha desc, id desc")
+
+	if estado := c.Query("estado"); estado != "" {
+		query = query.Where("estado = ?", estado)
+	}
+	if proveedorID := c.Query("proveedor_id"); proveedorID != "" {
+		query = query.Where("proveedor_id = ?", proveedorID)
+	}
+
+	var ordenes []models.PurchaseOrder
+	if err := query.Find(&ordenes).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo órdenes de compra"})
+	}
+	return c.JSON(ordenes)
+}
+
+func GetPurchaseOrderByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var orden models.PurchaseOrder
+	if err := database.DB.Preload("Proveedor").Preload("Lineas").Preload("Lineas.Producto").
+		Preload("Recepciones").Preload("Recepciones.Lineas").
+		First(&orden, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Orden de compra no encontrada"})
+	}
+	return c.JSON(orden)
+}
+
+// cantidadRecibida pasa lo recibido a la unidad de stock; cero es que no llegó nada
+func cantidadRecibida(tx *gorm.DB, producto *models.Product, cantidad float64, unidad string) (float64, error) {
+	if cantidad == 0 {
+		return 0, nil
+	}
+	return cantidadEnStock(tx, producto, cantidad, unidad)
+}
+
+// ReceivePurchaseOrder registra una entrega contra la orden. Cada línea recibida
+// entra al stock con un movimiento ENTRADA, igual que una entrada manual, y
+// guarda la diferencia contra lo pendiente y contra lo declarado por el proveedor.
+func ReceivePurchaseOrder(c *fiber.Ctx) error {
+	id := c.Params("id")
+	req := new(models.ReceivePurchaseOrderRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+	if len(req.Lineas) == 0 && !req.Cerrar {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Indique las líneas recibidas"})
+	}
+	for _, l := range req.Lineas {
+		if l.Cantidad < 0 || (l.Declarada != nil && *l.Declarada < 0) {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Cantidad inválida"})
+		}
+	}
+
+	if req.PuntoVenta < 0 || req.PuntoVenta > 99999 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Punto de venta inválido"})
+	}
+
+	fecha := fechaHoy()
+	if req.Fecha != "" {
+		var err error
+		if fecha, err = parseLocalDate(req.Fecha); err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+		}
+	}
+
+	var orden models.PurchaseOrder
+	var recepcion models.Receipt
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&orden, id).Error; err != nil {
+			return errorHTTP(404, "Orden de compra no encontrada")
+		}
+		if orden.Estado == models.OrdenCerrada || orden.Estado == models.OrdenAnulada {
+			return errorHTTP(400, "La orden de compra está "+orden.Estado)
+		}
+		if err := tx.Where("orden_id = ?", orden.ID).Order("id").Find(&orden.Lineas).Error; err != nil {
+			return err
+		}
+		lineasOrden := map[uint]*models.PurchaseOrderLine{}
+		for i := range orden.Lineas {
+			lineasOrden[orden.Lineas[i].ID] = &orden.Lineas[i]
+		}
+
+		recepcion = models.Receipt{
+			OrdenID:       orden.ID,
+			Fecha:         models.CustomDate{Time: fecha},
+			NumeroFactura: req.NumeroFactura,
+			PuntoVenta:    req.PuntoVenta,
+			Descripcion:   req.Descripcion,
+			Estado:        true,
+		}
+		descripcion := recepcion.Descripcion
+		if descripcion == "" {
+			descripcion = fmt.Sprintf("Recepción orden de compra #%d", orden.ID)
+		}
+
+		// Se arman todos los movimientos antes de registrar ninguno, para que el
+		// control de duplicados no compare la entrega consigo misma
+		var movimientos []models.Movement
+		for _, l := range req.Lineas {
+			lo, ok := lineasOrden[l.OrdenLineaID]
+			if !ok {
+				return errorHTTP(400, "La línea no pertenece a la orden de compra")
+			}
+			declarada := l.Cantidad
+			if l.Declarada != nil {
+				declarada = *l.Declarada
+			}
+			// La orden se lleva en la unidad de stock: lo recibido en otra unidad
+			// (por ejemplo cajas) se convierte antes de compararlo con lo pendiente
+			var producto models.Product
+			if err := tx.First(&producto, lo.ProductoID).Error; err != nil {
+				return errorHTTP(404, "Producto no encontrado")
+			}
+			cantidad, err := cantidadRecibida(tx, &producto, l.Cantidad, l.Unidad)
+			if err != nil {
+				return err
+			}
+			if declarada, err = cantidadRecibida(tx, &producto, declarada, l.Unidad); err != nil {
+				return err
+			}
+			linea := models.ReceiptLine{
+				OrdenLineaID:        lo.ID,
+				ProductoID:          lo.ProductoID,
+				Pendiente:           lo.Pendiente(),
+				Cantidad:            cantidad,
+				Declarada:           declarada,
+				Diferencia:          models.Round3(cantidad - lo.Pendiente()),
+				DiferenciaDeclarada: models.Round3(cantidad - declarada),
+				Observacion:         l.Observacion,
+			}
+			if cantidad != l.Cantidad {
+				linea.UnidadOriginal = models.NormalizarUnidad(l.Unidad)
+				linea.CantidadOriginal = l.Cantidad
+			}
+			recepcion.Lineas = append(recepcion.Lineas, linea)
+			lo.CantidadRecibida = models.Round3(lo.CantidadRecibida + cantidad)
+
+			movimientos = append(movimientos, models.Movement{
+				ProductoID:     lo.ProductoID,
+				NumeroFactura:  req.NumeroFactura,
+				PuntoVenta:     req.PuntoVenta,
+				ProveedorID:    &orden.ProveedorID,
+				Fecha:          recepcion.Fecha,
+				Descripcion:    descripcion,
+				Cantidad:       l.Cantidad,
+				UnidadOriginal: l.Unidad,
+			})
+		}
+
+		var coincidencias []models.DuplicateMatch
+		if req.NumeroFactura > 0 {
+			for i := range movimientos {
+				encontradas, err := entradasDuplicadas(tx, &movimientos[i])
+				if err != nil {
+					return err
+				}
+				coincidencias = append(coincidencias, encontradas...)
+			}
+		}
+		auditarAlta, err := controlarDuplicado(coincidencias, req.Forzar, req.Motivo)
+		if err != nil {
+			return err
+		}
+
+		for i := range movimientos {
+			// Lo que no llegó no genera movimiento, pero queda registrado el faltante
+			if movimientos[i].Cantidad == 0 {
+				continue
+			}
+			if err := registrarEntrada(tx, &movimientos[i]); err != nil {
+				return err
+			}
+			recepcion.Lineas[i].MovimientoID = &movimientos[i].ID
+			lo := lineasOrden[recepcion.Lineas[i].OrdenLineaID]
+			if err := actualizarCosto(tx, lo.ProductoID, lo.PrecioUnitario); err != nil {
+				return err
+			}
+		}
+
+		if len(recepcion.Lineas) > 0 {
+			if err := tx.Create(&recepcion).Error; err != nil {
+				return errorHTTP(500, "Error al registrar la recepción")
+			}
+		}
+		for _, lo := range lineasOrden {
+			if err := tx.Model(lo).Update("cantidad_recibida", lo.CantidadRecibida).Error; err != nil {
+				return err
+			}
+		}
+
+		orden.ActualizarEstado()
+		if req.Cerrar {
+			orden.Estado = models.OrdenCerrada
+		}
+		if err := tx.Model(&orden).Update("estado", orden.Estado).Error; err != nil {
+			return err
+		}
+		if auditarAlta {
+			return auditarDuplicado(tx, c, "recepcion", recepcion.ID, req.Motivo, coincidencias)
+		}
+		return nil
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"orden":     orden,
+		"recepcion": recepcion,
+	})
+}
+
+// CancelPurchaseReceipt anula una recepción: anula sus entradas y descuenta lo
+// recibido de la orden, que vuelve a quedar pendiente de esa mercadería aunque
+// se hubiera cerrado. El costo de los productos no se revierte.
+func CancelPurchaseReceipt(c *fiber.Ctx) error {
+	id := c.Params("id")
+	recepcionID := c.Params("recepcion_id")
+
+	var orden models.PurchaseOrder
+	var recepcion models.Receipt
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&orden, id).Error; err != nil {
+			return errorHTTP(404, "Orden de compra no encontrada")
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("Lineas").
+			Where("orden_id = ?", orden.ID).First(&recepcion, recepcionID).Error; err != nil {
+			return errorHTTP(404, "Recepción no encontrada")
+		}
+		if !recepcion.Estado {
+			return errorHTTP(400, "La recepción ya está anulada")
+		}
+		if err := tx.Where("orden_id = ?", orden.ID).Order("id").Find(&orden.Lineas).Error; err != nil {
+			return err
+		}
+		lineasOrden := map[uint]*models.PurchaseOrderLine{}
+		for i := range orden.Lineas {
+			lineasOrden[orden.Lineas[i].ID] = &orden.Lineas[i]
+		}
+
+		for _, l := range recepcion.Lineas {
+			if l.MovimientoID != nil {
+				var mov models.Movement
+				if err := tx.First(&mov, *l.MovimientoID).Error; err != nil {
+					return errorHTTP(404, "Movimiento no encontrado")
+				}
+				if mov.Estado {
+					if err := anularMovimiento(tx, &mov); err != nil {
+						return err
+					}
+				}
+			}
+			lo, ok := lineasOrden[l.OrdenLineaID]
+			if !ok {
+				continue
+			}
+			lo.CantidadRecibida = models.Round3(lo.CantidadRecibida - l.Cantidad)
+			if lo.CantidadRecibida < 0 {
+				lo.CantidadRecibida = 0
+			}
+			if err := tx.Model(lo).Update("cantidad_recibida", lo.CantidadRecibida).Error; err != nil {
+				return err
+			}
+		}
+
+		recepcion.Estado = false
+		if err := tx.Model(&recepcion).Update("estado", false).Error; err != nil {
+			return err
+		}
+		orden.Estado = models.OrdenPendiente
+		orden.ActualizarEstado()
+		return tx.Model(&orden).Update("estado", orden.Estado).Error
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"orden":     orden,
+		"recepcion": recepcion,
+	})
+}
+
+// ClosePurchaseOrder da por terminada una orden aunque falte recibir mercadería
+func ClosePurchaseOrder(c *fiber.Ctx) error {
+	return cambiarEstadoOrden(c, models.OrdenCerrada)
+}
+
+// CancelPurchaseOrder anula una orden que todavía no recibió nada
+func CancelPurchaseOrder(c *fiber.Ctx) error {
+	return cambiarEstadoOrden(c, models.OrdenAnulada)
+}
+
+func cambiarEstadoOrden(c *fiber.Ctx, estado string) error {
+	id := c.Params("id")
+
+	var orden models.PurchaseOrder
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&orden, id).Error; err != nil {
+			return errorHTTP(404, "Orden de compra no encontrada")
+		}
+		if orden.Estado == models.OrdenCerrada || orden.Estado == models.OrdenAnulada {
+			return errorHTTP(400, "La orden de compra ya está "+orden.Estado)
+		}
+		if estado == models.OrdenAnulada && orden.Estado != models.OrdenPendiente {
+			return errorHTTP(400, "No se puede anular una orden con mercadería recibida: ciérrela")
+		}
+		orden.Estado = estado
+		return tx.Model(&orden).Update("estado", estado).Error
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.JSON(orden)
+}
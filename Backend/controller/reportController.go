@@ -0,0 +1,102 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// ============================================
+// REPORTES
+// ============================================
+
+// GetLibroIVA arma el Libro IVA Ventas o Compras de un período a partir de las
+// facturas activas. En ventas van sólo las autorizadas por AFIP, con su número.
+func GetLibroIVA(c *fiber.Ctx) error {
+	libro := c.Params("libro")
+
+	var tipos []string
+	switch libro {
+	case "ventas":
+		tipos = models.ComprobantesElectronicos
+	case "compras":
+		tipos = []string{models.FacturaCompra}
+	default:
+		return c.Status(400).JSON(models.ErrorResponse{Error: "El libro debe ser ventas o compras"})
+	}
+
+	desdeStr, hastaStr := c.Query("fecha_inicio"), c.Query("fecha_fin")
+	desde, err := parseLocalDate(desdeStr)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "fecha_inicio inválida"})
+	}
+	hasta, err := parseLocalDate(hastaStr)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "fecha_fin inválida"})
+	}
+
+	query := database.DB.Preload("Cliente").Preload("Proveedor").
+		Where("tipo IN ? AND estado = ? AND fecha BETWEEN ? AND ?", tipos, true, desde, hasta)
+	if libro == "ventas" {
+		// Sin CAE el comprobante no existe para AFIP
+		query = query.Where("estado_afip = ?", models.AFIPAutorizada).Order("fecha, punto_venta, numero_comprobante")
+	} else {
+		query = query.Order("fecha, punto_venta, numero")
+	}
+	var facturas []models.Invoice
+	if err := query.Find(&facturas).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo facturas"})
+	}
+
+	resp := models.LibroIVAResponse{
+		Libro:     libro,
+		Desde:     desdeStr,
+		Hasta:     hastaStr,
+		Renglones: []models.LibroIVARow{},
+	}
+
+	for _, f := range facturas {
+		// Las notas de crédito restan
+		signo := f.Signo()
+		comprobante := "FACTURA "
+		if f.Tipo == models.NotaCredito {
+			comprobante = "NOTA DE CREDITO "
+		}
+		row := models.LibroIVARow{
+			FacturaID:    f.ID,
+			Fecha:        f.Fecha,
+			Comprobante:  comprobante + f.Letra,
+			PuntoVenta:   f.PuntoVenta,
+			Numero:       f.NumeroFiscal(),
+			CondicionIVA: f.CondicionIVA,
+			NetoGravado:  signo * f.NetoGravado,
+			Exento:       signo * f.Exento,
+			IVA105:       signo * f.IVA105,
+			IVA21:        signo * f.IVA21,
+			Total:        signo * f.Total,
+		}
+		switch {
+		case f.Cliente != nil:
+			row.RazonSocial, row.CUIT = f.Cliente.RazonSocial, f.Cliente.CUIT
+		case f.Proveedor != nil:
+			row.RazonSocial, row.CUIT = f.Proveedor.RazonSocial, f.Proveedor.CUIT
+		default:
+			row.RazonSocial = "Consumidor Final"
+		}
+		resp.Renglones = append(resp.Renglones, row)
+
+		resp.NetoGravado += row.NetoGravado
+		resp.Exento += row.Exento
+		resp.IVA105 += row.IVA105
+		resp.IVA21 += row.IVA21
+		resp.Total += row.Total
+	}
+
+	resp.NetoGravado = models.Round2(resp.NetoGravado)
+	resp.Exento = models.Round2(resp.Exento)
+	resp.IVA105 = models.Round2(resp.IVA105)
+	resp.IVA21 = models.Round2(resp.IVA21)
+	resp.Total = models.Round2(resp.Total)
+
+	return c.JSON(resp)
+}
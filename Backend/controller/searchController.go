@@ -0,0 +1,126 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"sanJoseProyect/balanza"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// similitudMinima es el parecido (word_similarity de pg_trgm, de 0 a 1) desde
+// el que una descripción cuenta como resultado aunque no empiece igual
+const similitudMinima = 0.5
+
+// terminosBusqueda separa el texto en palabras en minúsculas, sin acentos ni signos
+func terminosBusqueda(q string) []string {
+	return strings.FieldsFunc(database.SinAcentos(q), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == 'ñ')
+	})
+}
+
+// buscarProductos busca sin distinguir acentos ni mayúsculas. Coinciden el
+// código o un código de barras exactos o por prefijo, las descripciones con
+// palabras que empiezan con cada término ("lango" → "Langostinos enteros") y,
+// con pg_trgm, las que se parecen aunque tengan errores de tipeo. Ordena por
+// relevancia y pagina con ?pagina= y ?por_pagina= (20 por defecto, hasta 100).
+func buscarProductos(c *fiber.Ctx, query *gorm.DB, q string) error {
+	pagina := c.QueryInt("pagina", 1)
+	porPagina := c.QueryInt("por_pagina", 20)
+	if pagina < 1 || porPagina < 1 || porPagina > 100 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "pagina debe ser 1 o más y por_pagina de 1 a 100"})
+	}
+
+	terminos := terminosBusqueda(q)
+	texto := strings.Join(terminos, " ")
+	descripcion := database.SinAcentosSQL("productos.descripcion")
+
+	var condiciones []string
+	var args []interface{}
+	puntaje := "CASE"
+	agregar := func(condicion string, valor int, a ...interface{}) {
+		condiciones = append(condiciones, condicion)
+		puntaje += " WHEN " + condicion + " THEN " + strconv.Itoa(valor)
+		args = append(args, a...)
+	}
+
+	// Códigos: sólo si lo buscado es un número
+	if balanza.SoloDigitos(q) {
+		barras := "EXISTS (SELECT 1 FROM codigos_barra cb WHERE cb.producto_id = productos.id AND cb.codigo %s)"
+		agregar("CAST(productos.codigo AS text) = ?", 100, q)
+		agregar(strings.Replace(barras, "%s", "= ?", 1), 95, q)
+		agregar("CAST(productos.codigo AS text) LIKE ?", 50, q+"%")
+		agregar(strings.Replace(barras, "%s", "LIKE ?", 1), 45, q+"%")
+	}
+	// Descripción: empieza con lo buscado, o cada término empieza una palabra
+	if len(terminos) > 0 {
+		agregar(descripcion+" LIKE ?", 70, texto+"%")
+		prefijos := make([]string, len(terminos))
+		var a []interface{}
+		for i, t := range terminos {
+			prefijos[i] = descripcion + ` ~ ?`
+			a = append(a, `\m`+t)
+		}
+		agregar("("+strings.Join(prefijos, " AND ")+")", 60, a...)
+	}
+	if len(condiciones) == 0 {
+		return c.JSON(models.ProductSearchResponse{Pagina: pagina, PorPagina: porPagina, Productos: []models.ProductSearchResult{}})
+	}
+	puntaje += " ELSE 0 END"
+
+	// Los CASE se repiten en el SELECT y el WHERE, así que los argumentos también
+	filtro := "(" + strings.Join(condiciones, " OR ")
+	filtroArgs := append([]interface{}{}, args...)
+	puntajeArgs := append([]interface{}{}, args...)
+	if database.Trigramas && texto != "" {
+		filtro += " OR word_similarity(?, " + descripcion + ") >= ?"
+		filtroArgs = append(filtroArgs, texto, similitudMinima)
+		puntaje += " + 30 * word_similarity(?, " + descripcion + ")"
+		puntajeArgs = append(puntajeArgs, texto)
+	}
+	filtro += ")"
+	query = query.Where(filtro, filtroArgs...)
+
+	resp := models.ProductSearchResponse{Pagina: pagina, PorPagina: porPagina}
+	if err := query.Session(&gorm.Session{}).Count(&resp.Total).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error buscando productos"})
+	}
+
+	var encontrados []struct {
+		ID         uint
+		Relevancia float64
+	}
+	if err := query.Session(&gorm.Session{}).
+		Select("productos.id, ("+puntaje+") AS relevancia", puntajeArgs...).
+		Order("relevancia DESC, productos.descripcion, productos.id").
+		Limit(porPagina).Offset((pagina - 1) * porPagina).
+		Scan(&encontrados).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error buscando productos"})
+	}
+
+	ids := make([]uint, len(encontrados))
+	for i, e := range encontrados {
+		ids[i] = e.ID
+	}
+	var productos []models.Product
+	if len(ids) > 0 {
+		if err := database.DB.Preload("Especie").Preload("CodigosBarra").Where("id IN ?", ids).Find(&productos).Error; err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{Error: "Error buscando productos"})
+		}
+	}
+	porID := map[uint]models.Product{}
+	for _, p := range productos {
+		porID[p.ID] = p
+	}
+	resp.Productos = make([]models.ProductSearchResult, 0, len(encontrados))
+	for _, e := range encontrados {
+		resp.Productos = append(resp.Productos, models.ProductSearchResult{
+			Product:    porID[e.ID],
+			Relevancia: models.Round2(e.Relevancia),
+		})
+	}
+	return c.JSON(resp)
+}
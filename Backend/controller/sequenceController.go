@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// siguienteNumero reserva el próximo número de la serie con la fila del
+// numerador bloqueada hasta que termine la transacción. La primera vez que se
+// usa una serie se inicializa con semilla, el último número ya emitido.
+func siguienteNumero(tx *gorm.DB, comprobante string, puntoVenta int, semilla func(*gorm.DB) (int, error)) (int, error) {
+	buscar := func(num *models.DocumentSequence) error {
+		return tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("comprobante = ? AND punto_venta = ?", comprobante, puntoVenta).
+			First(num).Error
+	}
+
+	var num models.DocumentSequence
+	err := buscar(&num)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ultimo, err := semilla(tx)
+		if err != nil {
+			return 0, err
+		}
+		// Si otra transacción lo crea al mismo tiempo, ésta espera y no inserta nada
+		nuevo := models.DocumentSequence{Comprobante: comprobante, PuntoVenta: puntoVenta, Ultimo: ultimo}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&nuevo).Error; err != nil {
+			return 0, err
+		}
+		err = buscar(&num)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	num.Ultimo++
+	if err := tx.Model(&num).Update("ultimo", num.Ultimo).Error; err != nil {
+		return 0, err
+	}
+	return num.Ultimo, nil
+}
+
+// puntoVentaValido usa el punto de venta pedido o el configurado si no se indicó
+func puntoVentaValido(pv int) (int, error) {
+	if pv == 0 {
+		return puntoVentaPorDefecto(), nil
+	}
+	if pv < 0 || pv > 99999 {
+		return 0, errorHTTP(400, "Punto de venta inválido")
+	}
+	return pv, nil
+}
+
+// siguienteNumeroTicket numera las salidas de mostrador por punto de venta
+func siguienteNumeroTicket(tx *gorm.DB, puntoVenta int) (int, error) {
+	numero, err := siguienteNumero(tx, models.ComprobanteTicket, puntoVenta, func(tx *gorm.DB) (int, error) {
+		var ultimo int
+		err := tx.Model(&models.Movement{}).
+			Where("comprobante = ? AND punto_venta = ?", models.ComprobanteTicket, puntoVenta).
+			Select("COALESCE(MAX(numero_factura), 0)").
+			Scan(&ultimo).Error
+		return ultimo, err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Un ticket tiene un movimiento por línea, así que la tabla no puede tener un
+	// índice único: el número sólo lo protege el numerador bloqueado
+	var usados int64
+	if err := tx.Model(&models.Movement{}).
+		Where("comprobante = ? AND punto_venta = ? AND numero_factura = ?", models.ComprobanteTicket, puntoVenta, numero).
+		Count(&usados).Error; err != nil {
+		return 0, err
+	}
+	if usados > 0 {
+		return 0, errorHTTP(409, "El número de ticket ya fue usado: revise el numerador")
+	}
+	return numero, nil
+}
+
+// ============================================
+// NUMERADORES
+// ============================================
+
+// GetDocumentSequences lista el último número emitido de cada serie
+func GetDocumentSequences(c *fiber.Ctx) error {
+	var numeradores []models.DocumentSequence
+	if err := database.DB.Order("punto_venta, comprobante").Find(&numeradores).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo numeradores"})
+	}
+	return c.JSON(numeradores)
+}
@@ -0,0 +1,123 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// codigoFAOLibre controla que el código FAO no esté usado por otra especie
+func codigoFAOLibre(codigo string, id uint) bool {
+	if codigo == "" {
+		return true
+	}
+	var existente models.Species
+	return database.DB.Where("codigo_fao = ? AND id <> ?", codigo, id).First(&existente).Error != nil
+}
+
+// ============================================
+// ESPECIES
+// ============================================
+
+func CreateSpecies(c *fiber.Ctx) error {
+	req := new(models.CreateSpeciesRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Solicitud inválida",
+		})
+	}
+	if req.Nombre == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "El nombre es obligatorio",
+		})
+	}
+	codigo, ok := models.NormalizarCodigoFAO(req.CodigoFAO)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "El código FAO debe tener tres letras",
+		})
+	}
+	if !codigoFAOLibre(codigo, 0) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "El código FAO ya está cargado en otra especie",
+		})
+	}
+
+	especie := models.Species{
+		Nombre:           req.Nombre,
+		NombreCientifico: req.NombreCientifico,
+		CodigoFAO:        codigo,
+	}
+	if err := database.DB.Create(&especie).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Error al crear la especie",
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(especie)
+}
+
+func GetSpecies(c *fiber.Ctx) error {
+	var especies []models.Species
+	if err := database.DB.Order("nombre").Find(&especies).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Error al obtener especies",
+		})
+	}
+	return c.JSON(especies)
+}
+
+func GetSpeciesByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var especie models.Species
+	if err := database.DB.First(&especie, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Especie no encontrada",
+		})
+	}
+	return c.JSON(especie)
+}
+
+func UpdateSpecies(c *fiber.Ctx) error {
+	id := c.Params("id")
+	req := new(models.UpdateSpeciesRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Solicitud inválida",
+		})
+	}
+
+	var especie models.Species
+	if err := database.DB.First(&especie, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Especie no encontrada",
+		})
+	}
+
+	if req.CodigoFAO != "" {
+		codigo, ok := models.NormalizarCodigoFAO(req.CodigoFAO)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "El código FAO debe tener tres letras",
+			})
+		}
+		if !codigoFAOLibre(codigo, especie.ID) {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "El código FAO ya está cargado en otra especie",
+			})
+		}
+		especie.CodigoFAO = codigo
+	}
+	if req.Nombre != "" {
+		especie.Nombre = req.Nombre
+	}
+	if req.NombreCientifico != "" {
+		especie.NombreCientifico = req.NombreCientifico
+	}
+
+	if err := database.DB.Save(&especie).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Error al actualizar especie",
+		})
+	}
+	return c.JSON(especie)
+}
@@ -0,0 +1,346 @@
+package controller
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// movimientosAntesDe suma por producto los movimientos activos con fecha
+// anterior a `fecha`. Sumado al stock inicial da el stock al comenzar ese día.
+func movimientosAntesDe(fecha time.Time, productoID ...uint) (map[uint]float64, error) {
+	type total struct {
+		ProductoID uint
+		Cantidad   float64
+	}
+	query := database.DB.Model(&models.Movement{}).
+		Select("producto_id, COALESCE(SUM(cantidad), 0) AS cantidad").
+		Where("estado = ? AND fecha < ?", true, fecha.Format("2006-01-02"))
+	if len(productoID) > 0 {
+		query = query.Where("producto_id IN ?", productoID)
+	}
+	var totales []total
+	if err := query.Group("producto_id").Scan(&totales).Error; err != nil {
+		return nil, err
+	}
+	saldos := map[uint]float64{}
+	for _, t := range totales {
+		saldos[t.ProductoID] = t.Cantidad
+	}
+	return saldos, nil
+}
+
+// ============================================
+// KARDEX Y STOCK A FECHA
+// ============================================
+
+// GetKardex devuelve la ficha de stock del producto: saldo inicial, cada
+// movimiento activo del período con su saldo acumulado y saldo final
+func GetKardex(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var producto models.Product
+	if err := database.DB.First(&producto, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Producto no encontrado"})
+	}
+
+	desdeStr, hastaStr := c.Query("fecha_inicio"), c.Query("fecha_fin")
+	desde, err := parseLocalDate(desdeStr)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "fecha_inicio inválida"})
+	}
+	hasta, err := parseLocalDate(hastaStr)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "fecha_fin inválida"})
+	}
+	if hasta.Before(desde) {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "fecha_fin anterior a fecha_inicio"})
+	}
+
+	anteriores, err := movimientosAntesDe(desde, producto.ID)
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando el saldo inicial"})
+	}
+
+	var movimientos []models.Movement
+	if err := database.DB.Where("producto_id = ? AND estado = ? AND fecha BETWEEN ? AND ?",
+		producto.ID, true, desde, hasta).
+		Order("fecha, id").Find(&movimientos).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error al obtener movimientos del producto"})
+	}
+
+	kardex := models.KardexResponse{
+		ProductoID:   producto.ID,
+		Codigo:       producto.Codigo,
+		Descripcion:  producto.Descripcion,
+		Unidad:       producto.TipoCantidad,
+		Desde:        desdeStr,
+		Hasta:        hastaStr,
+		SaldoInicial: models.Round3(producto.StockInicial + anteriores[producto.ID]),
+		Movimientos:  make([]models.KardexRow, 0, len(movimientos)),
+	}
+	saldo := kardex.SaldoInicial
+	for _, m := range movimientos {
+		saldo = models.Round3(saldo + m.Cantidad)
+		row := models.KardexRow{
+			MovimientoID:  m.ID,
+			Fecha:         m.Fecha,
+			Tipo:          m.Tipo,
+			Comprobante:   m.Comprobante,
+			PuntoVenta:    m.PuntoVenta,
+			NumeroFactura: m.NumeroFactura,
+			Descripcion:   m.Descripcion,
+			Saldo:         saldo,
+		}
+		if m.EsIngreso() {
+			row.Entrada = m.Cantidad
+			kardex.Entradas = models.Round3(kardex.Entradas + m.Cantidad)
+		} else {
+			row.Salida = -m.Cantidad
+			kardex.Salidas = models.Round3(kardex.Salidas - m.Cantidad)
+		}
+		kardex.Movimientos = append(kardex.Movimientos, row)
+	}
+	kardex.SaldoFinal = saldo
+
+	return c.JSON(kardex)
+}
+
+// GetStockAtDate reconstruye el stock de todos los productos al cierre de la
+// fecha indicada a partir del stock inicial y los movimientos activos
+func GetStockAtDate(c *fiber.Ctx) error {
+	fecha, err := parseLocalDate(c.Query("fecha"))
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "fecha inválida"})
+	}
+
+	var productos []models.Product
+	if err := database.DB.Order("codigo").Find(&productos).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error al obtener productos"})
+	}
+	saldos, err := movimientosAntesDe(fecha.AddDate(0, 0, 1))
+	if err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando el stock"})
+	}
+
+	filas := make([]models.StockAtDateRow, 0, len(productos))
+	for _, p := range productos {
+		stock := models.Round3(p.StockInicial + saldos[p.ID])
+		filas = append(filas, models.StockAtDateRow{
+			ProductoID:  p.ID,
+			Codigo:      p.Codigo,
+			Descripcion: p.Descripcion,
+			Unidad:      p.TipoCantidad,
+			Stock:       stock,
+			Costo:       p.Costo,
+			Valorizado:  models.Round2(stock * p.Costo),
+			StockActual: p.Stock,
+		})
+	}
+	return c.JSON(filas)
+}
+
+// ============================================
+// STOCK MÍNIMO Y REPOSICIÓN
+// ============================================
+
+// GetLowStock lista los productos con mínimo configurado cuyo stock disponible
+// (físico menos reservado) está en el mínimo o por debajo
+func GetLowStock(c *fiber.Ctx) error {
+	var productos []models.Product
+	if err := database.DB.Where("archivado = ? AND stock_minimo > 0 AND stock - reservado <= stock_minimo", false).
+		Order("descripcion").Find(&productos).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error al obtener productos"})
+	}
+
+	filas := make([]models.LowStockRow, 0, len(productos))
+	for _, p := range productos {
+		filas = append(filas, models.LowStockRow{
+			ProductoID:  p.ID,
+			Codigo:      p.Codigo,
+			Descripcion: p.Descripcion,
+			Unidad:      p.TipoCantidad,
+			Stock:       p.Stock,
+			Reservado:   p.Reservado,
+			Disponible:  p.Disponible,
+			StockMinimo: p.StockMinimo,
+			StockMaximo: p.StockMaximo,
+			Faltante:    models.Round3(p.StockMinimo - p.Disponible),
+		})
+	}
+	return c.JSON(filas)
+}
+
+// GetReorderSuggestions arma la lista de compra por proveedor. La demanda
+// hasta que llegue la mercadería, dentro de `dias`, sale del pronóstico
+// (metodo=pronostico, por defecto) o del promedio de SALIDAS activas de las
+// últimas `semanas` (metodo=promedio); se pide lo necesario para volver al
+// máximo, descontando lo que ya está en camino en órdenes de compra.
+func GetReorderSuggestions(c *fiber.Ctx) error {
+	semanas, err := strconv.Atoi(c.Query("semanas", "4"))
+	if err != nil || semanas <= 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "semanas inválidas"})
+	}
+	dias, err := strconv.Atoi(c.Query("dias", "7"))
+	if err != nil || dias < 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "dias inválidos"})
+	}
+	metodo := c.Query("metodo", "pronostico")
+	if metodo != "pronostico" && metodo != "promedio" {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "El método debe ser pronostico o promedio"})
+	}
+
+	var productos []models.Product
+	if err := database.DB.Preload("Proveedor").Where("stock_maximo > 0 AND archivado = ?", false).
+		Order("descripcion").Find(&productos).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error al obtener productos"})
+	}
+
+	type total struct {
+		ProductoID uint
+		Cantidad   float64
+	}
+
+	desde := time.Now().AddDate(0, 0, -7*semanas)
+	var salidas []total
+	if err := database.DB.Model(&models.Movement{}).
+		Select("producto_id, SUM(-cantidad) AS cantidad").
+		Where("tipo = ? AND estado = ? AND fecha >= ?", models.MovimientoSalida, true, desde.Format("2006-01-02")).
+		Group("producto_id").Scan(&salidas).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando el consumo"})
+	}
+	consumo := map[uint]float64{}
+	for _, s := range salidas {
+		consumo[s.ProductoID] = s.Cantidad / float64(7*semanas)
+	}
+
+	var demanda map[uint]float64
+	if metodo == "pronostico" {
+		hoy := time.Now()
+		manana := time.Date(hoy.Year(), hoy.Month(), hoy.Day()+1, 0, 0, 0, 0, time.Local)
+		if demanda, err = pronosticarDemanda(manana, dias, semanas); err != nil {
+			return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando el pronóstico"})
+		}
+	}
+
+	var pendientes []total
+	if err := database.DB.Table("orden_compra_lineas l").
+		Select("l.producto_id, SUM(GREATEST(l.cantidad_pedida - l.cantidad_recibida, 0)) AS cantidad").
+		Joins("JOIN ordenes_compra o ON o.id = l.orden_id").
+		Where("o.estado IN ?", []string{models.OrdenPendiente, models.OrdenRecibidaParcial}).
+		Group("l.producto_id").Scan(&pendientes).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error calculando lo pendiente de recibir"})
+	}
+	enCamino := map[uint]float64{}
+	for _, p := range pendientes {
+		enCamino[p.ProductoID] = p.Cantidad
+	}
+
+	// Sin proveedor habitual se toma el de la última entrada
+	type ultimo struct {
+		ProductoID  uint
+		ProveedorID uint
+	}
+	var ultimos []ultimo
+	if err := database.DB.Raw(`SELECT DISTINCT ON (producto_id) producto_id, proveedor_id FROM movimientos
+		WHERE tipo = ? AND estado = ? AND proveedor_id IS NOT NULL
+		ORDER BY producto_id, fecha DESC, id DESC`, models.MovimientoEntrada, true).
+		Scan(&ultimos).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error buscando proveedores"})
+	}
+	ultimoProveedor := map[uint]uint{}
+	for _, u := range ultimos {
+		ultimoProveedor[u.ProductoID] = u.ProveedorID
+	}
+
+	var proveedores []models.Supplier
+	if err := database.DB.Find(&proveedores).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error buscando proveedores"})
+	}
+	nombres := map[uint]string{}
+	for _, p := range proveedores {
+		nombres[p.ID] = p.RazonSocial
+	}
+
+	filtro := c.Query("proveedor_id")
+	grupos := map[uint]*models.ReorderGroup{}
+	for _, p := range productos {
+		diario := consumo[p.ID]
+		camino := enCamino[p.ID]
+		periodo := diario * float64(dias)
+		if demanda != nil {
+			periodo = demanda[p.ID]
+		}
+		proyectado := p.Disponible + camino - periodo
+		if proyectado > p.StockMinimo {
+			continue
+		}
+		// Lo que se cuenta por unidades o cajas se pide entero
+		sugerido := models.Round3(p.StockMaximo - proyectado)
+		if models.NormalizarUnidad(p.TipoCantidad) != models.UnidadKg {
+			sugerido = math.Ceil(sugerido)
+		}
+		if sugerido <= 0 {
+			continue
+		}
+
+		var proveedorID uint
+		if p.ProveedorID != nil {
+			proveedorID = *p.ProveedorID
+		} else {
+			proveedorID = ultimoProveedor[p.ID]
+		}
+		if filtro != "" && filtro != strconv.FormatUint(uint64(proveedorID), 10) {
+			continue
+		}
+
+		cobertura := -1.0
+		if diario > 0 {
+			cobertura = models.Round2((p.Disponible + camino) / diario)
+		}
+		g, ok := grupos[proveedorID]
+		if !ok {
+			g = &models.ReorderGroup{Proveedor: "Sin proveedor"}
+			if proveedorID != 0 {
+				id := proveedorID
+				g.ProveedorID = &id
+				g.Proveedor = nombres[proveedorID]
+			}
+			grupos[proveedorID] = g
+		}
+		g.Productos = append(g.Productos, models.ReorderSuggestion{
+			ProductoID:      p.ID,
+			Codigo:          p.Codigo,
+			Descripcion:     p.Descripcion,
+			Unidad:          p.TipoCantidad,
+			Disponible:      p.Disponible,
+			EnCamino:        camino,
+			StockMinimo:     p.StockMinimo,
+			StockMaximo:     p.StockMaximo,
+			ConsumoDiario:   models.Round2(diario),
+			DemandaPeriodo:  models.Round2(periodo),
+			DiasCobertura:   cobertura,
+			StockProyectado: models.Round2(proyectado),
+			Sugerido:        sugerido,
+		})
+	}
+
+	resultado := make([]models.ReorderGroup, 0, len(grupos))
+	for _, g := range grupos {
+		resultado = append(resultado, *g)
+	}
+	sort.Slice(resultado, func(i, j int) bool {
+		// Los productos sin proveedor van al final
+		if (resultado[i].ProveedorID == nil) != (resultado[j].ProveedorID == nil) {
+			return resultado[j].ProveedorID == nil
+		}
+		return resultado[i].Proveedor < resultado[j].Proveedor
+	})
+
+	return c.JSON(resultado)
+}
@@ -0,0 +1,118 @@
+package controller
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// ============================================
+// PROVEEDORES
+// ============================================
+
+func CreateSupplier(c *fiber.Ctx) error {
+	req := new(models.CreateSupplierRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Solicitud inválida",
+		})
+	}
+	if req.RazonSocial == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "La razón social es obligatoria",
+		})
+	}
+	if req.CondicionIVA == "" {
+		req.CondicionIVA = models.CondicionResponsableInscripto
+	}
+	if !models.CondicionIVAValida(req.CondicionIVA) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Condición de IVA inválida",
+		})
+	}
+
+	proveedor := models.Supplier{
+		RazonSocial:  req.RazonSocial,
+		CUIT:         req.CUIT,
+		CondicionIVA: req.CondicionIVA,
+		Domicilio:    req.Domicilio,
+		Telefono:     req.Telefono,
+		Email:        req.Email,
+	}
+
+	if err := database.DB.Create(&proveedor).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Error al crear el proveedor",
+		})
+	}
+	return c.Status(fiber.StatusCreated).JSON(proveedor)
+}
+
+func GetSuppliers(c *fiber.Ctx) error {
+	var proveedores []models.Supplier
+	if err := database.DB.Order("razon_social").Find(&proveedores).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Error al obtener proveedores",
+		})
+	}
+	return c.JSON(proveedores)
+}
+
+func GetSupplierByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+	var proveedor models.Supplier
+	if err := database.DB.First(&proveedor, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Proveedor no encontrado",
+		})
+	}
+	return c.JSON(proveedor)
+}
+
+func UpdateSupplier(c *fiber.Ctx) error {
+	id := c.Params("id")
+	req := new(models.UpdateSupplierRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+			Error: "Solicitud inválida",
+		})
+	}
+
+	var proveedor models.Supplier
+	if err := database.DB.First(&proveedor, id).Error; err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(models.ErrorResponse{
+			Error: "Proveedor no encontrado",
+		})
+	}
+
+	if req.CondicionIVA != "" {
+		if !models.CondicionIVAValida(req.CondicionIVA) {
+			return c.Status(fiber.StatusBadRequest).JSON(models.ErrorResponse{
+				Error: "Condición de IVA inválida",
+			})
+		}
+		proveedor.CondicionIVA = req.CondicionIVA
+	}
+	if req.RazonSocial != "" {
+		proveedor.RazonSocial = req.RazonSocial
+	}
+	if req.CUIT != "" {
+		proveedor.CUIT = req.CUIT
+	}
+	if req.Domicilio != "" {
+		proveedor.Domicilio = req.Domicilio
+	}
+	if req.Telefono != "" {
+		proveedor.Telefono = req.Telefono
+	}
+	if req.Email != "" {
+		proveedor.Email = req.Email
+	}
+
+	if err := database.DB.Save(&proveedor).Error; err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(models.ErrorResponse{
+			Error: "Error al actualizar proveedor",
+		})
+	}
+	return c.JSON(proveedor)
+}
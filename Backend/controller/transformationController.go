@@ -0,0 +1,399 @@
+package controller
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// ============================================
+// TRANSFORMACIONES
+// ============================================
+
+// kilosTransformacion pasa la cantidad a kilos para sumar insumos y productos
+// en una misma unidad: los productos que no se pesan necesitan una conversión
+// a kg (cuántas unidades o cajas hay en un kilo)
+func kilosTransformacion(tx *gorm.DB, producto *models.Product, cantidad float64) (float64, error) {
+	if models.NormalizarUnidad(producto.TipoCantidad) == models.UnidadKg {
+		return models.Round3(cantidad), nil
+	}
+	var conversion models.UnitConversion
+	if err := tx.Where("producto_id = ? AND unidad = ?", producto.ID, models.UnidadKg).First(&conversion).Error; err != nil {
+		return 0, errorHTTP(400, fmt.Sprintf("%s se lleva en %s y no tiene conversión de kg: cárguela para calcular el rendimiento",
+			producto.Descripcion, producto.TipoCantidad))
+	}
+	return models.Round3(cantidad / conversion.Factor), nil
+}
+
+// CreateTransformation consume los insumos y da de alta los productos en una
+// sola transacción, con movimientos CONSUMO y PRODUCCION. El costo de los
+// insumos se reparte entre los productos y queda como su último costo.
+func CreateTransformation(c *fiber.Ctx) error {
+	req := new(models.CreateTransformationRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+	if len(req.Insumos) == 0 || len(req.Productos) == 0 {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Indique al menos un insumo y un producto"})
+	}
+	for _, in := range req.Insumos {
+		if in.Cantidad <= 0 || (in.CostoUnitario != nil && *in.CostoUnitario < 0) {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Cantidad o costo de insumo inválidos"})
+		}
+	}
+	for _, out := range req.Productos {
+		if out.Cantidad <= 0 || (out.FactorCosto != nil && *out.FactorCosto < 0) {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Cantidad o factor de costo inválidos"})
+		}
+	}
+
+	fecha, err := parseLocalDate(req.Fecha)
+	if err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+	}
+
+	t := models.Transformation{
+		Fecha:       models.CustomDate{Time: fecha},
+		Descripcion: req.Descripcion,
+		Estado:      true,
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if req.ProveedorID != nil {
+			var proveedor models.Supplier
+			if err := tx.First(&proveedor, *req.ProveedorID).Error; err != nil {
+				return errorHTTP(404, "Proveedor no encontrado")
+			}
+			t.ProveedorID = &proveedor.ID
+		}
+
+		for _, in := range req.Insumos {
+			var producto models.Product
+			if err := tx.First(&producto, in.ProductoID).Error; err != nil {
+				return errorHTTP(404, "Insumo no encontrado")
+			}
+			costo := producto.Costo
+			if in.CostoUnitario != nil {
+				costo = *in.CostoUnitario
+			}
+			kilos, err := kilosTransformacion(tx, &producto, in.Cantidad)
+			if err != nil {
+				return err
+			}
+			t.Insumos = append(t.Insumos, models.TransformationInput{
+				ProductoID:    producto.ID,
+				Cantidad:      in.Cantidad,
+				Kilos:         kilos,
+				CostoUnitario: models.Round2(costo),
+			})
+		}
+		for _, out := range req.Productos {
+			var producto models.Product
+			if err := tx.First(&producto, out.ProductoID).Error; err != nil {
+				return errorHTTP(404, "Producto no encontrado")
+			}
+			kilos, err := kilosTransformacion(tx, &producto, out.Cantidad)
+			if err != nil {
+				return err
+			}
+			factor := 1.0
+			if out.FactorCosto != nil {
+				factor = *out.FactorCosto
+			}
+			t.Productos = append(t.Productos, models.TransformationOutput{
+				ProductoID:  producto.ID,
+				Cantidad:    out.Cantidad,
+				Kilos:       kilos,
+				FactorCosto: factor,
+			})
+		}
+		t.Calcular()
+		if t.Salida > t.Entrada {
+			return errorHTTP(400, fmt.Sprintf("Los productos (%v kg) superan a los insumos (%v kg)", t.Salida, t.Entrada))
+		}
+
+		if err := tx.Omit("Insumos", "Productos").Create(&t).Error; err != nil {
+			return errorHTTP(500, "Error al crear la transformación")
+		}
+
+		descripcion := t.Descripcion
+		if descripcion == "" {
+			descripcion = fmt.Sprintf("Transformación #%d", t.ID)
+		}
+		for i := range t.Insumos {
+			in := &t.Insumos[i]
+			mov := models.Movement{
+				Tipo:        models.MovimientoConsumo,
+				ProductoID:  in.ProductoID,
+				ProveedorID: t.ProveedorID,
+				Fecha:       t.Fecha,
+				Descripcion: descripcion,
+				Cantidad:    in.Cantidad,
+			}
+			if err := registrarMovimiento(tx, &mov); err != nil {
+				return err
+			}
+			in.MovimientoID = &mov.ID
+			in.TransformacionID = t.ID
+		}
+		for i := range t.Productos {
+			out := &t.Productos[i]
+			mov := models.Movement{
+				Tipo:        models.MovimientoProduccion,
+				ProductoID:  out.ProductoID,
+				ProveedorID: t.ProveedorID,
+				Fecha:       t.Fecha,
+				Descripcion: descripcion,
+				Cantidad:    out.Cantidad,
+			}
+			if err := registrarMovimiento(tx, &mov); err != nil {
+				return err
+			}
+			out.MovimientoID = &mov.ID
+			out.TransformacionID = t.ID
+			if err := actualizarCosto(tx, out.ProductoID, out.CostoUnitario); err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Create(&t.Insumos).Error; err != nil {
+			return errorHTTP(500, "Error al guardar los insumos")
+		}
+		if err := tx.Create(&t.Productos).Error; err != nil {
+			return errorHTTP(500, "Error al guardar los productos")
+		}
+		return nil
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.Status(201).JSON(t)
+}
+
+func GetTransformations(c *fiber.Ctx) error {
+	query := database.DB.Preload("Proveedor").Preload("Insumos").Preload("Productos").
+		Order("fecha desc, id desc")
+
+	if desde := c.Query("fecha_inicio"); desde != "" {
+		fecha, err := parseLocalDate(desde)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+		}
+		query = query.Where("fecha >= ?", fecha)
+	}
+	if hasta := c.Query("fecha_fin"); hasta != "" {
+		fecha, err := parseLocalDate(hasta)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "Formato de fecha inválido"})
+		}
+		query = query.Where("fecha <= ?", fecha)
+	}
+
+	var transformaciones []models.Transformation
+	if err := query.Find(&transformaciones).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo transformaciones"})
+	}
+	return c.JSON(transformaciones)
+}
+
+func GetTransformationByID(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var t models.Transformation
+	if err := database.DB.Preload("Proveedor").
+		Preload("Insumos").Preload("Insumos.Producto").
+		Preload("Productos").Preload("Productos.Producto").
+		First(&t, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Transformación no encontrada"})
+	}
+	return c.JSON(t)
+}
+
+// CancelTransformation revierte los movimientos: primero saca los productos y
+// después devuelve los insumos
+func CancelTransformation(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var t models.Transformation
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Insumos").Preload("Productos").First(&t, id).Error; err != nil {
+			return errorHTTP(404, "Transformación no encontrada")
+		}
+		if !t.Estado {
+			return errorHTTP(400, "La transformación ya está anulada")
+		}
+
+		var movimientos []*uint
+		for _, out := range t.Productos {
+			movimientos = append(movimientos, out.MovimientoID)
+		}
+		for _, in := range t.Insumos {
+			movimientos = append(movimientos, in.MovimientoID)
+		}
+		for _, movID := range movimientos {
+			if movID == nil {
+				continue
+			}
+			var mov models.Movement
+			if err := tx.First(&mov, *movID).Error; err != nil {
+				return errorHTTP(404, "Movimiento no encontrado")
+			}
+			if !mov.Estado {
+				continue
+			}
+			if err := anularMovimiento(tx, &mov); err != nil {
+				return err
+			}
+		}
+
+		t.Estado = false
+		return tx.Model(&t).Update("estado", false).Error
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.JSON(t)
+}
+
+// ============================================
+// REPORTE DE RENDIMIENTO
+// ============================================
+
+// GetYieldReport acumula el rendimiento por especie del insumo y proveedor, con
+// el detalle por producto. Si una transformación tiene varios insumos, la salida
+// y la merma se reparten en proporción a la cantidad de cada uno.
+func GetYieldReport(c *fiber.Ctx) error {
+	query := database.DB.Preload("Proveedor").Preload("Insumos").Preload("Insumos.Producto.Especie").
+		Where("estado = ?", true)
+
+	if desde := c.Query("fecha_inicio"); desde != "" {
+		fecha, err := parseLocalDate(desde)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "fecha_inicio inválida"})
+		}
+		query = query.Where("fecha >= ?", fecha)
+	}
+	if hasta := c.Query("fecha_fin"); hasta != "" {
+		fecha, err := parseLocalDate(hasta)
+		if err != nil {
+			return c.Status(400).JSON(models.ErrorResponse{Error: "fecha_fin inválida"})
+		}
+		query = query.Where("fecha <= ?", fecha)
+	}
+	if proveedorID := c.Query("proveedor_id"); proveedorID != "" {
+		query = query.Where("proveedor_id = ?", proveedorID)
+	}
+
+	var transformaciones []models.Transformation
+	if err := query.Find(&transformaciones).Error; err != nil {
+		return c.Status(500).JSON(models.ErrorResponse{Error: "Error obteniendo transformaciones"})
+	}
+
+	type clave struct {
+		especie   uint
+		proveedor uint
+	}
+	filas := map[clave]*models.YieldReportRow{}
+	productos := map[clave]map[uint]*models.YieldProductRow{}
+	for _, t := range transformaciones {
+		if t.Entrada == 0 {
+			continue
+		}
+		contadas := map[clave]bool{}
+		for _, in := range t.Insumos {
+			// Líneas anteriores a los kilos que no se pudieron convertir
+			if in.Kilos == 0 {
+				continue
+			}
+			k := clave{}
+			if t.ProveedorID != nil {
+				k.proveedor = *t.ProveedorID
+			}
+			if in.Producto != nil && in.Producto.EspecieID != nil {
+				k.especie = *in.Producto.EspecieID
+			}
+			fila, ok := filas[k]
+			if !ok {
+				fila = &models.YieldReportRow{Especie: "Sin especie", ProveedorID: t.ProveedorID, Proveedor: "Sin proveedor"}
+				if in.Producto != nil && in.Producto.Especie != nil {
+					fila.EspecieID = in.Producto.EspecieID
+					fila.Especie = in.Producto.Especie.Nombre
+				}
+				if t.Proveedor != nil {
+					fila.Proveedor = t.Proveedor.RazonSocial
+				}
+				filas[k] = fila
+				productos[k] = map[uint]*models.YieldProductRow{}
+			}
+			prod, ok := productos[k][in.ProductoID]
+			if !ok {
+				prod = &models.YieldProductRow{ProductoID: in.ProductoID}
+				if in.Producto != nil {
+					prod.Producto = in.Producto.Descripcion
+				}
+				productos[k][in.ProductoID] = prod
+			}
+
+			proporcion := in.Kilos / t.Entrada
+			salida := proporcion * t.Salida
+			merma := proporcion * t.Merma
+			// Dos insumos de la misma especie cuentan como una sola transformación
+			if !contadas[k] {
+				fila.Transformaciones++
+				contadas[k] = true
+			}
+			fila.Entrada += in.Kilos
+			fila.Salida += salida
+			fila.Merma += merma
+			fila.Costo += in.CostoTotal
+			prod.Transformaciones++
+			prod.Entrada += in.Kilos
+			prod.Salida += salida
+			prod.Merma += merma
+			prod.Costo += in.CostoTotal
+		}
+	}
+
+	resultado := make([]models.YieldReportRow, 0, len(filas))
+	for k, fila := range filas {
+		fila.Entrada = models.Round3(fila.Entrada)
+		fila.Salida = models.Round3(fila.Salida)
+		fila.Merma = models.Round3(fila.Merma)
+		fila.Costo = models.Round2(fila.Costo)
+		fila.Rendimiento = porcentajeRendimiento(fila.Salida, fila.Entrada)
+		for _, prod := range productos[k] {
+			prod.Entrada = models.Round3(prod.Entrada)
+			prod.Salida = models.Round3(prod.Salida)
+			prod.Merma = models.Round3(prod.Merma)
+			prod.Costo = models.Round2(prod.Costo)
+			prod.Rendimiento = porcentajeRendimiento(prod.Salida, prod.Entrada)
+			fila.Productos = append(fila.Productos, *prod)
+		}
+		sort.Slice(fila.Productos, func(i, j int) bool {
+			return fila.Productos[i].Producto < fila.Productos[j].Producto
+		})
+		resultado = append(resultado, *fila)
+	}
+	sort.Slice(resultado, func(i, j int) bool {
+		if resultado[i].Especie != resultado[j].Especie {
+			return resultado[i].Especie < resultado[j].Especie
+		}
+		return resultado[i].Proveedor < resultado[j].Proveedor
+	})
+
+	return c.JSON(resultado)
+}
+
+// porcentajeRendimiento es la salida sobre la entrada, en %
+func porcentajeRendimiento(salida, entrada float64) float64 {
+	if entrada <= 0 {
+		return 0
+	}
+	return models.Round2(salida * 100 / entrada)
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+	"sanJoseProyect/database"
+	"sanJoseProyect/models"
+)
+
+// conversionesFijas valen para todos los productos sin cargarlas: los gramos
+// de las etiquetas de balanza pasan a kilos
+var conversionesFijas = map[[2]string]float64{
+	{"g", models.UnidadKg}: 0.001,
+}
+
+// cantidadEntera rechaza las fracciones en los productos que no se pesan
+func cantidadEntera(producto *models.Product, cantidad float64) error {
+	if models.NormalizarUnidad(producto.TipoCantidad) == models.UnidadKg || cantidad == math.Trunc(cantidad) {
+		return nil
+	}
+	return errorHTTP(400, fmt.Sprintf("%s se cuenta en %s enteras: %v no es una cantidad válida",
+		producto.Descripcion, producto.TipoCantidad, cantidad))
+}
+
+// cantidadEnStock pasa una cantidad (positiva) cargada en otra unidad a la
+// unidad de stock del producto. Si la unidad es la de stock (o está vacía) la
+// devuelve igual. Si el producto no se pesa y la conversión no da unidades
+// enteras, la rechaza en lugar de redondearla.
+func cantidadEnStock(tx *gorm.DB, producto *models.Product, cantidad float64, unidad string) (float64, error) {
+	unidad = models.NormalizarUnidad(unidad)
+	if unidad == "" || unidad == models.NormalizarUnidad(producto.TipoCantidad) {
+		return cantidad, nil
+	}
+
+	var conversion models.UnitConversion
+	if err := tx.Where("producto_id = ? AND unidad = ?", producto.ID, unidad).First(&conversion).Error; err != nil {
+		factor, ok := conversionesFijas[[2]string{unidad, models.NormalizarUnidad(producto.TipoCantidad)}]
+		if !ok {
+			return 0, errorHTTP(400, fmt.Sprintf("%s no tiene conversión de %s a %s",
+				producto.Descripcion, unidad, producto.TipoCantidad))
+		}
+		conversion.Factor = factor
+	}
+
+	cant := models.Round3(cantidad * conversion.Factor)
+	if cant <= 0 {
+		return 0, errorHTTP(400, fmt.Sprintf("%v %s no llega a una cantidad de stock", cantidad, unidad))
+	}
+	if models.NormalizarUnidad(producto.TipoCantidad) != models.UnidadKg && cant != math.Trunc(cant) {
+		return 0, errorHTTP(400, fmt.Sprintf("%v %s son %v %s: %s se cuenta en %s enteras",
+			cantidad, unidad, cant, producto.TipoCantidad, producto.Descripcion, producto.TipoCantidad))
+	}
+	return cant, nil
+}
+
+// convertirUnidad pasa la cantidad del movimiento de UnidadOriginal a la
+// unidad de stock del producto y guarda lo cargado en CantidadOriginal. Si la
+// unidad es la de stock (o está vacía) no hace nada.
+func convertirUnidad(tx *gorm.DB, producto *models.Product, mov *models.Movement) error {
+	unidad := models.NormalizarUnidad(mov.UnidadOriginal)
+	if unidad == "" || unidad == models.NormalizarUnidad(producto.TipoCantidad) {
+		mov.UnidadOriginal, mov.CantidadOriginal = "", 0
+		return nil
+	}
+
+	original := abs(mov.Cantidad)
+	cant, err := cantidadEnStock(tx, producto, original, unidad)
+	if err != nil {
+		return err
+	}
+	mov.UnidadOriginal = unidad
+	mov.CantidadOriginal = original
+	mov.Cantidad = cant
+	return nil
+}
+
+// ============================================
+// CONVERSIONES DE UNIDAD
+// ============================================
+
+func GetUnitConversions(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var producto models.Product
+	if err := database.DB.Preload("Conversiones").First(&producto, id).Error; err != nil {
+		return c.Status(404).JSON(models.ErrorResponse{Error: "Producto no encontrado"})
+	}
+	return c.JSON(producto.Conversiones)
+}
+
+// SetUnitConversions reemplaza las conversiones del producto. Los movimientos
+// ya cargados no se recalculan.
+func SetUnitConversions(c *fiber.Ctx) error {
+	id := c.Params("id")
+	req := new(models.SetUnitConversionsRequest)
+	if err := c.BodyParser(req); err != nil {
+		return c.Status(400).JSON(models.ErrorResponse{Error: "Solicitud inválida"})
+	}
+
+	var producto models.Product
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(&producto, id).Error; err != nil {
+			return errorHTTP(404, "Producto no encontrado")
+		}
+
+		vistas := map[string]bool{}
+		for _, conv := range req.Conversiones {
+			unidad := models.NormalizarUnidad(conv.Unidad)
+			if unidad == "" || conv.Factor <= 0 {
+				return errorHTTP(400, "Unidad o factor inválidos")
+			}
+			if unidad == models.NormalizarUnidad(producto.TipoCantidad) {
+				return errorHTTP(400, "No hace falta convertir la unidad de stock del producto")
+			}
+			if vistas[unidad] {
+				return errorHTTP(400, "Unidad repetida: "+unidad)
+			}
+			vistas[unidad] = true
+			producto.Conversiones = append(producto.Conversiones, models.UnitConversion{
+				ProductoID: producto.ID,
+				Unidad:     unidad,
+				Factor:     conv.Factor,
+			})
+		}
+
+		if err := tx.Where("producto_id = ?", producto.ID).Delete(&models.UnitConversion{}).Error; err != nil {
+			return errorHTTP(500, "Error actualizando conversiones")
+		}
+		if len(producto.Conversiones) > 0 {
+			if err := tx.Create(&producto.Conversiones).Error; err != nil {
+				return errorHTTP(500, "Error actualizando conversiones")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return responderError(c, err)
+	}
+
+	return c.JSON(producto)
+}
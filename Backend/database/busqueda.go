@@ -0,0 +1,51 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Trigramas indica si está pg_trgm, para la búsqueda por parecido (con errores de tipeo)
+var Trigramas bool
+
+// Letras que se comparan sin acento. translate() es inmutable, así que a
+// diferencia de unaccent() se puede usar en un índice.
+const (
+	conAcento = "áéíóúüàèìòùâêîôûç"
+	sinAcento = "aeiouuaeiouaeiouc"
+)
+
+var reemplazoAcentos = func() *strings.Replacer {
+	con, sin := []rune(conAcento), []rune(sinAcento)
+	pares := make([]string, 0, 2*len(con))
+	for i := range con {
+		pares = append(pares, string(con[i]), string(sin[i]))
+	}
+	return strings.NewReplacer(pares...)
+}()
+
+// SinAcentos lleva el texto a minúsculas y sin acentos, igual que SinAcentosSQL
+func SinAcentos(s string) string {
+	return reemplazoAcentos.Replace(strings.ToLower(s))
+}
+
+// SinAcentosSQL es la expresión que compara la columna en minúsculas y sin acentos
+func SinAcentosSQL(columna string) string {
+	return fmt.Sprintf("translate(lower(%s), '%s', '%s')", columna, conAcento, sinAcento)
+}
+
+// PrepararBusqueda habilita pg_trgm y crea el índice de trigramas de la
+// descripción de los productos. Sin permisos para la extensión, la búsqueda
+// funciona igual pero sólo por prefijo.
+func PrepararBusqueda() {
+	if err := DB.Exec("CREATE EXTENSION IF NOT EXISTS pg_trgm").Error; err != nil {
+		log.Println("⚠️  No se pudo habilitar pg_trgm, la búsqueda de productos será sólo por prefijo:", err)
+		return
+	}
+	Trigramas = true
+	if err := DB.Exec(fmt.Sprintf("CREATE INDEX IF NOT EXISTS idx_productos_descripcion_trgm ON productos USING gin (%s gin_trgm_ops)",
+		SinAcentosSQL("descripcion"))).Error; err != nil {
+		log.Println("⚠️  No se pudo crear el índice de búsqueda de productos:", err)
+	}
+}
@@ -0,0 +1,49 @@
+package database
+
+import "log"
+
+// CompletarMigraciones completa los datos que AutoMigrate no puede deducir
+// de las columnas nuevas
+func CompletarMigraciones() {
+	// Las anulaciones anteriores a anulado_en se toman por la última modificación
+	if err := DB.Exec("UPDATE movimientos SET anulado_en = updated_at WHERE estado = false AND anulado_en IS NULL").Error; err != nil {
+		log.Println("⚠️  No se pudo completar la fecha de anulación de los movimientos:", err)
+	}
+
+	// Las unidades se guardaban como venían ("Kg", "KG "): se comparan en minúsculas
+	if err := DB.Exec("UPDATE productos SET tipo_cantidad = LOWER(TRIM(tipo_cantidad)) WHERE tipo_cantidad <> LOWER(TRIM(tipo_cantidad))").Error; err != nil {
+		log.Println("⚠️  No se pudo normalizar la unidad de los productos:", err)
+	}
+
+	// Las transformaciones anteriores a la columna kilos sumaban cantidades en la
+	// unidad de cada producto: se pasan a kilos las líneas de productos que se
+	// pesan o tienen conversión de kg, y se recalculan los totales de las que
+	// quedaron completas. Las demás quedan fuera del reporte de rendimiento.
+	for _, tabla := range []string{"transformacion_insumos", "transformacion_productos"} {
+		if err := DB.Exec(`UPDATE ` + tabla + ` l SET kilos = l.cantidad FROM productos p
+			WHERE p.id = l.producto_id AND COALESCE(l.kilos, 0) = 0 AND LOWER(TRIM(p.tipo_cantidad)) = 'kg'`).Error; err != nil {
+			log.Println("⚠️  No se pudieron completar los kilos de", tabla+":", err)
+		}
+		if err := DB.Exec(`UPDATE ` + tabla + ` l SET kilos = ROUND(l.cantidad / c.factor, 3) FROM conversiones_unidad c
+			WHERE c.producto_id = l.producto_id AND c.unidad = 'kg' AND COALESCE(l.kilos, 0) = 0`).Error; err != nil {
+			log.Println("⚠️  No se pudieron completar los kilos de", tabla+":", err)
+		}
+	}
+	if err := DB.Exec(`UPDATE transformaciones t SET entrada = x.entrada, salida = x.salida, merma = x.entrada - x.salida,
+			rendimiento = CASE WHEN x.entrada > 0 THEN ROUND(x.salida * 100 / x.entrada, 2) ELSE 0 END
+		FROM (SELECT tr.id,
+				(SELECT SUM(kilos) FROM transformacion_insumos WHERE transformacion_id = tr.id) AS entrada,
+				(SELECT SUM(kilos) FROM transformacion_productos WHERE transformacion_id = tr.id) AS salida
+			FROM transformaciones tr
+			WHERE NOT EXISTS (SELECT 1 FROM transformacion_insumos WHERE transformacion_id = tr.id AND COALESCE(kilos, 0) = 0)
+				AND NOT EXISTS (SELECT 1 FROM transformacion_productos WHERE transformacion_id = tr.id AND COALESCE(kilos, 0) = 0)) x
+		WHERE x.id = t.id AND (t.entrada <> x.entrada OR t.salida <> x.salida)`).Error; err != nil {
+		log.Println("⚠️  No se pudieron recalcular los totales de las transformaciones:", err)
+	}
+	if err := DB.Exec(`UPDATE transformacion_productos o SET rendimiento = ROUND(o.kilos * 100 / t.entrada, 2)
+		FROM transformaciones t
+		WHERE t.id = o.transformacion_id AND t.entrada > 0 AND COALESCE(o.kilos, 0) > 0
+			AND NOT EXISTS (SELECT 1 FROM transformacion_insumos WHERE transformacion_id = t.id AND COALESCE(kilos, 0) = 0)`).Error; err != nil {
+		log.Println("⚠️  No se pudo recalcular el rendimiento de los productos de las transformaciones:", err)
+	}
+}
@@ -1,11 +1,13 @@
 module sanJoseProyect
 
-go 1.24
+go 1.24.0
 
 require (
+	github.com/boombuler/barcode v1.1.0
 	github.com/gofiber/fiber/v2 v2.52.10
 	github.com/golang-jwt/jwt/v5 v5.3.0
 	github.com/joho/godotenv v1.5.1
+	github.com/jung-kurt/gofpdf v1.16.2
 	gorm.io/driver/postgres v1.6.0
 	gorm.io/gorm v1.31.1
 )
@@ -9,6 +9,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/joho/godotenv"
+	"sanJoseProyect/controller"
 	"sanJoseProyect/database"
 	"sanJoseProyect/models"
 	"sanJoseProyect/routes"
@@ -54,10 +55,33 @@ func main() {
 	// Migraciones
 	database.DB.AutoMigrate(
 		&models.User{},
+		&models.Species{},
 		&models.Product{},
+		&models.UnitConversion{},
+		&models.ProductBarcode{},
+		&models.LabelTemplate{},
 		&models.Movement{},
+		&models.DocumentSequence{},
+		&models.AuditLog{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderLine{},
+		&models.Receipt{},
+		&models.ReceiptLine{},
+		&models.CustomerOrder{},
+		&models.CustomerOrderLine{},
+		&models.Transformation{},
+		&models.TransformationInput{},
+		&models.TransformationOutput{},
+		&models.Customer{},
+		&models.Supplier{},
+		&models.Invoice{},
+		&models.InvoiceLine{},
+		&models.DeliveryNote{},
+		&models.DeliveryNoteLine{},
 	)
+	database.CompletarMigraciones()
 	log.Println("✅ Migraciones completadas")
+	database.PrepararBusqueda()
 
 	// Inicializar Fiber
 	app := fiber.New()
@@ -83,6 +107,9 @@ func main() {
 	// Rutas
 	routes.SetupRoutes(app)
 
+	// Reintentos de facturas sin CAE
+	controller.IniciarColaAFIP(time.Minute)
+
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
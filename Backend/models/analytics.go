@@ -0,0 +1,86 @@
+package models
+
+import "sanJoseProyect/pronostico"
+
+// TopProductRow es la venta de un producto en el período
+type TopProductRow struct {
+	ProductoID  uint    `json:"producto_id"`
+	Codigo      int     `json:"codigo"`
+	Descripcion string  `json:"descripcion"`
+	Unidad      string  `json:"unidad"`
+	Puesto      int     `json:"puesto"` // dentro de su unidad
+	Cantidad    float64 `json:"cantidad"`
+	Tickets     int     `json:"tickets"` // movimientos de SALIDA que lo incluyen
+}
+
+// RotationRow mide qué tan rápido se vende el stock de un producto
+type RotationRow struct {
+	ProductoID    uint    `json:"producto_id"`
+	Codigo        int     `json:"codigo"`
+	Descripcion   string  `json:"descripcion"`
+	Unidad        string  `json:"unidad"`
+	Vendido       float64 `json:"vendido"`
+	StockInicial  float64 `json:"stock_inicial"`
+	StockFinal    float64 `json:"stock_final"`
+	StockPromedio float64 `json:"stock_promedio"`
+	Rotacion      float64 `json:"rotacion"`       // vendido / stock promedio
+	VentaDiaria   float64 `json:"venta_diaria"`   // promedio del período
+	Disponible    float64 `json:"disponible"`     // hoy
+	DiasCobertura float64 `json:"dias_cobertura"` // disponible / venta diaria; -1 sin ventas
+}
+
+// WeeklySalesRow compara una semana con la anterior y con la misma semana del año anterior
+type WeeklySalesRow struct {
+	Semana              CustomDate `json:"semana"` // lunes
+	Cantidad            float64    `json:"cantidad"`
+	SemanaAnterior      float64    `json:"semana_anterior"`
+	VariacionSemanal    *float64   `json:"variacion_semanal"` // %, nil si la anterior fue 0
+	AnioAnterior        float64    `json:"anio_anterior"`     // 52 semanas antes, mismo día de la semana
+	VariacionInteranual *float64   `json:"variacion_interanual"`
+}
+
+// SeasonRow compara la venta diaria de una temporada con la de las semanas previas
+type SeasonRow struct {
+	Temporada       string     `json:"temporada"`
+	Anio            int        `json:"anio"`
+	Desde           CustomDate `json:"desde"`
+	Hasta           CustomDate `json:"hasta"`
+	Cantidad        float64    `json:"cantidad"`
+	VentaDiaria     float64    `json:"venta_diaria"`
+	VentaDiariaBase float64    `json:"venta_diaria_base"` // 4 semanas anteriores
+	Indice          float64    `json:"indice"`            // venta diaria / base; 0 sin base
+}
+
+// ForecastDay es la venta estimada de un día
+type ForecastDay struct {
+	Fecha    CustomDate `json:"fecha"`
+	Cantidad float64    `json:"cantidad"`
+}
+
+// ForecastRow es el pronóstico de un producto para los próximos días
+type ForecastRow struct {
+	ProductoID  uint              `json:"producto_id"`
+	Codigo      int               `json:"codigo"`
+	Descripcion string            `json:"descripcion"`
+	Unidad      string            `json:"unidad"`
+	Modelo      pronostico.Modelo `json:"modelo"`
+	Dias        []ForecastDay     `json:"dias"`
+	Total       float64           `json:"total"`
+}
+
+// BacktestRow es la precisión del pronóstico de un producto sobre la historia
+type BacktestRow struct {
+	ProductoID  uint                  `json:"producto_id"`
+	Codigo      int                   `json:"codigo"`
+	Descripcion string                `json:"descripcion"`
+	Evaluacion  pronostico.Evaluacion `json:"evaluacion"`
+}
+
+// BacktestResponse trae la evaluación de cada producto y la del conjunto
+type BacktestResponse struct {
+	Desde     string                `json:"desde"`
+	Hasta     string                `json:"hasta"`
+	Semanas   int                   `json:"semanas"`
+	Total     pronostico.Evaluacion `json:"total"`
+	Productos []BacktestRow         `json:"productos"`
+}
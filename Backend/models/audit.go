@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// Acciones que quedan registradas en la auditoría
+const (
+	AuditoriaDuplicadoForzado   = "DUPLICADO_FORZADO"   // se cargó una factura pese a coincidir con otra
+	AuditoriaProductoArchivado  = "PRODUCTO_ARCHIVADO"  // se dejó de vender un producto
+	AuditoriaProductoRestaurado = "PRODUCTO_RESTAURADO" // un producto archivado volvió a la venta
+	AuditoriaProductoEliminado  = "PRODUCTO_ELIMINADO"  // se borró un producto sin historial
+	AuditoriaProductoFusionado  = "PRODUCTO_FUSIONADO"  // un producto duplicado se unió a otro
+)
+
+// AuditLog registra quién hizo una operación sensible, cuándo y por qué
+type AuditLog struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	UsuarioID uint      `json:"usuario_id" gorm:"index"`
+	Email     string    `json:"email"`
+	Accion    string    `json:"accion" gorm:"type:varchar(30);index"`
+	Entidad   string    `json:"entidad" gorm:"type:varchar(30);index:idx_auditoria_entidad"`
+	EntidadID uint      `json:"entidad_id" gorm:"index:idx_auditoria_entidad"`
+	Motivo    string    `json:"motivo"`
+	Detalle   string    `json:"detalle" gorm:"type:text"` // JSON con los datos relevantes de la operación
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+func (AuditLog) TableName() string {
+	return "auditoria"
+}
+
+// DuplicateMatch es un comprobante ya cargado que coincide con el que se intenta cargar
+type DuplicateMatch struct {
+	Entidad       string     `json:"entidad"` // "factura" o "movimiento"
+	ID            uint       `json:"id"`
+	Criterio      string     `json:"criterio"`
+	ProveedorID   *uint      `json:"proveedor_id,omitempty"`
+	PuntoVenta    int        `json:"punto_venta,omitempty"`
+	NumeroFactura int        `json:"numero_factura"`
+	Fecha         CustomDate `json:"fecha"`
+	Total         float64    `json:"total,omitempty"`
+}
@@ -0,0 +1,51 @@
+package models
+
+// Tipos de código de barras de un producto
+const (
+	CodigoBarraEAN = "EAN" // código impreso en el envase (EAN-8, EAN-13, UPC)
+	CodigoBarraPLU = "PLU" // número del producto en la balanza, va dentro de la etiqueta de peso o precio
+)
+
+// ProductBarcode es un código con el que se identifica el producto al escanear
+type ProductBarcode struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	ProductoID uint   `json:"producto_id" gorm:"index"`
+	Tipo       string `json:"tipo" gorm:"type:varchar(3);uniqueIndex:idx_codigo_barra"`
+	Codigo     string `json:"codigo" gorm:"type:varchar(20);uniqueIndex:idx_codigo_barra"`
+}
+
+func (ProductBarcode) TableName() string {
+	return "codigos_barra"
+}
+
+// ScanResult es el producto y la cantidad leídos de un código escaneado. La
+// cantidad va en Unidad (vacía = unidad de stock), lista para un movimiento.
+type ScanResult struct {
+	Codigo   string   `json:"codigo"`
+	Producto Product  `json:"producto"`
+	Balanza  bool     `json:"balanza"` // etiqueta de peso o precio variable
+	PLU      string   `json:"plu,omitempty"`
+	Peso     *float64 `json:"peso,omitempty"`    // kilos, si la etiqueta trae el peso o se calculó del importe
+	Importe  *float64 `json:"importe,omitempty"` // si la etiqueta trae el precio
+	Cantidad float64  `json:"cantidad"`
+	Unidad   string   `json:"unidad,omitempty"`
+}
+
+// Request DTOs
+type BarcodeRequest struct {
+	Tipo   string `json:"tipo" validate:"required"`
+	Codigo string `json:"codigo" validate:"required"`
+}
+
+// SetBarcodesRequest reemplaza todos los códigos del producto
+type SetBarcodesRequest struct {
+	CodigosBarra []BarcodeRequest `json:"codigos_barra"`
+}
+
+// ScanOutMovementRequest registra una salida a partir de un código escaneado
+type ScanOutMovementRequest struct {
+	Codigo      string `json:"codigo" validate:"required"`
+	Fecha       string `json:"fecha" validate:"required"`
+	PuntoVenta  int    `json:"punto_venta"` // si se omite, PUNTO_VENTA
+	Descripcion string `json:"descripcion"`
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Categorías del catálogo
+const (
+	CategoriaPescadoFresco = "PESCADO_FRESCO"
+	CategoriaCongelado     = "CONGELADO"
+	CategoriaMariscos      = "MARISCOS"
+	CategoriaElaborados    = "ELABORADOS"
+)
+
+// Presentaciones del producto
+const (
+	PresentacionEntero    = "ENTERO"
+	PresentacionFilet     = "FILET"
+	PresentacionDespinado = "DESPINADO"
+)
+
+// Origen: pesca en el mar o acuicultura
+const (
+	OrigenMar      = "MAR"
+	OrigenCriadero = "CRIADERO"
+)
+
+// CategoriaValida indica si la categoría es una de las del catálogo
+func CategoriaValida(categoria string) bool {
+	switch categoria {
+	case CategoriaPescadoFresco, CategoriaCongelado, CategoriaMariscos, CategoriaElaborados:
+		return true
+	}
+	return false
+}
+
+// PresentacionValida indica si la presentación es una de las del catálogo
+func PresentacionValida(presentacion string) bool {
+	switch presentacion {
+	case PresentacionEntero, PresentacionFilet, PresentacionDespinado:
+		return true
+	}
+	return false
+}
+
+// OrigenValido indica si el origen es MAR o CRIADERO
+func OrigenValido(origen string) bool {
+	return origen == OrigenMar || origen == OrigenCriadero
+}
+
+// Species es la especie del pescado o marisco, con su código FAO de tres letras (HKP = merluza común)
+type Species struct {
+	ID               uint      `json:"id" gorm:"primaryKey"`
+	Nombre           string    `json:"nombre" gorm:"not null"`
+	NombreCientifico string    `json:"nombre_cientifico"`
+	CodigoFAO        string    `json:"codigo_fao" gorm:"type:varchar(3);uniqueIndex:idx_especie_fao,where:codigo_fao <> ''"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func (Species) TableName() string {
+	return "especies"
+}
+
+// NormalizarCodigoFAO pasa el código a mayúsculas; es válido vacío o de tres letras
+func NormalizarCodigoFAO(codigo string) (string, bool) {
+	codigo = strings.ToUpper(strings.TrimSpace(codigo))
+	if codigo == "" {
+		return "", true
+	}
+	if len(codigo) != 3 {
+		return codigo, false
+	}
+	for _, r := range codigo {
+		if r < 'A' || r > 'Z' {
+			return codigo, false
+		}
+	}
+	return codigo, true
+}
+
+// Request DTOs
+type CreateSpeciesRequest struct {
+	Nombre           string `json:"nombre" validate:"required"`
+	NombreCientifico string `json:"nombre_cientifico"`
+	CodigoFAO        string `json:"codigo_fao"`
+}
+
+type UpdateSpeciesRequest struct {
+	Nombre           string `json:"nombre"`
+	NombreCientifico string `json:"nombre_cientifico"`
+	CodigoFAO        string `json:"codigo_fao"`
+}
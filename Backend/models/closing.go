@@ -0,0 +1,46 @@
+package models
+
+// DailyClosingRow resume el día de un producto: apertura + entradas − salidas − merma = cierre
+type DailyClosingRow struct {
+	ProductoID   uint    `json:"producto_id"`
+	Codigo       int     `json:"codigo"`
+	Descripcion  string  `json:"descripcion"`
+	Unidad       string  `json:"unidad"`
+	StockInicial float64 `json:"stock_inicial"`
+	Entradas     float64 `json:"entradas"` // ENTRADA, DEVOLUCION y PRODUCCION
+	Salidas      float64 `json:"salidas"`  // SALIDA y CONSUMO
+	Merma        float64 `json:"merma"`
+	StockFinal   float64 `json:"stock_final"`
+}
+
+// DailyCancellation es un movimiento anulado durante el día
+type DailyCancellation struct {
+	MovimientoID  uint       `json:"movimiento_id"`
+	Fecha         CustomDate `json:"fecha"` // la del movimiento, que puede ser de otro día
+	ProductoID    uint       `json:"producto_id"`
+	Descripcion   string     `json:"descripcion"`
+	Tipo          string     `json:"tipo"`
+	Comprobante   string     `json:"comprobante"`
+	NumeroFactura int        `json:"numero_factura"`
+	Cantidad      float64    `json:"cantidad"`
+}
+
+// SalesByPaymentRow son las ventas facturadas del día con una forma de pago;
+// las notas de crédito restan
+type SalesByPaymentRow struct {
+	FormaPago    string  `json:"forma_pago"`
+	Comprobantes int     `json:"comprobantes"`
+	Total        float64 `json:"total"`
+}
+
+// DailyClosingReport es el cierre del día
+type DailyClosingReport struct {
+	Fecha       string              `json:"fecha"`
+	Productos   []DailyClosingRow   `json:"productos"`
+	Entradas    float64             `json:"entradas"`
+	Salidas     float64             `json:"salidas"`
+	Merma       float64             `json:"merma"`
+	Anulaciones []DailyCancellation `json:"anulaciones"`
+	Ventas      []SalesByPaymentRow `json:"ventas"`
+	TotalVentas float64             `json:"total_ventas"`
+}
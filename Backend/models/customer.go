@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Condiciones frente al IVA (del emisor o del receptor)
+const (
+	CondicionResponsableInscripto = "RESPONSABLE_INSCRIPTO"
+	CondicionMonotributo          = "MONOTRIBUTO"
+	CondicionExento               = "EXENTO"
+	CondicionConsumidorFinal      = "CONSUMIDOR_FINAL"
+)
+
+// CondicionIVAValida indica si la condición es una de las conocidas
+func CondicionIVAValida(condicion string) bool {
+	switch condicion {
+	case CondicionResponsableInscripto, CondicionMonotributo, CondicionExento, CondicionConsumidorFinal:
+		return true
+	}
+	return false
+}
+
+type Customer struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	RazonSocial  string    `json:"razon_social" gorm:"not null"`
+	CUIT         string    `json:"cuit" gorm:"type:varchar(13)"`
+	CondicionIVA string    `json:"condicion_iva" gorm:"type:varchar(30);default:'CONSUMIDOR_FINAL'"`
+	Domicilio    string    `json:"domicilio"`
+	Telefono     string    `json:"telefono"`
+	Email        string    `json:"email"`
+	Saldo        float64   `json:"saldo" gorm:"type:numeric(14,2);default:0"` // facturado menos notas de crédito
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (Customer) TableName() string {
+	return "clientes"
+}
+
+type CreateCustomerRequest struct {
+	RazonSocial  string `json:"razon_social" validate:"required"`
+	CUIT         string `json:"cuit"`
+	CondicionIVA string `json:"condicion_iva"`
+	Domicilio    string `json:"domicilio"`
+	Telefono     string `json:"telefono"`
+	Email        string `json:"email"`
+}
+
+type UpdateCustomerRequest struct {
+	RazonSocial  string `json:"razon_social"`
+	CUIT         string `json:"cuit"`
+	CondicionIVA string `json:"condicion_iva"`
+	Domicilio    string `json:"domicilio"`
+	Telefono     string `json:"telefono"`
+	Email        string `json:"email"`
+}
@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// Estados del pedido
+const (
+	PedidoPendiente = "PENDIENTE"
+	PedidoPreparado = "PREPARADO"
+	PedidoEntregado = "ENTREGADO"
+	PedidoCancelado = "CANCELADO"
+)
+
+// TransicionPedidoValida indica si el pedido puede pasar de un estado a otro.
+// Entregado y cancelado son finales.
+func TransicionPedidoValida(desde, hasta string) bool {
+	switch desde {
+	case PedidoPendiente:
+		return hasta == PedidoPreparado || hasta == PedidoEntregado || hasta == PedidoCancelado
+	case PedidoPreparado:
+		return hasta == PedidoPendiente || hasta == PedidoEntregado || hasta == PedidoCancelado
+	}
+	return false
+}
+
+// CustomerOrder es un pedido de un cliente que reserva stock hasta que se
+// entrega (y sale con movimientos SALIDA) o se cancela
+type CustomerOrder struct {
+	ID           uint                `json:"id" gorm:"primaryKey"`
+	ClienteID    *uint               `json:"cliente_id" gorm:"index"`
+	Cliente      *Customer           `json:"cliente,omitempty" gorm:"foreignKey:ClienteID"`
+	Contacto     string              `json:"contacto"` // nombre o teléfono si no es un cliente cargado
+	Fecha        CustomDate          `json:"fecha" gorm:"type:date"`
+	FechaEntrega CustomDate          `json:"fecha_entrega" gorm:"type:date;index"`
+	Descripcion  string              `json:"descripcion"`
+	Estado       string              `json:"estado" gorm:"type:varchar(20);index"`
+	PuntoVenta   int                 `json:"punto_venta"`   // del ticket con que se entregó
+	NumeroTicket int                 `json:"numero_ticket"` // del ticket con que se entregó
+	Lineas       []CustomerOrderLine `json:"lineas,omitempty" gorm:"foreignKey:PedidoID"`
+	CreatedAt    time.Time           `json:"created_at"`
+	UpdatedAt    time.Time           `json:"updated_at"`
+}
+
+func (CustomerOrder) TableName() string {
+	return "pedidos"
+}
+
+type CustomerOrderLine struct {
+	ID                uint     `json:"id" gorm:"primaryKey"`
+	PedidoID          uint     `json:"pedido_id" gorm:"index"`
+	ProductoID        uint     `json:"producto_id"`
+	Producto          *Product `json:"producto,omitempty" gorm:"foreignKey:ProductoID"`
+	Cantidad          float64  `json:"cantidad" gorm:"type:numeric(14,3)"`           // reservada
+	CantidadEntregada float64  `json:"cantidad_entregada" gorm:"type:numeric(14,3)"` // puede diferir por el peso real
+	MovimientoID      *uint    `json:"movimiento_id"`
+}
+
+func (CustomerOrderLine) TableName() string {
+	return "pedido_lineas"
+}
+
+// Request DTOs
+type CustomerOrderLineRequest struct {
+	ProductoID uint    `json:"producto_id" validate:"required"`
+	Cantidad   float64 `json:"cantidad" validate:"required,gt=0"`
+}
+
+type CreateCustomerOrderRequest struct {
+	ClienteID    *uint                      `json:"cliente_id"`
+	Contacto     string                     `json:"contacto"`
+	FechaEntrega string                     `json:"fecha_entrega" validate:"required"`
+	Descripcion  string                     `json:"descripcion"`
+	Lineas       []CustomerOrderLineRequest `json:"lineas" validate:"required"`
+}
+
+type DeliveredLineRequest struct {
+	PedidoLineaID uint    `json:"pedido_linea_id" validate:"required"`
+	Cantidad      float64 `json:"cantidad" validate:"gte=0"`
+}
+
+// UpdateCustomerOrderStatusRequest cambia el estado. Al entregar se puede
+// indicar la fecha, el punto de venta y lo entregado por línea si difiere de lo pedido.
+type UpdateCustomerOrderStatusRequest struct {
+	Estado     string                 `json:"estado" validate:"required"`
+	Fecha      string                 `json:"fecha"`
+	PuntoVenta int                    `json:"punto_venta"`
+	Lineas     []DeliveredLineRequest `json:"lineas"`
+}
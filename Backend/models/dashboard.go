@@ -0,0 +1,35 @@
+package models
+
+// StockByUnit es el stock sumado de los productos que se miden en una unidad
+type StockByUnit struct {
+	Unidad    string  `json:"unidad"`
+	Productos int     `json:"productos"`
+	Stock     float64 `json:"stock"`
+}
+
+// MovementTotals cuenta movimientos activos y suma sus cantidades
+type MovementTotals struct {
+	Movimientos int     `json:"movimientos"`
+	Cantidad    float64 `json:"cantidad"`
+}
+
+// DailyMovementRow es el total de un día de la serie del tablero
+type DailyMovementRow struct {
+	Fecha       CustomDate `json:"fecha"`
+	Entradas    float64    `json:"entradas"`
+	Salidas     float64    `json:"salidas"`
+	Movimientos int        `json:"movimientos"`
+}
+
+// DashboardResponse son las cifras del tablero ya calculadas
+type DashboardResponse struct {
+	Productos         int                `json:"productos"`
+	StockUnidades     float64            `json:"stock_unidades"`
+	StockKg           float64            `json:"stock_kg"`
+	StockPorUnidad    []StockByUnit      `json:"stock_por_unidad"`
+	StockBajo         int                `json:"stock_bajo"`
+	EntradasHoy       MovementTotals     `json:"entradas_hoy"`
+	SalidasHoy        MovementTotals     `json:"salidas_hoy"`
+	AnulacionesSemana int                `json:"anulaciones_semana"`
+	Serie             []DailyMovementRow `json:"serie"` // últimos 30 días
+}
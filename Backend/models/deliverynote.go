@@ -0,0 +1,102 @@
+package models
+
+import "time"
+
+// Estados del remito
+const (
+	RemitoEmitido          = "EMITIDO"
+	RemitoFacturadoParcial = "FACTURADO_PARCIAL"
+	RemitoFacturado        = "FACTURADO"
+	RemitoAnulado          = "ANULADO"
+)
+
+// DeliveryNote es un remito: la mercadería sale (y baja el stock) antes de facturarse
+type DeliveryNote struct {
+	ID          uint               `json:"id" gorm:"primaryKey"`
+	PuntoVenta  int                `json:"punto_venta" gorm:"uniqueIndex:idx_remito_numero"`
+	Numero      int                `json:"numero" gorm:"uniqueIndex:idx_remito_numero"`
+	Fecha       CustomDate         `json:"fecha" gorm:"type:date;index"`
+	ClienteID   *uint              `json:"cliente_id"`
+	Cliente     *Customer          `json:"cliente,omitempty" gorm:"foreignKey:ClienteID"`
+	Descripcion string             `json:"descripcion"`
+	Estado      string             `json:"estado" gorm:"type:varchar(20);index"`
+	Lineas      []DeliveryNoteLine `json:"lineas,omitempty" gorm:"foreignKey:RemitoID"`
+	CreatedAt   time.Time          `json:"created_at"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+}
+
+func (DeliveryNote) TableName() string {
+	return "remitos"
+}
+
+type DeliveryNoteLine struct {
+	ID                uint     `json:"id" gorm:"primaryKey"`
+	RemitoID          uint     `json:"remito_id" gorm:"index"`
+	ProductoID        uint     `json:"producto_id"`
+	Producto          *Product `json:"producto,omitempty" gorm:"foreignKey:ProductoID"`
+	MovimientoID      *uint    `json:"movimiento_id"`
+	Cantidad          float64  `json:"cantidad" gorm:"type:numeric(14,3)"`
+	CantidadFacturada float64  `json:"cantidad_facturada" gorm:"type:numeric(14,3)"`
+}
+
+func (DeliveryNoteLine) TableName() string {
+	return "remito_lineas"
+}
+
+// Pendiente es lo que falta facturar de la línea
+func (l DeliveryNoteLine) Pendiente() float64 {
+	return Round3(l.Cantidad - l.CantidadFacturada)
+}
+
+// ActualizarEstado recalcula el estado según lo facturado en cada línea
+func (r *DeliveryNote) ActualizarEstado() {
+	if r.Estado == RemitoAnulado {
+		return
+	}
+	facturado, pendiente := false, false
+	for _, l := range r.Lineas {
+		if l.CantidadFacturada > 0 {
+			facturado = true
+		}
+		if l.Pendiente() > 0 {
+			pendiente = true
+		}
+	}
+	switch {
+	case !facturado:
+		r.Estado = RemitoEmitido
+	case pendiente:
+		r.Estado = RemitoFacturadoParcial
+	default:
+		r.Estado = RemitoFacturado
+	}
+}
+
+// Request DTOs
+type DeliveryNoteLineRequest struct {
+	ProductoID uint    `json:"producto_id" validate:"required"`
+	Cantidad   float64 `json:"cantidad" validate:"required,gt=0"`
+}
+
+type CreateDeliveryNoteRequest struct {
+	Fecha       string                    `json:"fecha" validate:"required"`
+	PuntoVenta  int                       `json:"punto_venta"` // si se omite, PUNTO_VENTA
+	ClienteID   *uint                     `json:"cliente_id"`
+	Descripcion string                    `json:"descripcion"`
+	Lineas      []DeliveryNoteLineRequest `json:"lineas" validate:"required"`
+}
+
+type InvoiceDeliveryNoteLineRequest struct {
+	RemitoLineaID  uint    `json:"remito_linea_id" validate:"required"`
+	Cantidad       float64 `json:"cantidad" validate:"required,gt=0"`
+	PrecioUnitario float64 `json:"precio_unitario" validate:"required"`
+	AlicuotaIVA    string  `json:"alicuota_iva"`
+}
+
+// InvoiceDeliveryNoteRequest convierte todo o parte de un remito en factura
+type InvoiceDeliveryNoteRequest struct {
+	Fecha       string                           `json:"fecha"` // por defecto, hoy
+	Descripcion string                           `json:"descripcion"`
+	FormaPago   string                           `json:"forma_pago"`
+	Lineas      []InvoiceDeliveryNoteLineRequest `json:"lineas" validate:"required"`
+}
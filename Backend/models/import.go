@@ -0,0 +1,26 @@
+package models
+
+// Qué hace la importación con cada fila
+const (
+	ImportarCrear      = "CREAR"
+	ImportarActualizar = "ACTUALIZAR"
+)
+
+// ImportRow es el resultado de validar una fila de la planilla
+type ImportRow struct {
+	Fila        int      `json:"fila"` // número de fila en la planilla (el encabezado es la 1)
+	Codigo      int      `json:"codigo"`
+	Descripcion string   `json:"descripcion"`
+	Accion      string   `json:"accion,omitempty"` // CREAR o ACTUALIZAR; vacío si la fila tiene errores
+	Errores     []string `json:"errores,omitempty"`
+}
+
+// ImportReport resume la importación. Con errores no se aplica ninguna fila.
+type ImportReport struct {
+	Aplicado     bool        `json:"aplicado"`
+	Filas        int         `json:"filas"`
+	Nuevos       int         `json:"nuevos"`
+	Actualizados int         `json:"actualizados"`
+	ConErrores   int         `json:"con_errores"`
+	Detalle      []ImportRow `json:"detalle"`
+}
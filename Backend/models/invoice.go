@@ -0,0 +1,282 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// Alícuotas de IVA por producto
+const (
+	IVA21     = "21"
+	IVA105    = "10.5"
+	IVAExento = "EXENTO"
+)
+
+// Tipos de factura
+const (
+	FacturaVenta  = "VENTA"
+	FacturaCompra = "COMPRA"
+	NotaCredito   = "NOTA_CREDITO" // anula total o parcialmente una factura de venta
+)
+
+// ComprobantesElectronicos son los tipos que se autorizan en AFIP
+var ComprobantesElectronicos = []string{FacturaVenta, NotaCredito}
+
+// Destino de la mercadería en una línea de nota de crédito. Sin destino la
+// línea es sólo un ajuste de precio y no mueve stock.
+const (
+	DestinoDevolucion = "DEVOLUCION" // vuelve al stock para revender
+	DestinoMerma      = "MERMA"      // vuelve pero se descarta
+)
+
+// Formas de pago de las ventas, para el cierre de caja
+const (
+	FormaPagoEfectivo        = "EFECTIVO"
+	FormaPagoDebito          = "DEBITO"
+	FormaPagoCredito         = "CREDITO"
+	FormaPagoTransferencia   = "TRANSFERENCIA"
+	FormaPagoCuentaCorriente = "CUENTA_CORRIENTE"
+	FormaPagoSinEspecificar  = "SIN_ESPECIFICAR"
+)
+
+// FormaPagoValida indica si la forma de pago es una de las conocidas
+func FormaPagoValida(forma string) bool {
+	switch forma {
+	case FormaPagoEfectivo, FormaPagoDebito, FormaPagoCredito, FormaPagoTransferencia,
+		FormaPagoCuentaCorriente, FormaPagoSinEspecificar:
+		return true
+	}
+	return false
+}
+
+// Estados de la autorización electrónica (sólo facturas de venta)
+const (
+	AFIPPendiente  = "PENDIENTE"
+	AFIPAutorizada = "AUTORIZADA"
+	AFIPRechazada  = "RECHAZADA"
+)
+
+// TasaIVA devuelve el porcentaje de una alícuota y si es válida.
+// Exento devuelve 0.
+func TasaIVA(alicuota string) (float64, bool) {
+	switch alicuota {
+	case IVA21:
+		return 21, true
+	case IVA105:
+		return 10.5, true
+	case IVAExento:
+		return 0, true
+	}
+	return 0, false
+}
+
+// LetraFactura decide entre Factura A, B o C según la condición del emisor y del receptor
+func LetraFactura(emisor, receptor string) string {
+	if emisor != CondicionResponsableInscripto {
+		return "C"
+	}
+	if receptor == CondicionResponsableInscripto || receptor == CondicionMonotributo {
+		return "A"
+	}
+	return "B"
+}
+
+// Redondeo a centavos
+func Round2(x float64) float64 {
+	return math.Round(x*100) / 100
+}
+
+type Invoice struct {
+	ID           uint          `json:"id" gorm:"primaryKey"`
+	Tipo         string        `json:"tipo" gorm:"type:varchar(15);index;uniqueIndex:idx_factura_numero,where:tipo <> 'COMPRA' AND numero > 0"`
+	Letra        string        `json:"letra" gorm:"type:varchar(1);uniqueIndex:idx_factura_numero"`
+	PuntoVenta   int           `json:"punto_venta" gorm:"uniqueIndex:idx_factura_numero"`
+	Numero       int           `json:"numero" gorm:"uniqueIndex:idx_factura_numero"` // en ventas y notas de crédito no se repite por serie
+	Fecha        CustomDate    `json:"fecha" gorm:"type:date;index"`
+	ClienteID    *uint         `json:"cliente_id"`
+	Cliente      *Customer     `json:"cliente,omitempty" gorm:"foreignKey:ClienteID"`
+	ProveedorID  *uint         `json:"proveedor_id"`
+	Proveedor    *Supplier     `json:"proveedor,omitempty" gorm:"foreignKey:ProveedorID"`
+	CondicionIVA string        `json:"condicion_iva" gorm:"type:varchar(30)"` // del cliente/proveedor al momento de emitir
+	Descripcion  string        `json:"descripcion"`
+	NetoGravado  float64       `json:"neto_gravado" gorm:"type:numeric(14,2)"`
+	Exento       float64       `json:"exento" gorm:"type:numeric(14,2)"`
+	IVA105       float64       `json:"iva_105" gorm:"column:iva105;type:numeric(14,2)"`
+	IVA21        float64       `json:"iva_21" gorm:"column:iva21;type:numeric(14,2)"`
+	Total        float64       `json:"total" gorm:"type:numeric(14,2)"`
+	Estado       bool          `json:"estado"`
+	FormaPago    string        `json:"forma_pago" gorm:"type:varchar(20);default:'SIN_ESPECIFICAR'"` // sólo ventas
+	Lineas       []InvoiceLine `json:"lineas,omitempty" gorm:"foreignKey:FacturaID"`
+
+	// Notas de crédito: la factura que ajustan y, en la factura, las que recibió
+	FacturaAsociadaID *uint     `json:"factura_asociada_id" gorm:"index"`
+	FacturaAsociada   *Invoice  `json:"factura_asociada,omitempty" gorm:"foreignKey:FacturaAsociadaID"`
+	NotasCredito      []Invoice `json:"notas_credito,omitempty" gorm:"foreignKey:FacturaAsociadaID"`
+
+	// Factura electrónica
+	EstadoAFIP         string      `json:"estado_afip" gorm:"type:varchar(12);index"`
+	CAE                string      `json:"cae" gorm:"type:varchar(14)"`
+	CAEVencimiento     *CustomDate `json:"cae_vencimiento" gorm:"type:date"`
+	NumeroComprobante  int         `json:"numero_comprobante"` // el que asignó AFIP
+	AFIPIntentos       int         `json:"afip_intentos"`
+	AFIPError          string      `json:"afip_error"`
+	AFIPProximoIntento *time.Time  `json:"afip_proximo_intento"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func (Invoice) TableName() string {
+	return "facturas"
+}
+
+// EsElectronica indica si el comprobante necesita CAE
+func (f *Invoice) EsElectronica() bool {
+	return f.Tipo == FacturaVenta || f.Tipo == NotaCredito
+}
+
+// NumeroFiscal es el número que asignó AFIP; mientras no lo tenga (y en las
+// compras) es el de la numeración local
+func (f *Invoice) NumeroFiscal() int {
+	if f.NumeroComprobante > 0 {
+		return f.NumeroComprobante
+	}
+	return f.Numero
+}
+
+// Signo es -1 para las notas de crédito, que restan en los libros y en el saldo del cliente
+func (f *Invoice) Signo() float64 {
+	if f.Tipo == NotaCredito {
+		return -1
+	}
+	return 1
+}
+
+type InvoiceLine struct {
+	ID             uint     `json:"id" gorm:"primaryKey"`
+	FacturaID      uint     `json:"factura_id" gorm:"index"`
+	ProductoID     uint     `json:"producto_id"`
+	Producto       *Product `json:"producto,omitempty" gorm:"foreignKey:ProductoID"`
+	MovimientoID   *uint    `json:"movimiento_id"`
+	RemitoLineaID  *uint    `json:"remito_linea_id"`               // si viene de un remito no mueve stock
+	FacturaLineaID *uint    `json:"factura_linea_id" gorm:"index"` // en notas de crédito, la línea que ajusta
+	Destino        string   `json:"destino" gorm:"type:varchar(12)"`
+	// MovimientoMermaID es la baja de la mercadería devuelta que no se puede revender
+	MovimientoMermaID *uint     `json:"movimiento_merma_id"`
+	Cantidad          float64   `json:"cantidad" gorm:"type:numeric(14,3)"`
+	Unidad            string    `json:"unidad,omitempty" gorm:"type:varchar(20)"`  // sólo compras: la facturada, si no es la de stock
+	PrecioUnitario    float64   `json:"precio_unitario" gorm:"type:numeric(14,2)"` // neto, sin IVA
+	AlicuotaIVA       string    `json:"alicuota_iva" gorm:"type:varchar(10)"`
+	Neto              float64   `json:"neto" gorm:"type:numeric(14,2)"`
+	IVA               float64   `json:"iva" gorm:"type:numeric(14,2)"`
+	Total             float64   `json:"total" gorm:"type:numeric(14,2)"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+func (InvoiceLine) TableName() string {
+	return "factura_lineas"
+}
+
+// Calcular completa neto, IVA y total de la línea. En los comprobantes C el
+// IVA no se discrimina.
+func (l *InvoiceLine) Calcular(discriminaIVA bool) {
+	tasa, _ := TasaIVA(l.AlicuotaIVA)
+	if !discriminaIVA {
+		tasa = 0
+	}
+	l.Neto = Round2(l.Cantidad * l.PrecioUnitario)
+	l.IVA = Round2(l.Neto * tasa / 100)
+	l.Total = Round2(l.Neto + l.IVA)
+}
+
+// CalcularTotales recalcula cada línea y acumula los totales por alícuota
+func (f *Invoice) CalcularTotales() {
+	f.NetoGravado, f.Exento, f.IVA105, f.IVA21, f.Total = 0, 0, 0, 0, 0
+	discrimina := f.Letra != "C"
+	for i := range f.Lineas {
+		l := &f.Lineas[i]
+		l.Calcular(discrimina)
+		switch {
+		case !discrimina || l.AlicuotaIVA == IVAExento:
+			f.Exento += l.Neto
+		case l.AlicuotaIVA == IVA105:
+			f.NetoGravado += l.Neto
+			f.IVA105 += l.IVA
+		default:
+			f.NetoGravado += l.Neto
+			f.IVA21 += l.IVA
+		}
+	}
+	f.NetoGravado = Round2(f.NetoGravado)
+	f.Exento = Round2(f.Exento)
+	f.IVA105 = Round2(f.IVA105)
+	f.IVA21 = Round2(f.IVA21)
+	f.Total = Round2(f.NetoGravado + f.Exento + f.IVA105 + f.IVA21)
+}
+
+// Request DTOs
+type InvoiceLineRequest struct {
+	ProductoID     uint    `json:"producto_id" validate:"required"`
+	Cantidad       float64 `json:"cantidad" validate:"required,gt=0"`
+	PrecioUnitario float64 `json:"precio_unitario" validate:"required"`
+	AlicuotaIVA    string  `json:"alicuota_iva"` // si se omite se usa la del producto
+	Unidad         string  `json:"unidad"`       // sólo compras; si se omite, la unidad de stock del producto
+}
+
+type CreateInvoiceRequest struct {
+	Tipo        string               `json:"tipo" validate:"required"`
+	Fecha       string               `json:"fecha" validate:"required"`
+	ClienteID   *uint                `json:"cliente_id"`
+	ProveedorID *uint                `json:"proveedor_id"`
+	Letra       string               `json:"letra"`       // solo compras: la informa el proveedor
+	PuntoVenta  int                  `json:"punto_venta"` // en ventas, si se omite, PUNTO_VENTA
+	Numero      int                  `json:"numero"`      // solo compras
+	Descripcion string               `json:"descripcion"`
+	FormaPago   string               `json:"forma_pago"` // solo ventas; si se omite, SIN_ESPECIFICAR
+	Lineas      []InvoiceLineRequest `json:"lineas" validate:"required"`
+	// Forzar carga una compra que coincide con otra ya cargada; exige Motivo y queda auditado
+	Forzar bool   `json:"forzar"`
+	Motivo string `json:"motivo"`
+}
+
+type CreditNoteLineRequest struct {
+	FacturaLineaID uint     `json:"factura_linea_id" validate:"required"`
+	Cantidad       float64  `json:"cantidad" validate:"required,gt=0"`
+	PrecioUnitario *float64 `json:"precio_unitario"` // si se omite se usa el de la factura
+	Destino        string   `json:"destino"`         // DEVOLUCION, MERMA o vacío (sólo precio)
+}
+
+type CreateCreditNoteRequest struct {
+	Fecha       string                  `json:"fecha"` // si se omite, hoy
+	Descripcion string                  `json:"descripcion"`
+	Lineas      []CreditNoteLineRequest `json:"lineas" validate:"required"`
+}
+
+// LibroIVARow es un renglón del Libro IVA Compras/Ventas
+type LibroIVARow struct {
+	FacturaID    uint       `json:"factura_id"`
+	Fecha        CustomDate `json:"fecha"`
+	Comprobante  string     `json:"comprobante"`
+	PuntoVenta   int        `json:"punto_venta"`
+	Numero       int        `json:"numero"`
+	RazonSocial  string     `json:"razon_social"`
+	CUIT         string     `json:"cuit"`
+	CondicionIVA string     `json:"condicion_iva"`
+	NetoGravado  float64    `json:"neto_gravado"`
+	Exento       float64    `json:"exento"`
+	IVA105       float64    `json:"iva_105"`
+	IVA21        float64    `json:"iva_21"`
+	Total        float64    `json:"total"`
+}
+
+type LibroIVAResponse struct {
+	Libro       string        `json:"libro"`
+	Desde       string        `json:"desde"`
+	Hasta       string        `json:"hasta"`
+	Renglones   []LibroIVARow `json:"renglones"`
+	NetoGravado float64       `json:"neto_gravado"`
+	Exento      float64       `json:"exento"`
+	IVA105      float64       `json:"iva_105"`
+	IVA21       float64       `json:"iva_21"`
+	Total       float64       `json:"total"`
+}
@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// Tipos de código de barras que se imprimen en la etiqueta
+const (
+	BarraEAN13   = "EAN13"
+	BarraCode128 = "CODE128"
+)
+
+// LabelTemplate es un diseño de etiqueta: el tamaño (para el PDF y la
+// impresora) y el ZPL con los datos como {{.Descripcion}}. ZPL vacío usa el
+// diseño estándar.
+type LabelTemplate struct {
+	ID             uint      `json:"id" gorm:"primaryKey"`
+	Nombre         string    `json:"nombre" gorm:"not null"`
+	AnchoMM        float64   `json:"ancho_mm" gorm:"type:numeric(6,1)"`
+	AltoMM         float64   `json:"alto_mm" gorm:"type:numeric(6,1)"`
+	ZPL            string    `json:"zpl" gorm:"type:text"`
+	Predeterminada bool      `json:"predeterminada" gorm:"default:false"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (LabelTemplate) TableName() string {
+	return "plantillas_etiqueta"
+}
+
+// Label son los datos de una etiqueta de producto envasado
+type Label struct {
+	Codigo           int        `json:"codigo"`
+	Descripcion      string     `json:"descripcion"`
+	Especie          string     `json:"especie"`
+	NombreCientifico string     `json:"nombre_cientifico"`
+	ZonaCaptura      string     `json:"zona_captura"`
+	Origen           string     `json:"origen"`
+	Congelado        bool       `json:"congelado"`
+	Cantidad         float64    `json:"cantidad"` // kilos si se vende por peso
+	Unidad           string     `json:"unidad"`
+	Importe          float64    `json:"importe"` // cantidad × precio de venta; 0 sin precio
+	Lote             string     `json:"lote"`
+	Envasado         time.Time  `json:"envasado"`
+	Vencimiento      *time.Time `json:"vencimiento"`
+	CodigoBarra      string     `json:"codigo_barra"`
+	TipoBarra        string     `json:"tipo_barra"` // EAN13 o CODE128
+	Copias           int        `json:"copias"`
+}
+
+// Request DTOs
+type CreateLabelTemplateRequest struct {
+	Nombre         string  `json:"nombre" validate:"required"`
+	AnchoMM        float64 `json:"ancho_mm" validate:"required"`
+	AltoMM         float64 `json:"alto_mm" validate:"required"`
+	ZPL            string  `json:"zpl"`
+	Predeterminada bool    `json:"predeterminada"`
+}
+
+type UpdateLabelTemplateRequest struct {
+	Nombre         string   `json:"nombre"`
+	AnchoMM        *float64 `json:"ancho_mm,omitempty"`
+	AltoMM         *float64 `json:"alto_mm,omitempty"`
+	ZPL            *string  `json:"zpl,omitempty"`
+	Predeterminada *bool    `json:"predeterminada,omitempty"`
+}
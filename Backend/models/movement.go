@@ -70,48 +70,106 @@ func (cd *CustomDate) Scan(value interface{}) error {
 	}
 }
 
+// Tipos de movimiento. Cantidad se guarda con signo: positiva si ingresa stock,
+// negativa si sale.
+const (
+	MovimientoEntrada    = "ENTRADA"
+	MovimientoSalida     = "SALIDA"
+	MovimientoDevolucion = "DEVOLUCION" // mercadería que devuelve un cliente
+	MovimientoMerma      = "MERMA"      // mercadería que se descarta
+	MovimientoConsumo    = "CONSUMO"    // insumo de una transformación
+	MovimientoProduccion = "PRODUCCION" // producto que sale de una transformación
+)
+
 // Movement model
 type Movement struct {
-	ID            uint       `json:"id" gorm:"primaryKey"`
-	ProductoID    uint       `json:"producto_id"`
-	Producto      *Product   `json:"producto,omitempty" gorm:"foreignKey:ProductoID"`
-	NumeroFactura int        `json:"numero_factura"`
-	Fecha         CustomDate `json:"fecha" gorm:"type:date"`
-	Descripcion   string     `json:"descripcion"`
-	Cantidad      int        `json:"cantidad"`
-	Tipo          string     `json:"tipo"`
-	Estado        bool       `json:"estado"`
-	CreatedAt     time.Time  `json:"createdAt"`
-	UpdatedAt     time.Time  `json:"updatedAt"`
+	ID            uint     `json:"id" gorm:"primaryKey"`
+	ProductoID    uint     `json:"producto_id"`
+	Producto      *Product `json:"producto,omitempty" gorm:"foreignKey:ProductoID"`
+	NumeroFactura int      `json:"numero_factura" gorm:"index:idx_movimiento_comprobante"`
+	// PuntoVenta y Comprobante (TICKET, REMITO, FACTURA_B...) identifican de qué
+	// documento de venta salió el movimiento. En las entradas Comprobante va vacío
+	// y PuntoVenta, si se indicó, es el de la factura del proveedor
+	PuntoVenta  int        `json:"punto_venta" gorm:"index:idx_movimiento_comprobante"`
+	Comprobante string     `json:"comprobante" gorm:"type:varchar(20);index:idx_movimiento_comprobante"`
+	ProveedorID *uint      `json:"proveedor_id" gorm:"index"` // en entradas, quién emitió la factura
+	Fecha       CustomDate `json:"fecha" gorm:"type:date"`
+	Descripcion string     `json:"descripcion"`
+	Cantidad    float64    `json:"cantidad" gorm:"type:numeric(14,3)"`
+	// UnidadOriginal y CantidadOriginal guardan lo que se cargó cuando vino en
+	// otra unidad (por ejemplo 3 cajas); Cantidad queda en la unidad de stock
+	UnidadOriginal   string     `json:"unidad_original" gorm:"type:varchar(20)"`
+	CantidadOriginal float64    `json:"cantidad_original" gorm:"type:numeric(14,3)"`
+	Tipo             string     `json:"tipo"`
+	Estado           bool       `json:"estado"`
+	AnuladoEn        *time.Time `json:"anulado_en,omitempty" gorm:"index"` // cuándo se anuló (updated_at cambia con otras ediciones)
+	CreatedAt        time.Time  `json:"createdAt"`
+	UpdatedAt        time.Time  `json:"updatedAt"`
 }
 
 func (Movement) TableName() string {
 	return "movimientos"
 }
 
+// EsIngreso indica si el movimiento suma stock
+func (m Movement) EsIngreso() bool {
+	return m.Cantidad > 0
+}
+
 // Request DTOs
 type CreateInMovementRequest struct {
-	NumeroFactura int    `json:"numero_factura" validate:"required"`
-	Fecha         string `json:"fecha" validate:"required"`
-	ProductoID    uint   `json:"producto_id" validate:"required"`
-	Descripcion   string `json:"descripcion" validate:"required"`
-	Cantidad      int    `json:"cantidad" validate:"required,min=1"`
+	NumeroFactura int     `json:"numero_factura" validate:"required"`
+	PuntoVenta    int     `json:"punto_venta"` // de la factura del proveedor
+	ProveedorID   *uint   `json:"proveedor_id"`
+	Fecha         string  `json:"fecha" validate:"required"`
+	ProductoID    uint    `json:"producto_id" validate:"required"`
+	Descripcion   string  `json:"descripcion" validate:"required"`
+	Cantidad      float64 `json:"cantidad" validate:"required,gt=0"`
+	Unidad        string  `json:"unidad"` // si se omite, la unidad de stock del producto
+	// Forzar carga una factura que coincide con otra ya cargada; exige Motivo y queda auditado
+	Forzar bool   `json:"forzar"`
+	Motivo string `json:"motivo"`
 }
 
 type CreateOutMovementRequest struct {
-	Fecha       string `json:"fecha" validate:"required"`
-	ProductoID  uint   `json:"producto_id" validate:"required"`
-	Descripcion string `json:"descripcion" validate:"required"`
-	Cantidad    int    `json:"cantidad" validate:"required,min=1"`
+	Fecha       string  `json:"fecha" validate:"required"`
+	PuntoVenta  int     `json:"punto_venta"` // si se omite, PUNTO_VENTA
+	ProductoID  uint    `json:"producto_id" validate:"required"`
+	Descripcion string  `json:"descripcion" validate:"required"`
+	Cantidad    float64 `json:"cantidad" validate:"required,gt=0"`
+	Unidad      string  `json:"unidad"`
+}
+
+// CreateSaleTicketRequest registra varias salidas bajo un mismo número de ticket
+type CreateSaleTicketRequest struct {
+	Fecha       string                  `json:"fecha" validate:"required"`
+	PuntoVenta  int                     `json:"punto_venta"`
+	Descripcion string                  `json:"descripcion"`
+	Lineas      []SaleTicketLineRequest `json:"lineas" validate:"required"`
+}
+
+type SaleTicketLineRequest struct {
+	ProductoID uint    `json:"producto_id" validate:"required"`
+	Cantidad   float64 `json:"cantidad" validate:"required,gt=0"`
+	Unidad     string  `json:"unidad"`
+}
+
+// SaleTicketResponse agrupa los movimientos de un comprobante de venta
+type SaleTicketResponse struct {
+	Comprobante string     `json:"comprobante"`
+	PuntoVenta  int        `json:"punto_venta"`
+	Numero      int        `json:"numero"`
+	Fecha       CustomDate `json:"fecha"`
+	Movimientos []Movement `json:"movimientos"`
 }
 
 type UpdateMovementRequest struct {
-	Cantidad int `json:"cantidad" validate:"required,min=1"`
+	Cantidad float64 `json:"cantidad" validate:"required,gt=0"`
 }
 
 type UpdateMovementProductRequest struct {
-	ProductoID uint `json:"producto_id" validate:"required"`
-	Cantidad   int  `json:"cantidad" validate:"required,min=1"`
+	ProductoID uint    `json:"producto_id" validate:"required"`
+	Cantidad   float64 `json:"cantidad" validate:"required,gt=0"`
 }
 
 type MovementResponse struct {
@@ -120,7 +178,7 @@ type MovementResponse struct {
 	NumeroFactura int        `json:"numero_factura"`
 	Fecha         CustomDate `json:"fecha"`
 	Descripcion   string     `json:"descripcion"`
-	Cantidad      int        `json:"cantidad"`
+	Cantidad      float64    `json:"cantidad"`
 	Tipo          string     `json:"tipo"`
 	Estado        bool       `json:"estado"`
 	CreadoEn      time.Time  `json:"creado_en"`
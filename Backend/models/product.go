@@ -1,33 +1,158 @@
 package models
 
-import "time"
+import (
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Unidades en las que se lleva el stock de un producto
+const (
+	UnidadUnidades = "unidades"
+	UnidadCajas    = "cajas"
+	UnidadKg       = "kg"
+)
+
+// Round3 redondea una cantidad a tres decimales (gramos si es en kilos), la
+// precisión con la que se guardan el stock y los movimientos
+func Round3(x float64) float64 {
+	return math.Round(x*1000) / 1000
+}
+
+// TipoCantidadValido indica si la unidad es una de las que maneja el sistema
+func TipoCantidadValido(unidad string) bool {
+	switch unidad {
+	case UnidadUnidades, UnidadCajas, UnidadKg:
+		return true
+	}
+	return false
+}
 
 type Product struct {
-	ID           uint       `json:"id" gorm:"primaryKey"`
-	Codigo       int        `json:"codigo" gorm:"unique;not null"`
-	Descripcion  string     `json:"descripcion"`
-	StockInicial uint       `json:"stock_inicial" gorm:"default:0"` // ⭐ NUEVO CAMPO
-	Stock        uint       `json:"stock"`
-	TipoCantidad string     `json:"tipo_cantidad" gorm:"type:varchar(20);default:'unidades'"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
-	Movements    []Movement `json:"movements,omitempty" gorm:"foreignKey:ProductoID"`
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Codigo       int       `json:"codigo" gorm:"unique;not null"`
+	Descripcion  string    `json:"descripcion"`
+	StockInicial float64   `json:"stock_inicial" gorm:"type:numeric(14,3);default:0"` // ⭐ NUEVO CAMPO
+	Stock        float64   `json:"stock" gorm:"type:numeric(14,3)"`                   // físico, en la cámara
+	Reservado    float64   `json:"reservado" gorm:"type:numeric(14,3);default:0"`     // comprometido en pedidos sin entregar
+	Disponible   float64   `json:"disponible" gorm:"-"`                               // físico menos reservado
+	TipoCantidad string    `json:"tipo_cantidad" gorm:"type:varchar(20);default:'unidades'"`
+	AlicuotaIVA  string    `json:"alicuota_iva" gorm:"type:varchar(10);default:'21'"` // 21, 10.5 o EXENTO
+	Costo        float64   `json:"costo" gorm:"type:numeric(14,2);default:0"`         // último costo unitario conocido, sin IVA
+	PrecioVenta  float64   `json:"precio_venta" gorm:"type:numeric(14,2);default:0"`  // por unidad de stock, con IVA (el de la balanza)
+	StockMinimo  float64   `json:"stock_minimo" gorm:"type:numeric(14,3);default:0"`  // 0 = sin control
+	StockMaximo  float64   `json:"stock_maximo" gorm:"type:numeric(14,3);default:0"`  // nivel al que se repone
+	ProveedorID  *uint     `json:"proveedor_id" gorm:"index"`                         // proveedor habitual
+	Proveedor    *Supplier `json:"proveedor,omitempty" gorm:"foreignKey:ProveedorID"`
+	// Catálogo
+	Categoria    string   `json:"categoria" gorm:"type:varchar(20);index"` // PESCADO_FRESCO, CONGELADO, MARISCOS o ELABORADOS
+	EspecieID    *uint    `json:"especie_id" gorm:"index"`
+	Especie      *Species `json:"especie,omitempty" gorm:"foreignKey:EspecieID"`
+	Presentacion string   `json:"presentacion" gorm:"type:varchar(20)"` // ENTERO, FILET o DESPINADO
+	Origen       string   `json:"origen" gorm:"type:varchar(20)"`       // MAR o CRIADERO
+	ZonaCaptura  string   `json:"zona_captura"`                         // por ejemplo "FAO 41 - Atlántico sudoccidental"
+	Congelado    bool     `json:"congelado" gorm:"default:false"`       // false = fresco
+	VidaUtilDias uint     `json:"vida_util_dias" gorm:"default:0"`      // para el vencimiento de la etiqueta; 0 = sin vencimiento
+	// Archivado: no se vende ni se compra más, pero sigue en el historial y los reportes
+	Archivado   bool       `json:"archivado" gorm:"default:false;index"`
+	ArchivadoEn *time.Time `json:"archivado_en,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	Movements   []Movement `json:"movements,omitempty" gorm:"foreignKey:ProductoID"`
+	// Conversiones permite cargar movimientos en otras unidades (cajas, unidades)
+	Conversiones []UnitConversion `json:"conversiones,omitempty" gorm:"foreignKey:ProductoID"`
+	// CodigosBarra son los EAN del envase y los PLU de la balanza
+	CodigosBarra []ProductBarcode `json:"codigos_barra,omitempty" gorm:"foreignKey:ProductoID"`
 }
 
 func (Product) TableName() string {
 	return "productos"
 }
 
+// AfterFind completa el stock disponible para venta
+func (p *Product) AfterFind(tx *gorm.DB) error {
+	p.Disponible = Round3(p.Stock - p.Reservado)
+	return nil
+}
+
 type CreateProductRequest struct {
-	Codigo       int    `json:"codigo" validate:"required"`
-	Descripcion  string `json:"descripcion" validate:"required"`
-	Stock        uint   `json:"stock" validate:"required"`
-	TipoCantidad string `json:"tipo_cantidad"`
+	Codigo       int     `json:"codigo" validate:"required"`
+	Descripcion  string  `json:"descripcion" validate:"required"`
+	Stock        float64 `json:"stock" validate:"required"`
+	TipoCantidad string  `json:"tipo_cantidad"`
+	AlicuotaIVA  string  `json:"alicuota_iva"`
+	PrecioVenta  float64 `json:"precio_venta"`
+	StockMinimo  float64 `json:"stock_minimo"`
+	StockMaximo  float64 `json:"stock_maximo"`
+	ProveedorID  *uint   `json:"proveedor_id"`
+	Categoria    string  `json:"categoria"`
+	EspecieID    *uint   `json:"especie_id"`
+	Presentacion string  `json:"presentacion"`
+	Origen       string  `json:"origen"`
+	ZonaCaptura  string  `json:"zona_captura"`
+	Congelado    bool    `json:"congelado"`
+	VidaUtilDias uint    `json:"vida_util_dias"`
 }
 
 type UpdateProductRequest struct {
-	Codigo       *int   `json:"codigo,omitempty"`
-	Descripcion  string `json:"descripcion"`
-	Stock        uint   `json:"stock"`
-	TipoCantidad string `json:"tipo_cantidad,omitempty"`
+	Codigo       *int     `json:"codigo,omitempty"`
+	Descripcion  string   `json:"descripcion"`
+	Stock        float64  `json:"stock"`
+	TipoCantidad string   `json:"tipo_cantidad,omitempty"`
+	AlicuotaIVA  string   `json:"alicuota_iva,omitempty"`
+	PrecioVenta  *float64 `json:"precio_venta,omitempty"`
+	StockMinimo  *float64 `json:"stock_minimo,omitempty"`
+	StockMaximo  *float64 `json:"stock_maximo,omitempty"`
+	ProveedorID  *uint    `json:"proveedor_id,omitempty"`
+	Categoria    string   `json:"categoria,omitempty"`
+	EspecieID    *uint    `json:"especie_id,omitempty"`
+	Presentacion string   `json:"presentacion,omitempty"`
+	Origen       string   `json:"origen,omitempty"`
+	ZonaCaptura  string   `json:"zona_captura,omitempty"`
+	Congelado    *bool    `json:"congelado,omitempty"`
+	VidaUtilDias *uint    `json:"vida_util_dias,omitempty"`
+}
+
+// ProductSearchResult es un producto encontrado con su relevancia: 100 el
+// código exacto, menos los prefijos y el resto según el parecido del texto
+type ProductSearchResult struct {
+	Product
+	Relevancia float64 `json:"relevancia"`
+}
+
+// ProductSearchResponse es una página de resultados de la búsqueda
+type ProductSearchResponse struct {
+	Total     int64                 `json:"total"`
+	Pagina    int                   `json:"pagina"`
+	PorPagina int                   `json:"por_pagina"`
+	Productos []ProductSearchResult `json:"productos"`
+}
+
+// ArchiveProductRequest es el motivo por el que se archiva o restaura un producto
+type ArchiveProductRequest struct {
+	Motivo string `json:"motivo"`
+}
+
+// ProductReference es una tabla del historial que apunta al producto y cuántas filas tiene
+type ProductReference struct {
+	Tabla    string `json:"tabla"`
+	Nombre   string `json:"nombre"`
+	Cantidad int64  `json:"cantidad"`
+}
+
+// MergeProductRequest indica el producto duplicado que se fusiona en el de la URL
+type MergeProductRequest struct {
+	ProductoID uint   `json:"producto_id" validate:"required"`
+	Motivo     string `json:"motivo"`
+}
+
+// MergeProductResult es el producto que queda, el archivado y lo que se le pasó
+type MergeProductResult struct {
+	Producto     Product            `json:"producto"`
+	Fusionado    Product            `json:"fusionado"`
+	Reasignados  []ProductReference `json:"reasignados"`
+	CodigosBarra int64              `json:"codigos_barra"`
+	Conversiones int64              `json:"conversiones"`
+	StockSumado  float64            `json:"stock_sumado"`
 }
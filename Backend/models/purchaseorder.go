@@ -0,0 +1,155 @@
+package models
+
+import "time"
+
+// Estados de la orden de compra
+const (
+	OrdenPendiente       = "PENDIENTE"
+	OrdenRecibidaParcial = "RECIBIDA_PARCIAL"
+	OrdenCerrada         = "CERRADA"
+	OrdenAnulada         = "ANULADA"
+)
+
+// PurchaseOrder es un pedido a un proveedor que después se recibe en una o más entregas
+type PurchaseOrder struct {
+	ID            uint                `json:"id" gorm:"primaryKey"`
+	ProveedorID   uint                `json:"proveedor_id" gorm:"index"`
+	Proveedor     *Supplier           `json:"proveedor,omitempty" gorm:"foreignKey:ProveedorID"`
+	Fecha         CustomDate          `json:"fecha" gorm:"type:date;index"`
+	FechaEsperada *CustomDate         `json:"fecha_esperada" gorm:"type:date"`
+	Descripcion   string              `json:"descripcion"`
+	Estado        string              `json:"estado" gorm:"type:varchar(20);index"`
+	Lineas        []PurchaseOrderLine `json:"lineas,omitempty" gorm:"foreignKey:OrdenID"`
+	Recepciones   []Receipt           `json:"recepciones,omitempty" gorm:"foreignKey:OrdenID"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+func (PurchaseOrder) TableName() string {
+	return "ordenes_compra"
+}
+
+type PurchaseOrderLine struct {
+	ID               uint     `json:"id" gorm:"primaryKey"`
+	OrdenID          uint     `json:"orden_id" gorm:"index"`
+	ProductoID       uint     `json:"producto_id"`
+	Producto         *Product `json:"producto,omitempty" gorm:"foreignKey:ProductoID"`
+	CantidadPedida   float64  `json:"cantidad_pedida" gorm:"type:numeric(14,3)"`
+	CantidadRecibida float64  `json:"cantidad_recibida" gorm:"type:numeric(14,3)"`
+	PrecioUnitario   float64  `json:"precio_unitario" gorm:"type:numeric(14,2)"` // pactado, opcional
+}
+
+func (PurchaseOrderLine) TableName() string {
+	return "orden_compra_lineas"
+}
+
+// Pendiente es lo que falta recibir de la línea (nunca negativo)
+func (l PurchaseOrderLine) Pendiente() float64 {
+	if l.CantidadRecibida >= l.CantidadPedida {
+		return 0
+	}
+	return Round3(l.CantidadPedida - l.CantidadRecibida)
+}
+
+// ActualizarEstado recalcula el estado según lo recibido; una orden cerrada o
+// anulada no cambia
+func (o *PurchaseOrder) ActualizarEstado() {
+	if o.Estado == OrdenCerrada || o.Estado == OrdenAnulada {
+		return
+	}
+	recibido, pendiente := false, false
+	for _, l := range o.Lineas {
+		if l.CantidadRecibida > 0 {
+			recibido = true
+		}
+		if l.Pendiente() > 0 {
+			pendiente = true
+		}
+	}
+	switch {
+	case !pendiente:
+		o.Estado = OrdenCerrada
+	case recibido:
+		o.Estado = OrdenRecibidaParcial
+	default:
+		o.Estado = OrdenPendiente
+	}
+}
+
+// Receipt es una entrega del proveedor contra una orden de compra
+type Receipt struct {
+	ID            uint          `json:"id" gorm:"primaryKey"`
+	OrdenID       uint          `json:"orden_id" gorm:"index"`
+	Fecha         CustomDate    `json:"fecha" gorm:"type:date"`
+	NumeroFactura int           `json:"numero_factura"` // remito o factura del proveedor
+	PuntoVenta    int           `json:"punto_venta"`
+	Descripcion   string        `json:"descripcion"`
+	Estado        bool          `json:"estado" gorm:"default:true"` // false = anulada
+	Lineas        []ReceiptLine `json:"lineas,omitempty" gorm:"foreignKey:RecepcionID"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+func (Receipt) TableName() string {
+	return "recepciones"
+}
+
+// ReceiptLine guarda lo recibido contra lo que faltaba y contra lo que declaró
+// el proveedor, para seguir faltantes, sobrantes y diferencias de peso
+type ReceiptLine struct {
+	ID                  uint     `json:"id" gorm:"primaryKey"`
+	RecepcionID         uint     `json:"recepcion_id" gorm:"index"`
+	OrdenLineaID        uint     `json:"orden_linea_id" gorm:"index"`
+	ProductoID          uint     `json:"producto_id"`
+	Producto            *Product `json:"producto,omitempty" gorm:"foreignKey:ProductoID"`
+	MovimientoID        *uint    `json:"movimiento_id"`
+	Pendiente           float64  `json:"pendiente" gorm:"type:numeric(14,3)"`            // lo que faltaba recibir antes de esta entrega
+	Cantidad            float64  `json:"cantidad" gorm:"type:numeric(14,3)"`             // lo que efectivamente entró (pesado/contado)
+	Declarada           float64  `json:"declarada" gorm:"type:numeric(14,3)"`            // lo que dice el remito del proveedor
+	Diferencia          float64  `json:"diferencia" gorm:"type:numeric(14,3)"`           // cantidad - pendiente: negativo faltante, positivo sobrante
+	DiferenciaDeclarada float64  `json:"diferencia_declarada" gorm:"type:numeric(14,3)"` // cantidad - declarada: diferencia de peso
+	// UnidadOriginal y CantidadOriginal guardan lo que se cargó si vino en otra
+	// unidad; las cantidades de la línea quedan en la unidad de stock
+	UnidadOriginal   string  `json:"unidad_original,omitempty" gorm:"type:varchar(20)"`
+	CantidadOriginal float64 `json:"cantidad_original,omitempty" gorm:"type:numeric(14,3)"`
+	Observacion      string  `json:"observacion"`
+}
+
+func (ReceiptLine) TableName() string {
+	return "recepcion_lineas"
+}
+
+// Request DTOs
+type PurchaseOrderLineRequest struct {
+	ProductoID     uint    `json:"producto_id" validate:"required"`
+	Cantidad       float64 `json:"cantidad" validate:"required,gt=0"`
+	PrecioUnitario float64 `json:"precio_unitario"`
+}
+
+type CreatePurchaseOrderRequest struct {
+	ProveedorID   uint                       `json:"proveedor_id" validate:"required"`
+	Fecha         string                     `json:"fecha"` // por defecto, hoy
+	FechaEsperada string                     `json:"fecha_esperada"`
+	Descripcion   string                     `json:"descripcion"`
+	Lineas        []PurchaseOrderLineRequest `json:"lineas" validate:"required"`
+}
+
+type ReceiptLineRequest struct {
+	OrdenLineaID uint     `json:"orden_linea_id" validate:"required"`
+	Cantidad     float64  `json:"cantidad" validate:"gte=0"`
+	Declarada    *float64 `json:"declarada"` // si se omite, igual a la cantidad
+	Unidad       string   `json:"unidad"`    // de cantidad y declarada; si se omite, la unidad de stock
+	Observacion  string   `json:"observacion"`
+}
+
+// ReceivePurchaseOrderRequest registra una entrega. Cerrar da la orden por
+// terminada aunque falte mercadería.
+type ReceivePurchaseOrderRequest struct {
+	Fecha         string               `json:"fecha"` // por defecto, hoy
+	NumeroFactura int                  `json:"numero_factura"`
+	PuntoVenta    int                  `json:"punto_venta"` // de la factura del proveedor
+	Descripcion   string               `json:"descripcion"`
+	Lineas        []ReceiptLineRequest `json:"lineas" validate:"required"`
+	Cerrar        bool                 `json:"cerrar"`
+	Forzar        bool                 `json:"forzar"`
+	Motivo        string               `json:"motivo"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// Comprobantes de venta que numera el sistema además de facturas y notas de crédito
+const (
+	ComprobanteTicket = "TICKET"
+	ComprobanteRemito = "REMITO"
+)
+
+// ClaveComprobante identifica la serie de una factura o nota de crédito: "FACTURA_B", "NOTA_CREDITO_A"
+func ClaveComprobante(tipo, letra string) string {
+	if tipo == NotaCredito {
+		return "NOTA_CREDITO_" + letra
+	}
+	return "FACTURA_" + letra
+}
+
+// DocumentSequence guarda el último número emitido de cada serie por punto de
+// venta. Se incrementa con la fila bloqueada dentro de la misma transacción que
+// crea el comprobante, así un rollback no deja huecos.
+type DocumentSequence struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	Comprobante string    `json:"comprobante" gorm:"type:varchar(20);not null;uniqueIndex:idx_numerador"`
+	PuntoVenta  int       `json:"punto_venta" gorm:"not null;uniqueIndex:idx_numerador"`
+	Ultimo      int       `json:"ultimo"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (DocumentSequence) TableName() string {
+	return "numeradores"
+}
@@ -0,0 +1,82 @@
+package models
+
+// LowStockRow es un producto cuyo stock disponible llegó al mínimo
+type LowStockRow struct {
+	ProductoID  uint    `json:"producto_id"`
+	Codigo      int     `json:"codigo"`
+	Descripcion string  `json:"descripcion"`
+	Unidad      string  `json:"unidad"`
+	Stock       float64 `json:"stock"`
+	Reservado   float64 `json:"reservado"`
+	Disponible  float64 `json:"disponible"`
+	StockMinimo float64 `json:"stock_minimo"`
+	StockMaximo float64 `json:"stock_maximo"`
+	Faltante    float64 `json:"faltante"` // lo que falta para llegar al mínimo
+}
+
+// ReorderSuggestion es lo que conviene pedir de un producto para volver al
+// máximo cuando llegue la mercadería
+type ReorderSuggestion struct {
+	ProductoID      uint    `json:"producto_id"`
+	Codigo          int     `json:"codigo"`
+	Descripcion     string  `json:"descripcion"`
+	Unidad          string  `json:"unidad"`
+	Disponible      float64 `json:"disponible"`
+	EnCamino        float64 `json:"en_camino"` // pendiente de recibir en órdenes de compra
+	StockMinimo     float64 `json:"stock_minimo"`
+	StockMaximo     float64 `json:"stock_maximo"`
+	ConsumoDiario   float64 `json:"consumo_diario"`   // promedio de SALIDAS en las últimas semanas
+	DemandaPeriodo  float64 `json:"demanda_periodo"`  // estimada hasta que llegue el pedido
+	DiasCobertura   float64 `json:"dias_cobertura"`   // días que alcanza lo disponible y en camino; -1 sin consumo
+	StockProyectado float64 `json:"stock_proyectado"` // al llegar el pedido
+	Sugerido        float64 `json:"sugerido"`
+}
+
+// ReorderGroup agrupa las sugerencias por proveedor para armar las órdenes de compra
+type ReorderGroup struct {
+	ProveedorID *uint               `json:"proveedor_id"`
+	Proveedor   string              `json:"proveedor"`
+	Productos   []ReorderSuggestion `json:"productos"`
+}
+
+// KardexRow es un movimiento con el saldo acumulado después de aplicarlo
+type KardexRow struct {
+	MovimientoID  uint       `json:"movimiento_id"`
+	Fecha         CustomDate `json:"fecha"`
+	Tipo          string     `json:"tipo"`
+	Comprobante   string     `json:"comprobante"`
+	PuntoVenta    int        `json:"punto_venta"`
+	NumeroFactura int        `json:"numero_factura"`
+	Descripcion   string     `json:"descripcion"`
+	Entrada       float64    `json:"entrada"`
+	Salida        float64    `json:"salida"`
+	Saldo         float64    `json:"saldo"`
+}
+
+// KardexResponse es la ficha de stock de un producto en un período
+type KardexResponse struct {
+	ProductoID   uint        `json:"producto_id"`
+	Codigo       int         `json:"codigo"`
+	Descripcion  string      `json:"descripcion"`
+	Unidad       string      `json:"unidad"`
+	Desde        string      `json:"desde"`
+	Hasta        string      `json:"hasta"`
+	SaldoInicial float64     `json:"saldo_inicial"`
+	Entradas     float64     `json:"entradas"`
+	Salidas      float64     `json:"salidas"`
+	SaldoFinal   float64     `json:"saldo_final"`
+	Movimientos  []KardexRow `json:"movimientos"`
+}
+
+// StockAtDateRow es el stock de un producto al cierre de una fecha, calculado
+// desde el stock inicial y los movimientos activos
+type StockAtDateRow struct {
+	ProductoID  uint    `json:"producto_id"`
+	Codigo      int     `json:"codigo"`
+	Descripcion string  `json:"descripcion"`
+	Unidad      string  `json:"unidad"`
+	Stock       float64 `json:"stock"`
+	Costo       float64 `json:"costo"`
+	Valorizado  float64 `json:"valorizado"` // stock × último costo
+	StockActual float64 `json:"stock_actual"`
+}
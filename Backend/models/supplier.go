@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+type Supplier struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	RazonSocial  string    `json:"razon_social" gorm:"not null"`
+	CUIT         string    `json:"cuit" gorm:"type:varchar(13)"`
+	CondicionIVA string    `json:"condicion_iva" gorm:"type:varchar(30);default:'RESPONSABLE_INSCRIPTO'"`
+	Domicilio    string    `json:"domicilio"`
+	Telefono     string    `json:"telefono"`
+	Email        string    `json:"email"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (Supplier) TableName() string {
+	return "proveedores"
+}
+
+type CreateSupplierRequest struct {
+	RazonSocial  string `json:"razon_social" validate:"required"`
+	CUIT         string `json:"cuit"`
+	CondicionIVA string `json:"condicion_iva"`
+	Domicilio    string `json:"domicilio"`
+	Telefono     string `json:"telefono"`
+	Email        string `json:"email"`
+}
+
+type UpdateSupplierRequest struct {
+	RazonSocial  string `json:"razon_social"`
+	CUIT         string `json:"cuit"`
+	CondicionIVA string `json:"condicion_iva"`
+	Domicilio    string `json:"domicilio"`
+	Telefono     string `json:"telefono"`
+	Email        string `json:"email"`
+}
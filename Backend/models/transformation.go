@@ -0,0 +1,153 @@
+package models
+
+import "time"
+
+// Transformation procesa insumos (merluza entera) en productos (filet,
+// cabezas, recortes). Lo que no sale como producto es merma.
+type Transformation struct {
+	ID          uint                   `json:"id" gorm:"primaryKey"`
+	Fecha       CustomDate             `json:"fecha" gorm:"type:date;index"`
+	ProveedorID *uint                  `json:"proveedor_id" gorm:"index"` // de quién era la mercadería procesada
+	Proveedor   *Supplier              `json:"proveedor,omitempty" gorm:"foreignKey:ProveedorID"`
+	Descripcion string                 `json:"descripcion"`
+	Estado      bool                   `json:"estado"`
+	Entrada     float64                `json:"entrada" gorm:"type:numeric(14,3)"` // total de insumos, en kilos
+	Salida      float64                `json:"salida" gorm:"type:numeric(14,3)"`  // total de productos, en kilos
+	Merma       float64                `json:"merma" gorm:"type:numeric(14,3)"`   // entrada - salida, en kilos
+	Rendimiento float64                `json:"rendimiento"`                       // salida / entrada, en %
+	CostoTotal  float64                `json:"costo_total" gorm:"type:numeric(14,2)"`
+	Insumos     []TransformationInput  `json:"insumos,omitempty" gorm:"foreignKey:TransformacionID"`
+	Productos   []TransformationOutput `json:"productos,omitempty" gorm:"foreignKey:TransformacionID"`
+	CreatedAt   time.Time              `json:"created_at"`
+	UpdatedAt   time.Time              `json:"updated_at"`
+}
+
+func (Transformation) TableName() string {
+	return "transformaciones"
+}
+
+type TransformationInput struct {
+	ID               uint     `json:"id" gorm:"primaryKey"`
+	TransformacionID uint     `json:"transformacion_id" gorm:"index"`
+	ProductoID       uint     `json:"producto_id" gorm:"index"`
+	Producto         *Product `json:"producto,omitempty" gorm:"foreignKey:ProductoID"`
+	Cantidad         float64  `json:"cantidad" gorm:"type:numeric(14,3)"` // en la unidad de stock del producto
+	Kilos            float64  `json:"kilos" gorm:"type:numeric(14,3)"`    // la cantidad pasada a kilos
+	CostoUnitario    float64  `json:"costo_unitario" gorm:"type:numeric(14,2)"`
+	CostoTotal       float64  `json:"costo_total" gorm:"type:numeric(14,2)"`
+	MovimientoID     *uint    `json:"movimiento_id"`
+}
+
+func (TransformationInput) TableName() string {
+	return "transformacion_insumos"
+}
+
+type TransformationOutput struct {
+	ID               uint     `json:"id" gorm:"primaryKey"`
+	TransformacionID uint     `json:"transformacion_id" gorm:"index"`
+	ProductoID       uint     `json:"producto_id"`
+	Producto         *Product `json:"producto,omitempty" gorm:"foreignKey:ProductoID"`
+	Cantidad         float64  `json:"cantidad" gorm:"type:numeric(14,3)"` // en la unidad de stock del producto
+	Kilos            float64  `json:"kilos" gorm:"type:numeric(14,3)"`    // la cantidad pasada a kilos
+	FactorCosto      float64  `json:"factor_costo"`                       // peso relativo para repartir el costo; 0 = no absorbe costo
+	Rendimiento      float64  `json:"rendimiento"`                        // kilos / entrada, en %
+	CostoAsignado    float64  `json:"costo_asignado" gorm:"type:numeric(14,2)"`
+	CostoUnitario    float64  `json:"costo_unitario" gorm:"type:numeric(14,2)"`
+	MovimientoID     *uint    `json:"movimiento_id"`
+}
+
+func (TransformationOutput) TableName() string {
+	return "transformacion_productos"
+}
+
+// Calcular completa totales, rendimientos y reparte el costo de los insumos
+// entre los productos según kilos × factor de costo. Los totales y rendimientos
+// se calculan en kilos, así se pueden comparar productos que se llevan en
+// distintas unidades (cabezas por unidad, filet por kilo); Kilos tiene que
+// venir completo en cada línea.
+func (t *Transformation) Calcular() {
+	t.Entrada, t.Salida, t.CostoTotal = 0, 0, 0
+	for i := range t.Insumos {
+		in := &t.Insumos[i]
+		in.CostoTotal = Round2(in.Cantidad * in.CostoUnitario)
+		t.Entrada += in.Kilos
+		t.CostoTotal += in.CostoTotal
+	}
+	t.Entrada = Round3(t.Entrada)
+	t.CostoTotal = Round2(t.CostoTotal)
+
+	base := 0.0
+	for _, out := range t.Productos {
+		t.Salida += out.Kilos
+		base += out.Kilos * out.FactorCosto
+	}
+	t.Salida = Round3(t.Salida)
+	t.Merma = Round3(t.Entrada - t.Salida)
+	t.Rendimiento = 0
+	if t.Entrada > 0 {
+		t.Rendimiento = Round2(t.Salida * 100 / t.Entrada)
+	}
+
+	for i := range t.Productos {
+		out := &t.Productos[i]
+		out.Rendimiento, out.CostoAsignado, out.CostoUnitario = 0, 0, 0
+		if t.Entrada > 0 {
+			out.Rendimiento = Round2(out.Kilos * 100 / t.Entrada)
+		}
+		if base > 0 {
+			out.CostoAsignado = Round2(t.CostoTotal * out.Kilos * out.FactorCosto / base)
+		}
+		if out.Cantidad > 0 {
+			out.CostoUnitario = Round2(out.CostoAsignado / out.Cantidad)
+		}
+	}
+}
+
+// Request DTOs
+type TransformationInputRequest struct {
+	ProductoID    uint     `json:"producto_id" validate:"required"`
+	Cantidad      float64  `json:"cantidad" validate:"required,gt=0"`
+	CostoUnitario *float64 `json:"costo_unitario"` // si se omite, el costo del producto
+}
+
+type TransformationOutputRequest struct {
+	ProductoID  uint     `json:"producto_id" validate:"required"`
+	Cantidad    float64  `json:"cantidad" validate:"required,gt=0"`
+	FactorCosto *float64 `json:"factor_costo"` // si se omite, 1
+}
+
+type CreateTransformationRequest struct {
+	Fecha       string                        `json:"fecha" validate:"required"`
+	ProveedorID *uint                         `json:"proveedor_id"`
+	Descripcion string                        `json:"descripcion"`
+	Insumos     []TransformationInputRequest  `json:"insumos" validate:"required"`
+	Productos   []TransformationOutputRequest `json:"productos" validate:"required"`
+}
+
+// YieldReportRow es el rendimiento acumulado de una especie y proveedor, con
+// el detalle de cada producto de insumo
+type YieldReportRow struct {
+	EspecieID        *uint             `json:"especie_id"`
+	Especie          string            `json:"especie"`
+	ProveedorID      *uint             `json:"proveedor_id"`
+	Proveedor        string            `json:"proveedor"`
+	Transformaciones int               `json:"transformaciones"`
+	Entrada          float64           `json:"entrada"`
+	Salida           float64           `json:"salida"`
+	Merma            float64           `json:"merma"`
+	Rendimiento      float64           `json:"rendimiento"`
+	Costo            float64           `json:"costo"`
+	Productos        []YieldProductRow `json:"productos"`
+}
+
+// YieldProductRow es el rendimiento de un producto de insumo dentro de su especie
+type YieldProductRow struct {
+	ProductoID       uint    `json:"producto_id"`
+	Producto         string  `json:"producto"`
+	Transformaciones int     `json:"transformaciones"`
+	Entrada          float64 `json:"entrada"`
+	Salida           float64 `json:"salida"`
+	Merma            float64 `json:"merma"`
+	Rendimiento      float64 `json:"rendimiento"`
+	Costo            float64 `json:"costo"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "strings"
+
+// UnitConversion dice cuántas unidades de stock del producto (su TipoCantidad)
+// equivalen a una unidad alternativa: 1 caja = 10 kg, 1 unidad ≈ 0.8 kg
+type UnitConversion struct {
+	ID         uint    `json:"id" gorm:"primaryKey"`
+	ProductoID uint    `json:"producto_id" gorm:"uniqueIndex:idx_conversion_producto_unidad"`
+	Unidad     string  `json:"unidad" gorm:"type:varchar(20);uniqueIndex:idx_conversion_producto_unidad"`
+	Factor     float64 `json:"factor" gorm:"type:numeric(12,4)"`
+}
+
+func (UnitConversion) TableName() string {
+	return "conversiones_unidad"
+}
+
+// NormalizarUnidad compara unidades sin distinguir mayúsculas ni espacios
+func NormalizarUnidad(unidad string) string {
+	return strings.ToLower(strings.TrimSpace(unidad))
+}
+
+// Request DTOs
+type UnitConversionRequest struct {
+	Unidad string  `json:"unidad" validate:"required"`
+	Factor float64 `json:"factor" validate:"required,gt=0"`
+}
+
+// SetUnitConversionsRequest reemplaza todas las conversiones del producto
+type SetUnitConversionsRequest struct {
+	Conversiones []UnitConversionRequest `json:"conversiones"`
+}
@@ -0,0 +1,65 @@
+package pdf
+
+import (
+	"fmt"
+
+	"sanJoseProyect/models"
+)
+
+// CierreDiario imprime el resumen del día: stock por producto, anulaciones y ventas
+func CierreDiario(r *models.DailyClosingReport, emp Empresa) ([]byte, error) {
+	d := nuevoDocumento("P")
+	d.AddPage()
+	d.encabezadoListado(emp, "Cierre del día "+r.Fecha,
+		fmt.Sprintf("Entradas %s - Salidas %s - Merma %s",
+			cantidad(r.Entradas), cantidad(r.Salidas), cantidad(r.Merma)))
+
+	filas := make([][]string, 0, len(r.Productos))
+	for _, p := range r.Productos {
+		filas = append(filas, []string{
+			fmt.Sprint(p.Codigo),
+			p.Descripcion,
+			cantidad(p.StockInicial),
+			cantidad(p.Entradas),
+			cantidad(p.Salidas),
+			cantidad(p.Merma),
+			cantidad(p.StockFinal),
+		})
+	}
+	d.tabla([]string{"Código", "Descripción", "Apertura", "Entradas", "Salidas", "Merma", "Cierre"},
+		[]float64{18, 72, 20, 20, 20, 20, 20},
+		[]string{"C", "L", "R", "R", "R", "R", "R"}, filas)
+
+	if len(r.Anulaciones) > 0 {
+		d.Ln(4)
+		d.SetFont("Helvetica", "B", 11)
+		d.celda(0, 6, "Anulaciones del día", "", 1, "L")
+		filas = filas[:0]
+		for _, a := range r.Anulaciones {
+			filas = append(filas, []string{
+				a.Fecha.Format("02/01/2006"),
+				a.Descripcion,
+				a.Tipo,
+				fmt.Sprintf("%s %d", a.Comprobante, a.NumeroFactura),
+				cantidad(a.Cantidad),
+			})
+		}
+		d.tabla([]string{"Fecha", "Producto", "Tipo", "Comprobante", "Cantidad"},
+			[]float64{22, 74, 26, 46, 22},
+			[]string{"C", "L", "C", "L", "R"}, filas)
+	}
+
+	d.Ln(4)
+	d.SetFont("Helvetica", "B", 11)
+	d.celda(0, 6, "Ventas por forma de pago", "", 1, "L")
+	filas = filas[:0]
+	for _, v := range r.Ventas {
+		filas = append(filas, []string{v.FormaPago, fmt.Sprint(v.Comprobantes), moneda(v.Total)})
+	}
+	filas = append(filas, []string{"TOTAL", "", moneda(r.TotalVentas)})
+	d.tabla([]string{"Forma de pago", "Comprobantes", "Total"},
+		[]float64{80, 40, 70},
+		[]string{"L", "R", "R"}, filas)
+
+	return d.bytes()
+}
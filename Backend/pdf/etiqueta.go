@@ -0,0 +1,101 @@
+package pdf
+
+import (
+	"fmt"
+
+	"github.com/boombuler/barcode"
+	"github.com/boombuler/barcode/code128"
+	"github.com/boombuler/barcode/ean"
+	"github.com/jung-kurt/gofpdf"
+	"sanJoseProyect/models"
+)
+
+// Etiquetas genera una página por etiqueta (y por copia) del tamaño de la
+// plantilla, para imprimir en cualquier impresora con rollo de etiquetas
+func Etiquetas(etiquetas []models.Label, anchoMM, altoMM float64) ([]byte, error) {
+	f := gofpdf.NewCustom(&gofpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "mm",
+		Size:           gofpdf.SizeType{Wd: anchoMM, Ht: altoMM},
+	})
+	d := &documento{Fpdf: f, tr: f.UnicodeTranslatorFromDescriptor("")}
+	margen := 2.5
+	f.SetMargins(margen, margen, margen)
+	f.SetAutoPageBreak(false, 0)
+
+	// Los tamaños de letra (pt) y las alturas (mm) van en proporción al alto: el estándar es de 40 mm
+	escala := altoMM / 40
+	ancho := anchoMM - 2*margen
+	for _, e := range etiquetas {
+		copias := e.Copias
+		if copias < 1 {
+			copias = 1
+		}
+		var bc barcode.Barcode
+		var err error
+		if e.TipoBarra == models.BarraEAN13 {
+			bc, err = ean.Encode(e.CodigoBarra)
+		} else {
+			bc, err = code128.Encode(e.CodigoBarra)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("código de barras %s: %v", e.CodigoBarra, err)
+		}
+
+		for i := 0; i < copias; i++ {
+			f.AddPage()
+			f.SetFont("Helvetica", "B", 11*escala)
+			d.MultiCell(ancho, 4.5*escala, d.tr(e.Descripcion), "", "L", false)
+
+			f.SetFont("Helvetica", "", 7*escala)
+			if e.Especie != "" {
+				especie := e.Especie
+				if e.NombreCientifico != "" {
+					especie += " (" + e.NombreCientifico + ")"
+				}
+				d.celda(ancho, 3*escala, especie, "", 1, "L")
+			}
+			if e.ZonaCaptura != "" || e.Congelado {
+				zona := e.ZonaCaptura
+				if e.Congelado {
+					if zona != "" {
+						zona += " - "
+					}
+					zona += "Congelado"
+				}
+				d.celda(ancho, 3*escala, zona, "", 1, "L")
+			}
+
+			f.SetFont("Helvetica", "B", 10*escala)
+			cant := numero(e.Cantidad, 0) + " " + e.Unidad
+			if models.NormalizarUnidad(e.Unidad) == models.UnidadKg {
+				cant = numero(e.Cantidad, 3) + " kg"
+			}
+			if e.Importe > 0 {
+				d.celda(ancho/2, 4.5*escala, cant, "", 0, "L")
+				d.celda(ancho/2, 4.5*escala, moneda(e.Importe), "", 1, "R")
+			} else {
+				d.celda(ancho, 4.5*escala, cant, "", 1, "L")
+			}
+
+			f.SetFont("Helvetica", "", 7*escala)
+			lote := fmt.Sprintf("Lote %s   Env. %s", e.Lote, e.Envasado.Format("02/01/2006"))
+			if e.Vencimiento != nil {
+				lote += "   Vto. " + e.Vencimiento.Format("02/01/2006")
+			}
+			d.celda(ancho, 3*escala, lote, "", 1, "L")
+
+			// El código ocupa lo que queda abajo, con los números debajo de las barras
+			y := f.GetY() + 1*escala
+			altoBarras := altoMM - margen - 3*escala - y
+			if altoBarras > 3 {
+				anchoBarras := ancho * 0.8
+				d.dibujarCodigo(bc, margen+(ancho-anchoBarras)/2, y, anchoBarras, altoBarras)
+				f.SetXY(margen, y+altoBarras)
+				d.celda(ancho, 3*escala, e.CodigoBarra, "", 1, "C")
+			}
+		}
+	}
+
+	return d.bytes()
+}
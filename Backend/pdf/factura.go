@@ -0,0 +1,210 @@
+package pdf
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/boombuler/barcode/qr"
+	"sanJoseProyect/models"
+)
+
+var nombresCondicion = map[string]string{
+	models.CondicionResponsableInscripto: "IVA Responsable Inscripto",
+	models.CondicionMonotributo:          "Responsable Monotributo",
+	models.CondicionExento:               "IVA Sujeto Exento",
+	models.CondicionConsumidorFinal:      "Consumidor Final",
+}
+
+var codigosComprobante = map[string]map[string]string{
+	models.FacturaVenta: {"A": "01", "B": "06", "C": "11"},
+	models.NotaCredito:  {"A": "03", "B": "08", "C": "13"},
+}
+
+// encabezadoFiscal dibuja el recuadro con los datos del emisor, la letra y el número
+func (d *documento) encabezadoFiscal(emp Empresa, titulo, letra, codigo string, puntoVenta, numero int, fecha string) {
+	x, y := d.GetX(), d.GetY()
+	ancho, alto := 190.0, 40.0
+	d.Rect(x, y, ancho, alto, "D")
+	d.Line(x+ancho/2, y+14, x+ancho/2, y+alto)
+
+	// Letra en el centro
+	d.Rect(x+ancho/2-8, y, 16, 14, "D")
+	d.SetXY(x+ancho/2-8, y+1)
+	d.SetFont("Helvetica", "B", 22)
+	d.celda(16, 9, letra, "", 2, "C")
+	d.SetX(x + ancho/2 - 8)
+	d.SetFont("Helvetica", "", 6)
+	if codigo != "" {
+		d.celda(16, 3, "COD. "+codigo, "", 0, "C")
+	}
+
+	// Emisor a la izquierda
+	d.SetXY(x+3, y+3)
+	d.SetFont("Helvetica", "B", 14)
+	d.celda(ancho/2-14, 7, emp.NombreFantasia, "", 2, "L")
+	d.SetFont("Helvetica", "", 8)
+	d.SetX(x + 3)
+	if emp.RazonSocial != "" {
+		d.celda(ancho/2-6, 4, "Razón social: "+emp.RazonSocial, "", 2, "L")
+	}
+	d.SetX(x + 3)
+	d.celda(ancho/2-6, 4, "Domicilio: "+emp.Domicilio, "", 2, "L")
+	d.SetX(x + 3)
+	d.celda(ancho/2-6, 4, "Condición frente al IVA: "+emp.CondicionIVA, "", 2, "L")
+
+	// Comprobante a la derecha
+	d.SetXY(x+ancho/2+12, y+3)
+	d.SetFont("Helvetica", "B", 14)
+	d.celda(ancho/2-14, 7, titulo, "", 2, "L")
+	d.SetFont("Helvetica", "", 8)
+	d.SetX(x + ancho/2 + 12)
+	d.celda(ancho/2-14, 4, fmt.Sprintf("Punto de venta: %04d   Comp. Nro: %08d", puntoVenta, numero), "", 2, "L")
+	d.SetX(x + ancho/2 + 12)
+	d.celda(ancho/2-14, 4, "Fecha de emisión: "+fecha, "", 2, "L")
+	d.SetXY(x+ancho/2+3, y+22)
+	d.celda(ancho/2-6, 4, "CUIT: "+emp.CUIT, "", 2, "L")
+	d.SetX(x + ancho/2 + 3)
+	d.celda(ancho/2-6, 4, "Ingresos Brutos: "+emp.IngresosBrutos, "", 2, "L")
+	d.SetX(x + ancho/2 + 3)
+	d.celda(ancho/2-6, 4, "Inicio de actividades: "+emp.InicioActividades, "", 2, "L")
+
+	d.SetXY(x, y+alto+2)
+}
+
+// recuadroCliente dibuja los datos del receptor
+func (d *documento) recuadroCliente(cliente *models.Customer, condicion string) {
+	razonSocial, cuit, domicilio := "Consumidor Final", "", ""
+	if cliente != nil {
+		razonSocial, cuit, domicilio = cliente.RazonSocial, cliente.CUIT, cliente.Domicilio
+	}
+	nombreCondicion := nombresCondicion[condicion]
+	if nombreCondicion == "" {
+		nombreCondicion = condicion
+	}
+
+	d.SetFont("Helvetica", "", 9)
+	y := d.GetY()
+	d.Rect(10, y, 190, 14, "D")
+	d.SetXY(12, y+1)
+	d.celda(110, 6, "Cliente: "+razonSocial, "", 0, "L")
+	d.celda(76, 6, "CUIT: "+cuit, "", 1, "L")
+	d.SetX(12)
+	d.celda(110, 6, "Domicilio: "+domicilio, "", 0, "L")
+	d.celda(76, 6, "Condición IVA: "+nombreCondicion, "", 1, "L")
+	d.SetXY(10, y+16)
+}
+
+// Factura genera el PDF de una factura de venta o nota de crédito con sus
+// líneas, cliente y, si ya tiene CAE, el código QR de AFIP (qrURL).
+func Factura(f *models.Invoice, emp Empresa, qrURL string) ([]byte, error) {
+	d := nuevoDocumento("P")
+	d.AddPage()
+
+	titulo := "FACTURA"
+	if f.Tipo == models.NotaCredito {
+		titulo = "NOTA DE CRÉDITO"
+	}
+	d.encabezadoFiscal(emp, titulo, f.Letra, codigosComprobante[f.Tipo][f.Letra], f.PuntoVenta, f.NumeroFiscal(), f.Fecha.Format("02/01/2006"))
+	d.recuadroCliente(f.Cliente, f.CondicionIVA)
+	if asoc := f.FacturaAsociada; asoc != nil {
+		d.SetFont("Helvetica", "", 9)
+		d.celda(0, 6, fmt.Sprintf("Comprobante asociado: Factura %s %04d-%08d del %s",
+			asoc.Letra, asoc.PuntoVenta, asoc.NumeroFiscal(), asoc.Fecha.Format("02/01/2006")), "", 1, "L")
+		d.Ln(1)
+	}
+
+	// En la A se discrimina el IVA; en la B y la C los precios van finales
+	discrimina := f.Letra == "A"
+	var columnas []string
+	var anchos []float64
+	if discrimina {
+		columnas = []string{"Código", "Descripción", "Cantidad", "Unidad", "P. Unit.", "% IVA", "Subtotal"}
+		anchos = []float64{18, 70, 18, 18, 24, 14, 28}
+	} else {
+		columnas = []string{"Código", "Descripción", "Cantidad", "Unidad", "P. Unit.", "Subtotal"}
+		anchos = []float64{18, 84, 18, 18, 24, 28}
+	}
+	alineacion := []string{"C", "L", "R", "C", "R", "R", "R"}
+
+	var filas [][]string
+	for _, l := range f.Lineas {
+		codigo, descripcion, unidad := "", "", ""
+		if l.Producto != nil {
+			codigo = fmt.Sprint(l.Producto.Codigo)
+			descripcion = l.Producto.Descripcion
+			unidad = l.Producto.TipoCantidad
+		}
+		if discrimina {
+			filas = append(filas, []string{codigo, descripcion, cantidad(l.Cantidad), unidad,
+				moneda(l.PrecioUnitario), l.AlicuotaIVA, moneda(l.Neto)})
+		} else {
+			unitario := l.PrecioUnitario
+			if l.Cantidad > 0 {
+				unitario = l.Total / l.Cantidad
+			}
+			filas = append(filas, []string{codigo, descripcion, cantidad(l.Cantidad), unidad,
+				moneda(unitario), moneda(l.Total)})
+		}
+	}
+	if !discrimina {
+		alineacion = []string{"C", "L", "R", "C", "R", "R"}
+	}
+	d.tabla(columnas, anchos, alineacion, filas)
+
+	// Totales
+	d.Ln(4)
+	totales := [][2]string{}
+	if discrimina {
+		totales = append(totales,
+			[2]string{"Neto gravado", moneda(f.NetoGravado)},
+			[2]string{"Exento / No gravado", moneda(f.Exento)},
+			[2]string{"IVA 10,5 %", moneda(f.IVA105)},
+			[2]string{"IVA 21 %", moneda(f.IVA21)},
+		)
+	}
+	totales = append(totales, [2]string{"TOTAL", moneda(f.Total)})
+	for i, t := range totales {
+		estilo := ""
+		if i == len(totales)-1 {
+			estilo = "B"
+		}
+		d.SetFont("Helvetica", estilo, 10)
+		d.SetX(120)
+		d.celda(45, 6, t[0], "", 0, "R")
+		d.celda(35, 6, t[1], "", 1, "R")
+	}
+	if f.Letra == "B" && f.IVA105+f.IVA21 > 0 {
+		// Régimen de transparencia fiscal al consumidor (Ley 27.743)
+		d.SetFont("Helvetica", "", 8)
+		d.SetX(120)
+		d.celda(80, 5, "IVA contenido: "+moneda(f.IVA105+f.IVA21), "", 1, "R")
+	}
+
+	// Autorización AFIP
+	d.Ln(6)
+	y := d.GetY()
+	if f.CAE != "" {
+		if qrURL != "" {
+			if codigo, err := qr.Encode(qrURL, qr.M, qr.Auto); err == nil {
+				d.dibujarCodigo(codigo, 10, y, 30, 30)
+			}
+		}
+		d.SetXY(45, y+8)
+		d.SetFont("Helvetica", "B", 10)
+		d.celda(0, 6, "CAE N°: "+f.CAE, "", 1, "L")
+		d.SetX(45)
+		vto := ""
+		if f.CAEVencimiento != nil {
+			vto = f.CAEVencimiento.Format("02/01/2006")
+		}
+		d.celda(0, 6, "Fecha de vto. de CAE: "+vto, "", 1, "L")
+		d.SetX(45)
+		d.SetFont("Helvetica", "I", 8)
+		d.celda(0, 5, "Comprobante autorizado", "", 1, "L")
+	} else {
+		d.SetFont("Helvetica", "B", 10)
+		d.celda(0, 6, "Comprobante pendiente de autorización de AFIP - no válido como "+strings.ToLower(titulo), "", 1, "L")
+	}
+
+	return d.bytes()
+}
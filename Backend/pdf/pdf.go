@@ -0,0 +1,203 @@
+// Package pdf arma los documentos imprimibles (facturas, remitos, listados)
+// del lado del servidor, para no depender del diseño de impresión del navegador.
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/boombuler/barcode"
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Empresa son los datos del negocio que van en el encabezado
+type Empresa struct {
+	RazonSocial       string
+	NombreFantasia    string
+	CUIT              string
+	Domicilio         string
+	CondicionIVA      string
+	IngresosBrutos    string
+	InicioActividades string
+}
+
+// EmpresaDesdeEntorno lee los datos del negocio de las variables EMPRESA_*
+func EmpresaDesdeEntorno() Empresa {
+	emp := Empresa{
+		RazonSocial:       os.Getenv("EMPRESA_RAZON_SOCIAL"),
+		NombreFantasia:    os.Getenv("EMPRESA_NOMBRE_FANTASIA"),
+		CUIT:              os.Getenv("EMPRESA_CUIT"),
+		Domicilio:         os.Getenv("EMPRESA_DOMICILIO"),
+		CondicionIVA:      os.Getenv("EMPRESA_CONDICION_IVA"),
+		IngresosBrutos:    os.Getenv("EMPRESA_IIBB"),
+		InicioActividades: os.Getenv("EMPRESA_INICIO_ACTIVIDADES"),
+	}
+	if emp.NombreFantasia == "" {
+		emp.NombreFantasia = "Pescadería San José"
+	}
+	if emp.CUIT == "" {
+		emp.CUIT = os.Getenv("AFIP_CUIT")
+	}
+	if emp.CondicionIVA == "" {
+		emp.CondicionIVA = "IVA Responsable Inscripto"
+	}
+	return emp
+}
+
+// documento envuelve gofpdf con el traductor a cp1252 (las fuentes base no son UTF-8)
+type documento struct {
+	*gofpdf.Fpdf
+	tr func(string) string
+}
+
+func nuevoDocumento(orientacion string) *documento {
+	f := gofpdf.New(orientacion, "mm", "A4", "")
+	d := &documento{Fpdf: f, tr: f.UnicodeTranslatorFromDescriptor("")}
+	f.SetMargins(10, 10, 10)
+	f.SetAutoPageBreak(true, 15)
+	f.AliasNbPages("")
+	f.SetFooterFunc(func() {
+		f.SetY(-12)
+		f.SetFont("Helvetica", "I", 8)
+		f.CellFormat(0, 5, d.tr(fmt.Sprintf("Página %d/{nb}", f.PageNo())), "", 0, "R", false, 0, "")
+	})
+	return d
+}
+
+// celda escribe texto UTF-8 en una celda
+func (d *documento) celda(w, h float64, txt, borde string, ln int, alin string) {
+	d.CellFormat(w, h, d.tr(txt), borde, ln, alin, false, 0, "")
+}
+
+// celdaFondo escribe una celda con el color de relleno actual
+func (d *documento) celdaFondo(w, h float64, txt, borde string, ln int, alin string) {
+	d.CellFormat(w, h, d.tr(txt), borde, ln, alin, true, 0, "")
+}
+
+func (d *documento) bytes() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := d.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// encabezadoListado es el título simple de los reportes (no fiscales)
+func (d *documento) encabezadoListado(emp Empresa, titulo, subtitulo string) {
+	d.SetFont("Helvetica", "B", 14)
+	d.celda(0, 7, emp.NombreFantasia, "", 1, "L")
+	d.SetFont("Helvetica", "B", 12)
+	d.celda(0, 6, titulo, "", 1, "L")
+	d.SetFont("Helvetica", "", 9)
+	if subtitulo != "" {
+		d.celda(0, 5, subtitulo, "", 1, "L")
+	}
+	d.celda(0, 5, "Generado: "+time.Now().Format("02/01/2006 15:04"), "", 1, "L")
+	d.Ln(3)
+}
+
+// tabla dibuja una tabla con encabezado gris y repite el encabezado en cada página
+func (d *documento) tabla(columnas []string, anchos []float64, alineacion []string, filas [][]string) {
+	encabezado := func() {
+		d.SetFont("Helvetica", "B", 9)
+		d.SetFillColor(220, 220, 220)
+		for i, col := range columnas {
+			d.celdaFondo(anchos[i], 6, col, "1", 0, "C")
+		}
+		d.Ln(-1)
+		d.SetFont("Helvetica", "", 9)
+	}
+
+	encabezado()
+	_, altoPagina := d.GetPageSize()
+	_, _, _, margenInf := d.GetMargins()
+	for _, fila := range filas {
+		if d.GetY()+5 > altoPagina-margenInf-5 {
+			d.AddPage()
+			encabezado()
+		}
+		for i, valor := range fila {
+			d.celda(anchos[i], 5, valor, "1", 0, alineacion[i])
+		}
+		d.Ln(-1)
+	}
+}
+
+// dibujarCodigo dibuja un código de barras o QR como rectángulos vectoriales
+func (d *documento) dibujarCodigo(bc barcode.Barcode, x, y, w, h float64) {
+	b := bc.Bounds()
+	cols, filas := b.Dx(), b.Dy()
+	if cols == 0 || filas == 0 {
+		return
+	}
+	mw, mh := w/float64(cols), h/float64(filas)
+
+	d.SetFillColor(0, 0, 0)
+	for fy := 0; fy < filas; fy++ {
+		// Junta los módulos oscuros consecutivos de la fila en un solo rectángulo
+		inicio := -1
+		for fx := 0; fx <= cols; fx++ {
+			oscuro := false
+			if fx < cols {
+				r, _, _, _ := bc.At(b.Min.X+fx, b.Min.Y+fy).RGBA()
+				oscuro = r < 0x8000
+			}
+			if oscuro && inicio < 0 {
+				inicio = fx
+			}
+			if !oscuro && inicio >= 0 {
+				d.Rect(x+float64(inicio)*mw, y+float64(fy)*mh, float64(fx-inicio)*mw, mh, "F")
+				inicio = -1
+			}
+		}
+	}
+}
+
+// ============================================
+// FORMATO
+// ============================================
+
+// numero formatea al estilo argentino: 1.234,56
+func numero(x float64, decimales int) string {
+	negativo := x < 0
+	x = math.Abs(x)
+	s := fmt.Sprintf("%.*f", decimales, x)
+
+	entera, fraccion := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		entera, fraccion = s[:i], s[i+1:]
+	}
+
+	var b strings.Builder
+	for i, r := range entera {
+		if i > 0 && (len(entera)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	if fraccion != "" {
+		b.WriteByte(',')
+		b.WriteString(fraccion)
+	}
+	if negativo {
+		return "-" + b.String()
+	}
+	return b.String()
+}
+
+func moneda(x float64) string {
+	return "$ " + numero(x, 2)
+}
+
+// cantidad muestra los gramos sólo si los hay: 12 o 1,25
+func cantidad(x float64) string {
+	s := numero(x, 3)
+	if strings.IndexByte(s, ',') >= 0 {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ",")
+	}
+	return s
+}
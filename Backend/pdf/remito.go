@@ -0,0 +1,53 @@
+package pdf
+
+import (
+	"fmt"
+
+	"sanJoseProyect/models"
+)
+
+// Remito genera el PDF del remito con la mercadería entregada, sin precios
+func Remito(r *models.DeliveryNote, emp Empresa) ([]byte, error) {
+	d := nuevoDocumento("P")
+	d.AddPage()
+
+	d.encabezadoFiscal(emp, "REMITO", "R", "91", r.PuntoVenta, r.Numero, r.Fecha.Format("02/01/2006"))
+	condicion := models.CondicionConsumidorFinal
+	if r.Cliente != nil {
+		condicion = r.Cliente.CondicionIVA
+	}
+	d.recuadroCliente(r.Cliente, condicion)
+
+	if r.Descripcion != "" {
+		d.SetFont("Helvetica", "", 9)
+		d.celda(0, 5, r.Descripcion, "", 1, "L")
+		d.Ln(2)
+	}
+
+	filas := make([][]string, 0, len(r.Lineas))
+	for _, l := range r.Lineas {
+		codigo, descripcion, unidad := "", "", ""
+		if l.Producto != nil {
+			codigo = fmt.Sprint(l.Producto.Codigo)
+			descripcion = l.Producto.Descripcion
+			unidad = l.Producto.TipoCantidad
+		}
+		filas = append(filas, []string{codigo, descripcion, cantidad(l.Cantidad), unidad})
+	}
+	d.tabla(
+		[]string{"Código", "Descripción", "Cantidad", "Unidad"},
+		[]float64{25, 115, 25, 25},
+		[]string{"C", "L", "R", "C"},
+		filas,
+	)
+
+	d.Ln(20)
+	d.SetFont("Helvetica", "", 9)
+	d.celda(95, 5, "Recibí conforme: ______________________", "", 0, "L")
+	d.celda(95, 5, "Aclaración: ______________________", "", 1, "L")
+	d.Ln(6)
+	d.SetFont("Helvetica", "I", 8)
+	d.celda(0, 5, "Documento no válido como factura", "", 1, "C")
+
+	return d.bytes()
+}
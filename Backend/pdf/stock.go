@@ -0,0 +1,32 @@
+package pdf
+
+import (
+	"fmt"
+
+	"sanJoseProyect/models"
+)
+
+// Stock genera el listado de stock actual de todos los productos
+func Stock(productos []models.Product, emp Empresa) ([]byte, error) {
+	d := nuevoDocumento("P")
+	d.AddPage()
+	d.encabezadoListado(emp, "Listado de stock", fmt.Sprintf("%d productos", len(productos)))
+
+	columnas := []string{"Código", "Descripción", "Unidad", "Stock inicial", "Stock actual"}
+	anchos := []float64{22, 98, 22, 24, 24}
+	alineacion := []string{"C", "L", "C", "R", "R"}
+
+	filas := make([][]string, 0, len(productos))
+	for _, p := range productos {
+		filas = append(filas, []string{
+			fmt.Sprint(p.Codigo),
+			p.Descripcion,
+			p.TipoCantidad,
+			cantidad(p.StockInicial),
+			cantidad(p.Stock),
+		})
+	}
+	d.tabla(columnas, anchos, alineacion, filas)
+
+	return d.bytes()
+}
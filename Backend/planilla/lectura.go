@@ -0,0 +1,236 @@
+package planilla
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/csv"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// ============================================
+// LECTURA
+// ============================================
+
+// Leer devuelve todas las filas de un CSV o de la primera hoja de un XLSX,
+// según la extensión del archivo. Las celdas vacías quedan como "".
+func Leer(nombreArchivo string, r io.Reader) ([][]string, error) {
+	datos, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(path.Ext(nombreArchivo)) {
+	case ".csv", ".txt":
+		return LeerCSV(datos)
+	case ".xlsx":
+		return LeerXLSX(datos)
+	}
+	return nil, fmt.Errorf("el archivo debe ser .csv o .xlsx")
+}
+
+// LeerCSV acepta lo que exporta Excel: con o sin BOM y separado por punto y
+// coma, coma o tabulación (se toma el que más aparece en la primera línea)
+func LeerCSV(datos []byte) ([][]string, error) {
+	datos = bytes.TrimPrefix(datos, []byte("\ufeff"))
+	primera := datos
+	if i := bytes.IndexByte(datos, '\n'); i >= 0 {
+		primera = datos[:i]
+	}
+	separador := ';'
+	for _, s := range []rune{',', '\t'} {
+		if bytes.Count(primera, []byte(string(s))) > bytes.Count(primera, []byte(string(separador))) {
+			separador = s
+		}
+	}
+
+	cr := csv.NewReader(bytes.NewReader(datos))
+	cr.Comma = separador
+	cr.FieldsPerRecord = -1
+	cr.LazyQuotes = true
+	filas, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV inválido: %v", err)
+	}
+	return filas, nil
+}
+
+type textoXLSX struct {
+	Texto  string `xml:"t"`
+	Tramos []struct {
+		Texto string `xml:"t"`
+	} `xml:"r"`
+}
+
+// String une el texto simple y los tramos con formato (texto enriquecido)
+func (t textoXLSX) String() string {
+	s := t.Texto
+	for _, tramo := range t.Tramos {
+		s += tramo.Texto
+	}
+	return s
+}
+
+type celdaXLSX struct {
+	Ref    string    `xml:"r,attr"`
+	Tipo   string    `xml:"t,attr"`
+	Valor  string    `xml:"v"`
+	Inline textoXLSX `xml:"is"`
+}
+
+type hojaLeidaXLSX struct {
+	Filas []struct {
+		Celdas []celdaXLSX `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+// LeerXLSX lee la primera hoja del libro. Los números se devuelven como los
+// guarda Excel (con punto decimal); las fechas no se convierten.
+func LeerXLSX(datos []byte) ([][]string, error) {
+	z, err := zip.NewReader(bytes.NewReader(datos), int64(len(datos)))
+	if err != nil {
+		return nil, fmt.Errorf("XLSX inválido: %v", err)
+	}
+	partes := map[string]*zip.File{}
+	for _, f := range z.File {
+		partes[f.Name] = f
+	}
+
+	var compartidos []string
+	if f := partes["xl/sharedStrings.xml"]; f != nil {
+		var sst struct {
+			Items []textoXLSX `xml:"si"`
+		}
+		if err := leerXML(f, &sst); err != nil {
+			return nil, err
+		}
+		for _, item := range sst.Items {
+			compartidos = append(compartidos, item.String())
+		}
+	}
+
+	ruta, err := primeraHoja(partes)
+	if err != nil {
+		return nil, err
+	}
+	var hoja hojaLeidaXLSX
+	if err := leerXML(partes[ruta], &hoja); err != nil {
+		return nil, err
+	}
+
+	filas := make([][]string, 0, len(hoja.Filas))
+	for _, f := range hoja.Filas {
+		var fila []string
+		for _, c := range f.Celdas {
+			col := len(fila)
+			if c.Ref != "" {
+				col = indiceColumna(c.Ref)
+			}
+			for len(fila) <= col {
+				fila = append(fila, "")
+			}
+			switch c.Tipo {
+			case "s":
+				i, err := strconv.Atoi(c.Valor)
+				if err != nil || i < 0 || i >= len(compartidos) {
+					return nil, fmt.Errorf("XLSX inválido: texto compartido %q inexistente", c.Valor)
+				}
+				fila[col] = compartidos[i]
+			case "inlineStr":
+				fila[col] = c.Inline.String()
+			default:
+				fila[col] = c.Valor
+			}
+		}
+		filas = append(filas, fila)
+	}
+	return filas, nil
+}
+
+// primeraHoja busca en el libro la ruta de la primera hoja
+func primeraHoja(partes map[string]*zip.File) (string, error) {
+	var libro struct {
+		Hojas []struct {
+			ID string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+		} `xml:"sheets>sheet"`
+	}
+	var relaciones struct {
+		Items []struct {
+			ID     string `xml:"Id,attr"`
+			Target string `xml:"Target,attr"`
+		} `xml:"Relationship"`
+	}
+	if f, r := partes["xl/workbook.xml"], partes["xl/_rels/workbook.xml.rels"]; f != nil && r != nil {
+		if err := leerXML(f, &libro); err != nil {
+			return "", err
+		}
+		if err := leerXML(r, &relaciones); err != nil {
+			return "", err
+		}
+		if len(libro.Hojas) > 0 {
+			for _, rel := range relaciones.Items {
+				if rel.ID != libro.Hojas[0].ID {
+					continue
+				}
+				ruta := strings.TrimPrefix(rel.Target, "/")
+				if !strings.HasPrefix(ruta, "xl/") {
+					ruta = path.Join("xl", ruta)
+				}
+				if partes[ruta] != nil {
+					return ruta, nil
+				}
+			}
+		}
+	}
+	if partes["xl/worksheets/sheet1.xml"] != nil {
+		return "xl/worksheets/sheet1.xml", nil
+	}
+	return "", fmt.Errorf("XLSX inválido: el libro no tiene hojas")
+}
+
+func leerXML(f *zip.File, destino interface{}) error {
+	r, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	if err := xml.NewDecoder(r).Decode(destino); err != nil {
+		return fmt.Errorf("XLSX inválido (%s): %v", f.Name, err)
+	}
+	return nil
+}
+
+// indiceColumna convierte la referencia de celda (B7, AA3) en el índice de columna desde 0
+func indiceColumna(ref string) int {
+	col := 0
+	for _, r := range ref {
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		col = col*26 + int(r-'A'+1)
+	}
+	return col - 1
+}
+
+// Numero interpreta un número escrito en castellano (1.234,5) o como lo
+// guarda Excel (1234.5)
+func Numero(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if strings.Contains(s, ",") {
+		s = strings.Replace(strings.ReplaceAll(s, ".", ""), ",", ".", 1)
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// NormalizarEncabezado lleva el título de una columna a minúsculas, sin
+// acentos y con guiones bajos: "Stock inicial" → "stock_inicial"
+func NormalizarEncabezado(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "ü", "u", "ñ", "n").Replace(s)
+	return strings.Join(strings.FieldsFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+	}), "_")
+}
@@ -0,0 +1,103 @@
+// Package planilla escribe planillas CSV y XLSX fila por fila, sin armar el
+// archivo entero en memoria, con el formato que se usa en Argentina: coma
+// decimal y fechas dd/mm/aaaa.
+package planilla
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Decimal es un importe o cantidad con dos decimales
+type Decimal float64
+
+// Hoja recibe las filas de una planilla. Los valores pueden ser string, int,
+// uint, Decimal, float64 o time.Time.
+type Hoja interface {
+	Encabezado(columnas ...string) error
+	Fila(valores ...interface{}) error
+	Cerrar() error
+}
+
+// Nueva crea la hoja en el formato pedido: "csv" o "xlsx"
+func Nueva(formato string, w io.Writer, nombre string) (Hoja, error) {
+	switch formato {
+	case "csv":
+		return NuevaCSV(w)
+	case "xlsx":
+		return NuevaXLSX(w, nombre)
+	}
+	return nil, fmt.Errorf("formato de planilla desconocido: %s", formato)
+}
+
+// TipoContenido devuelve el Content-Type del formato
+func TipoContenido(formato string) string {
+	if formato == "xlsx" {
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ============================================
+// CSV
+// ============================================
+
+type hojaCSV struct {
+	w *csv.Writer
+}
+
+// NuevaCSV escribe separado por punto y coma y con BOM, para que Excel en
+// castellano lo abra en columnas y respete los acentos
+func NuevaCSV(w io.Writer) (Hoja, error) {
+	if _, err := io.WriteString(w, "\ufeff"); err != nil {
+		return nil, err
+	}
+	cw := csv.NewWriter(w)
+	cw.Comma = ';'
+	return &hojaCSV{w: cw}, nil
+}
+
+func (h *hojaCSV) Encabezado(columnas ...string) error {
+	return h.w.Write(columnas)
+}
+
+func (h *hojaCSV) Fila(valores ...interface{}) error {
+	registro := make([]string, len(valores))
+	for i, v := range valores {
+		registro[i] = Texto(v)
+	}
+	return h.w.Write(registro)
+}
+
+func (h *hojaCSV) Cerrar() error {
+	h.w.Flush()
+	return h.w.Error()
+}
+
+// Texto formatea un valor como se muestra en castellano
+func Texto(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return x
+	case int:
+		return strconv.Itoa(x)
+	case uint:
+		return strconv.FormatUint(uint64(x), 10)
+	case Decimal:
+		return strings.Replace(strconv.FormatFloat(float64(x), 'f', 2, 64), ".", ",", 1)
+	case float64:
+		return strings.Replace(strconv.FormatFloat(x, 'f', -1, 64), ".", ",", 1)
+	case time.Time:
+		if x.IsZero() {
+			return ""
+		}
+		return x.Format("02/01/2006")
+	}
+	return fmt.Sprint(v)
+}
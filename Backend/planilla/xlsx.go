@@ -0,0 +1,181 @@
+package planilla
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Estilos definidos en styles.xml (índices de cellXfs)
+const (
+	estiloNormal     = 0
+	estiloFecha      = 1
+	estiloDecimal    = 2
+	estiloEncabezado = 3
+)
+
+const estilosXLSX = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<numFmts count="1"><numFmt numFmtId="164" formatCode="dd/mm/yyyy"/></numFmts>
+<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>
+<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
+<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
+<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+<cellXfs count="4">
+<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>
+<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="4" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>
+<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>
+</cellXfs>
+</styleSheet>`
+
+// hojaXLSX escribe un libro de una sola hoja. Las partes fijas van primero y
+// la hoja al final, para poder escribir el zip de corrido.
+type hojaXLSX struct {
+	zip  *zip.Writer
+	w    *bufio.Writer
+	fila int
+}
+
+// NuevaXLSX crea un libro de Excel con una hoja llamada `nombre`
+func NuevaXLSX(w io.Writer, nombre string) (Hoja, error) {
+	z := zip.NewWriter(w)
+	partes := []struct{ ruta, contenido string }{
+		{"[Content_Types].xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
+</Types>`},
+		{"_rels/.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`},
+		{"xl/workbook.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="` + escapar(nombreHoja(nombre)) + `" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`},
+		{"xl/_rels/workbook.xml.rels", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`},
+		{"xl/styles.xml", estilosXLSX},
+	}
+	for _, p := range partes {
+		f, err := z.Create(p.ruta)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := io.WriteString(f, p.contenido); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+	h := &hojaXLSX{zip: z, w: bufio.NewWriter(f)}
+	h.w.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` +
+		`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	return h, nil
+}
+
+func (h *hojaXLSX) Encabezado(columnas ...string) error {
+	h.fila++
+	fmt.Fprintf(h.w, `<row r="%d">`, h.fila)
+	for i, col := range columnas {
+		h.texto(i, col, estiloEncabezado)
+	}
+	_, err := h.w.WriteString("</row>")
+	return err
+}
+
+func (h *hojaXLSX) Fila(valores ...interface{}) error {
+	h.fila++
+	fmt.Fprintf(h.w, `<row r="%d">`, h.fila)
+	for i, v := range valores {
+		switch x := v.(type) {
+		case nil:
+		case int:
+			h.numero(i, strconv.Itoa(x), estiloNormal)
+		case uint:
+			h.numero(i, strconv.FormatUint(uint64(x), 10), estiloNormal)
+		case Decimal:
+			h.numero(i, strconv.FormatFloat(float64(x), 'f', 2, 64), estiloDecimal)
+		case float64:
+			h.numero(i, strconv.FormatFloat(x, 'f', -1, 64), estiloNormal)
+		case time.Time:
+			if !x.IsZero() {
+				h.numero(i, strconv.Itoa(serialExcel(x)), estiloFecha)
+			}
+		default:
+			h.texto(i, Texto(v), estiloNormal)
+		}
+	}
+	_, err := h.w.WriteString("</row>")
+	return err
+}
+
+func (h *hojaXLSX) Cerrar() error {
+	h.w.WriteString("</sheetData></worksheet>")
+	if err := h.w.Flush(); err != nil {
+		return err
+	}
+	return h.zip.Close()
+}
+
+func (h *hojaXLSX) texto(col int, s string, estilo int) {
+	fmt.Fprintf(h.w, `<c r="%s%d" t="inlineStr" s="%d"><is><t xml:space="preserve">%s</t></is></c>`,
+		columna(col), h.fila, estilo, escapar(s))
+}
+
+func (h *hojaXLSX) numero(col int, valor string, estilo int) {
+	fmt.Fprintf(h.w, `<c r="%s%d" s="%d"><v>%s</v></c>`, columna(col), h.fila, estilo, valor)
+}
+
+// columna convierte el índice (desde 0) en la letra de Excel: A, B, ..., Z, AA
+func columna(i int) string {
+	nombre := ""
+	for i++; i > 0; i = (i - 1) / 26 {
+		nombre = string(rune('A'+(i-1)%26)) + nombre
+	}
+	return nombre
+}
+
+func escapar(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// nombreHoja ajusta el nombre a lo que acepta Excel: hasta 31 caracteres y sin : \ / ? * [ ]
+func nombreHoja(nombre string) string {
+	nombre = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`:\/?*[]`, r) {
+			return '-'
+		}
+		return r
+	}, nombre)
+	if r := []rune(nombre); len(r) > 31 {
+		nombre = string(r[:31])
+	}
+	if nombre == "" {
+		nombre = "Hoja1"
+	}
+	return nombre
+}
+
+// serialExcel es la fecha como número de días desde el 30/12/1899
+func serialExcel(t time.Time) int {
+	fecha := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return int(fecha.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24)
+}
@@ -0,0 +1,82 @@
+// Package pronostico estima la demanda diaria de cada producto a partir del
+// historial de ventas, con el patrón de cada día de la semana, las temporadas
+// (Semana Santa y Fiestas) y los feriados nacionales.
+package pronostico
+
+import "time"
+
+// Temporadas de venta alta
+const (
+	SemanaSanta = "SEMANA_SANTA"
+	Fiestas     = "FIESTAS"
+)
+
+// DomingoDePascua calcula la fecha de Pascua (algoritmo de Meeus/Jones/Butcher)
+func DomingoDePascua(anio int) time.Time {
+	a := anio % 19
+	b, c := anio/100, anio%100
+	d, e := b/4, b%4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i, k := c/4, c%4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	mes := (h + l - 7*m + 114) / 31
+	dia := (h+l-7*m+114)%31 + 1
+	return time.Date(anio, time.Month(mes), dia, 0, 0, 0, 0, time.Local)
+}
+
+// RangoTemporada devuelve el primer y el último día de la temporada en el año:
+// Semana Santa va del Domingo de Ramos al Sábado Santo y las Fiestas del 15 al
+// 31 de diciembre
+func RangoTemporada(temporada string, anio int) (time.Time, time.Time) {
+	if temporada == SemanaSanta {
+		pascua := DomingoDePascua(anio)
+		return pascua.AddDate(0, 0, -7), pascua.AddDate(0, 0, -1)
+	}
+	return time.Date(anio, time.December, 15, 0, 0, 0, 0, time.Local),
+		time.Date(anio, time.December, 31, 0, 0, 0, 0, time.Local)
+}
+
+// Temporada indica en qué temporada cae la fecha, o "" si en ninguna
+func Temporada(fecha time.Time) string {
+	for _, t := range []string{SemanaSanta, Fiestas} {
+		desde, hasta := RangoTemporada(t, fecha.Year())
+		if !fecha.Before(desde) && !fecha.After(hasta) {
+			return t
+		}
+	}
+	return ""
+}
+
+// feriadosFijos son los feriados nacionales inamovibles (mes, día)
+var feriadosFijos = [][2]int{
+	{1, 1}, {3, 24}, {4, 2}, {5, 1}, {5, 25}, {6, 20}, {7, 9}, {12, 8}, {12, 25},
+}
+
+// EsFeriado indica si la fecha es feriado nacional: los fijos, Carnaval y
+// Viernes Santo. Los trasladables cambian por decreto cada año y no se incluyen.
+func EsFeriado(fecha time.Time) bool {
+	for _, f := range feriadosFijos {
+		if int(fecha.Month()) == f[0] && fecha.Day() == f[1] {
+			return true
+		}
+	}
+	pascua := DomingoDePascua(fecha.Year())
+	for _, dias := range []int{-48, -47, -2} { // lunes y martes de Carnaval, Viernes Santo
+		if mismoDia(fecha, pascua.AddDate(0, 0, dias)) {
+			return true
+		}
+	}
+	return false
+}
+
+func mismoDia(a, b time.Time) bool {
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
+
+// dia normaliza una fecha a las 00:00 locales
+func dia(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
@@ -0,0 +1,83 @@
+package pronostico
+
+import (
+	"testing"
+	"time"
+)
+
+func fecha(s string) time.Time {
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestDomingoDePascua(t *testing.T) {
+	casos := []struct {
+		anio int
+		want string
+	}{
+		{1961, "1961-04-02"},
+		{2000, "2000-04-23"},
+		{2008, "2008-03-23"},
+		{2019, "2019-04-21"},
+		{2023, "2023-04-09"},
+		{2024, "2024-03-31"},
+		{2025, "2025-04-20"},
+		{2026, "2026-04-05"},
+		{2038, "2038-04-25"},
+	}
+	for _, c := range casos {
+		if got := DomingoDePascua(c.anio).Format("2006-01-02"); got != c.want {
+			t.Errorf("DomingoDePascua(%d) = %s, want %s", c.anio, got, c.want)
+		}
+	}
+}
+
+func TestEsFeriado(t *testing.T) {
+	casos := []struct {
+		fecha string
+		want  bool
+	}{
+		{"2024-01-01", true},  // Año nuevo
+		{"2024-03-24", true},  // Día de la Memoria
+		{"2024-07-09", true},  // Independencia
+		{"2024-12-25", true},  // Navidad
+		{"2024-02-12", true},  // lunes de Carnaval
+		{"2024-02-13", true},  // martes de Carnaval
+		{"2024-03-29", true},  // Viernes Santo
+		{"2025-04-18", true},  // Viernes Santo
+		{"2025-03-03", true},  // lunes de Carnaval
+		{"2024-03-28", false}, // Jueves Santo
+		{"2024-02-14", false}, // miércoles de Ceniza
+		{"2024-07-10", false},
+		{"2024-11-18", false}, // trasladable: no se incluye
+	}
+	for _, c := range casos {
+		if got := EsFeriado(fecha(c.fecha)); got != c.want {
+			t.Errorf("EsFeriado(%s) = %v, want %v", c.fecha, got, c.want)
+		}
+	}
+}
+
+func TestTemporada(t *testing.T) {
+	casos := []struct {
+		fecha string
+		want  string
+	}{
+		{"2024-03-24", SemanaSanta}, // Domingo de Ramos
+		{"2024-03-30", SemanaSanta}, // Sábado Santo
+		{"2024-03-31", ""},          // Pascua
+		{"2024-03-23", ""},
+		{"2024-12-15", Fiestas},
+		{"2024-12-31", Fiestas},
+		{"2024-12-14", ""},
+		{"2024-06-10", ""},
+	}
+	for _, c := range casos {
+		if got := Temporada(fecha(c.fecha)); got != c.want {
+			t.Errorf("Temporada(%s) = %q, want %q", c.fecha, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+package pronostico
+
+import (
+	"math"
+	"time"
+)
+
+// Historial son las ventas por día ("2006-01-02"); los días sin ventas no
+// figuran y cuentan como cero
+type Historial map[string]float64
+
+// Venta devuelve lo vendido en la fecha
+func (h Historial) Venta(fecha time.Time) float64 {
+	return h[fecha.Format("2006-01-02")]
+}
+
+// Modelo es un nivel de venta diaria corregido por factores multiplicativos
+type Modelo struct {
+	Nivel           float64            `json:"nivel"`      // venta diaria de un día común
+	FactorDia       [7]float64         `json:"factor_dia"` // por día de la semana, domingo = 0
+	FactorTemporada map[string]float64 `json:"factor_temporada"`
+	FactorFeriado   float64            `json:"factor_feriado"`
+}
+
+// diaComun indica si la fecha no está afectada por temporada ni feriado
+func diaComun(fecha time.Time) bool {
+	return Temporada(fecha) == "" && !EsFeriado(fecha)
+}
+
+// Ajustar estima el modelo con las `semanas` que terminan en `hasta`
+// (inclusive). Las temporadas y feriados se miden con el último año de
+// historia; nunca se usan datos posteriores a `hasta`.
+func Ajustar(h Historial, hasta time.Time, semanas int) Modelo {
+	hasta = dia(hasta)
+	desde := hasta.AddDate(0, 0, -7*semanas+1)
+	m := Modelo{FactorTemporada: map[string]float64{}, FactorFeriado: 1}
+
+	var suma float64
+	var dias int
+	var sumaDia [7]float64
+	var diasDia [7]int
+	for d := desde; !d.After(hasta); d = d.AddDate(0, 0, 1) {
+		if !diaComun(d) {
+			continue
+		}
+		v := h.Venta(d)
+		suma += v
+		dias++
+		sumaDia[d.Weekday()] += v
+		diasDia[d.Weekday()]++
+	}
+	if dias > 0 {
+		m.Nivel = suma / float64(dias)
+	}
+	for w := range m.FactorDia {
+		m.FactorDia[w] = 1
+		if m.Nivel > 0 && diasDia[w] > 0 {
+			m.FactorDia[w] = sumaDia[w] / float64(diasDia[w]) / m.Nivel
+		}
+	}
+
+	// Temporadas: lo vendido contra lo esperado según las 4 semanas previas
+	for _, t := range []string{SemanaSanta, Fiestas} {
+		var real, esperado float64
+		for anio := hasta.Year() - 1; anio <= hasta.Year(); anio++ {
+			ini, fin := RangoTemporada(t, anio)
+			if fin.After(hasta) {
+				continue
+			}
+			base := 0.0
+			for d := ini.AddDate(0, 0, -28); d.Before(ini); d = d.AddDate(0, 0, 1) {
+				base += h.Venta(d)
+			}
+			base /= 28
+			for d := ini; !d.After(fin); d = d.AddDate(0, 0, 1) {
+				real += h.Venta(d)
+				esperado += base * m.FactorDia[d.Weekday()]
+			}
+		}
+		m.FactorTemporada[t] = 1
+		if esperado > 0 {
+			m.FactorTemporada[t] = real / esperado
+		}
+	}
+
+	// Feriados fuera de temporada del último año
+	var real, esperado float64
+	for d := hasta.AddDate(-1, 0, 0); !d.After(hasta); d = d.AddDate(0, 0, 1) {
+		if EsFeriado(d) && Temporada(d) == "" {
+			real += h.Venta(d)
+			esperado += m.Nivel * m.FactorDia[d.Weekday()]
+		}
+	}
+	if esperado > 0 {
+		m.FactorFeriado = real / esperado
+	}
+	return m
+}
+
+// Pronosticar estima la venta de un día
+func (m Modelo) Pronosticar(fecha time.Time) float64 {
+	v := m.Nivel * m.FactorDia[fecha.Weekday()]
+	if t := Temporada(fecha); t != "" {
+		if f, ok := m.FactorTemporada[t]; ok {
+			v *= f
+		}
+	} else if EsFeriado(fecha) {
+		v *= m.FactorFeriado
+	}
+	return v
+}
+
+// Evaluacion compara lo pronosticado con lo que efectivamente se vendió
+type Evaluacion struct {
+	Dias         int     `json:"dias"`
+	Real         float64 `json:"real"`
+	Pronosticado float64 `json:"pronosticado"`
+	MAE          float64 `json:"mae"`         // error absoluto medio por día
+	WAPE         float64 `json:"wape"`        // error absoluto total / venta real, en %
+	Sesgo        float64 `json:"sesgo"`       // (pronosticado − real) / real, en %: positivo = sobrecompra
+	MAEIngenuo   float64 `json:"mae_ingenuo"` // MAE de repetir lo del mismo día de la semana anterior
+}
+
+// Sumar acumula otra evaluación (por ejemplo, para el total de todos los productos)
+func (e *Evaluacion) Sumar(o Evaluacion) {
+	e.Dias += o.Dias
+	e.Real += o.Real
+	e.Pronosticado += o.Pronosticado
+	e.MAE += o.MAE * float64(o.Dias)
+	e.MAEIngenuo += o.MAEIngenuo * float64(o.Dias)
+}
+
+// Cerrar convierte los acumulados de Sumar en promedios y porcentajes
+func (e *Evaluacion) Cerrar() {
+	if e.Dias > 0 {
+		e.MAE /= float64(e.Dias)
+		e.MAEIngenuo /= float64(e.Dias)
+	}
+	e.calcularPorcentajes(e.MAE * float64(e.Dias))
+}
+
+func (e *Evaluacion) calcularPorcentajes(errorTotal float64) {
+	e.WAPE, e.Sesgo = 0, 0
+	if e.Real > 0 {
+		e.WAPE = redondear(errorTotal * 100 / e.Real)
+		e.Sesgo = redondear((e.Pronosticado - e.Real) * 100 / e.Real)
+	}
+	e.Real = redondear(e.Real)
+	e.Pronosticado = redondear(e.Pronosticado)
+	e.MAE = redondear(e.MAE)
+	e.MAEIngenuo = redondear(e.MAEIngenuo)
+}
+
+// Backtest recorre el período semana por semana: ajusta el modelo sólo con la
+// historia anterior a cada semana, la pronostica y la compara con lo vendido
+func Backtest(h Historial, desde, hasta time.Time, semanas int) Evaluacion {
+	desde, hasta = dia(desde), dia(hasta)
+	var e Evaluacion
+	var errorTotal, errorIngenuo float64
+	for inicio := desde; !inicio.After(hasta); inicio = inicio.AddDate(0, 0, 7) {
+		m := Ajustar(h, inicio.AddDate(0, 0, -1), semanas)
+		for d := inicio; d.Before(inicio.AddDate(0, 0, 7)) && !d.After(hasta); d = d.AddDate(0, 0, 1) {
+			real := h.Venta(d)
+			p := m.Pronosticar(d)
+			e.Dias++
+			e.Real += real
+			e.Pronosticado += p
+			errorTotal += math.Abs(p - real)
+			errorIngenuo += math.Abs(h.Venta(d.AddDate(0, 0, -7)) - real)
+		}
+	}
+	if e.Dias > 0 {
+		e.MAE = errorTotal / float64(e.Dias)
+		e.MAEIngenuo = errorIngenuo / float64(e.Dias)
+	}
+	e.calcularPorcentajes(errorTotal)
+	return e
+}
+
+func redondear(x float64) float64 {
+	return math.Round(x*100) / 100
+}
@@ -0,0 +1,179 @@
+package pronostico
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// semanal vende 10 de lunes a viernes, 20 los sábados y nada los domingos:
+// el nivel es 10 y los factores 1, 2 y 0
+var semanal = [7]float64{0, 10, 10, 10, 10, 10, 20}
+
+// historia arma el historial del patrón semanal entre las fechas, con el
+// multiplicador que devuelva ajuste para cada día
+func historia(desde, hasta string, ajuste func(time.Time) float64) Historial {
+	h := Historial{}
+	for d := fecha(desde); !d.After(fecha(hasta)); d = d.AddDate(0, 0, 1) {
+		v := semanal[d.Weekday()]
+		if ajuste != nil {
+			v *= ajuste(d)
+		}
+		if v != 0 {
+			h[d.Format("2006-01-02")] = v
+		}
+	}
+	return h
+}
+
+func cerca(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestAjustarFactorDia(t *testing.T) {
+	h := historia("2022-01-01", "2023-11-30", nil)
+	m := Ajustar(h, fecha("2023-11-30"), 8)
+
+	if !cerca(m.Nivel, 10) {
+		t.Fatalf("Nivel = %v, want 10", m.Nivel)
+	}
+	want := [7]float64{0, 1, 1, 1, 1, 1, 2}
+	for w, f := range want {
+		if !cerca(m.FactorDia[w], f) {
+			t.Errorf("FactorDia[%v] = %v, want %v", time.Weekday(w), m.FactorDia[w], f)
+		}
+	}
+	for _, tc := range []struct {
+		fecha string
+		want  float64
+	}{
+		{"2023-12-02", 20}, // sábado común
+		{"2023-12-04", 10}, // lunes común
+		{"2023-12-03", 0},  // domingo
+	} {
+		if got := m.Pronosticar(fecha(tc.fecha)); !cerca(got, tc.want) {
+			t.Errorf("Pronosticar(%s) = %v, want %v", tc.fecha, got, tc.want)
+		}
+	}
+}
+
+func TestAjustarSinHistoria(t *testing.T) {
+	m := Ajustar(Historial{}, fecha("2023-11-30"), 4)
+	if m.Nivel != 0 || m.FactorFeriado != 1 {
+		t.Fatalf("modelo vacío = %+v", m)
+	}
+	for w, f := range m.FactorDia {
+		if f != 1 {
+			t.Errorf("FactorDia[%d] = %v, want 1", w, f)
+		}
+	}
+	if got := m.Pronosticar(fecha("2023-12-20")); got != 0 {
+		t.Errorf("Pronosticar = %v, want 0", got)
+	}
+}
+
+func TestAjustarFactorTemporada(t *testing.T) {
+	// En las Fiestas de 2023 se vende el doble
+	h := historia("2022-01-01", "2024-01-31", func(d time.Time) float64 {
+		if Temporada(d) == Fiestas && d.Year() == 2023 {
+			return 2
+		}
+		return 1
+	})
+	m := Ajustar(h, fecha("2024-01-31"), 4)
+
+	if got := m.FactorTemporada[Fiestas]; !cerca(got, 2) {
+		t.Errorf("FactorTemporada[Fiestas] = %v, want 2", got)
+	}
+	if got := m.FactorTemporada[SemanaSanta]; !cerca(got, 1) {
+		t.Errorf("FactorTemporada[SemanaSanta] = %v, want 1", got)
+	}
+	// Sábado 21/12/2024: 20 de un sábado común por 2
+	if got := m.Pronosticar(fecha("2024-12-21")); !cerca(got, 40) {
+		t.Errorf("Pronosticar(2024-12-21) = %v, want 40", got)
+	}
+}
+
+func TestAjustarNoUsaDatosFuturos(t *testing.T) {
+	// Las Fiestas del año que termina después de `hasta` no se miden
+	h := historia("2022-01-01", "2023-12-31", func(d time.Time) float64 {
+		if Temporada(d) == Fiestas && d.Year() == 2023 {
+			return 3
+		}
+		return 1
+	})
+	m := Ajustar(h, fecha("2023-12-20"), 4)
+	if got := m.FactorTemporada[Fiestas]; !cerca(got, 1) {
+		t.Errorf("FactorTemporada[Fiestas] = %v, want 1 (sólo 2022)", got)
+	}
+}
+
+func TestAjustarFactorFeriado(t *testing.T) {
+	// Los feriados se vende la mitad
+	h := historia("2022-01-01", "2023-11-30", func(d time.Time) float64 {
+		if EsFeriado(d) {
+			return 0.5
+		}
+		return 1
+	})
+	m := Ajustar(h, fecha("2023-11-30"), 8)
+
+	if !cerca(m.FactorFeriado, 0.5) {
+		t.Errorf("FactorFeriado = %v, want 0.5", m.FactorFeriado)
+	}
+	// Viernes 8/12/2023, feriado fuera de temporada
+	if got := m.Pronosticar(fecha("2023-12-08")); !cerca(got, 5) {
+		t.Errorf("Pronosticar(2023-12-08) = %v, want 5", got)
+	}
+}
+
+func TestBacktest(t *testing.T) {
+	casos := []struct {
+		nombre       string
+		ajuste       func(time.Time) float64
+		desde, hasta string
+		want         Evaluacion
+	}{
+		{
+			nombre: "patrón estable",
+			desde:  "2023-10-02", hasta: "2023-11-26",
+			want: Evaluacion{Dias: 56, Real: 560, Pronosticado: 560},
+		},
+		{
+			nombre: "semana con el doble de ventas",
+			ajuste: func(d time.Time) float64 {
+				if !d.Before(fecha("2023-11-06")) && !d.After(fecha("2023-11-12")) {
+					return 2
+				}
+				return 1
+			},
+			desde: "2023-11-06", hasta: "2023-11-12",
+			want: Evaluacion{Dias: 7, Real: 140, Pronosticado: 70, MAE: 10, WAPE: 50, Sesgo: -50, MAEIngenuo: 10},
+		},
+		{
+			nombre: "período parcial",
+			desde:  "2023-11-06", hasta: "2023-11-08",
+			want: Evaluacion{Dias: 3, Real: 30, Pronosticado: 30},
+		},
+	}
+	for _, c := range casos {
+		t.Run(c.nombre, func(t *testing.T) {
+			h := historia("2023-01-01", "2023-11-30", c.ajuste)
+			if got := Backtest(h, fecha(c.desde), fecha(c.hasta), 4); got != c.want {
+				t.Errorf("Backtest = %+v, want %+v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestEvaluacionSumar(t *testing.T) {
+	var total Evaluacion
+	total.Sumar(Evaluacion{Dias: 7, Real: 100, Pronosticado: 90, MAE: 2, MAEIngenuo: 4})
+	total.Sumar(Evaluacion{Dias: 7, Real: 100, Pronosticado: 130, MAE: 6, MAEIngenuo: 4})
+	total.Cerrar()
+
+	want := Evaluacion{Dias: 14, Real: 200, Pronosticado: 220, MAE: 4, WAPE: 28, Sesgo: 10, MAEIngenuo: 4}
+	if total != want {
+		t.Errorf("total = %+v, want %+v", total, want)
+	}
+}
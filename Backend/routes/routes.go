@@ -83,16 +83,36 @@ func SetupRoutes(app *fiber.App) {
 	authProtected := app.Group("/api/auth").Use(AuthMiddleware)
 	authProtected.Get("/profile", controller.GetProfile)
 
+	// =========================
+	// DASHBOARD (protegidas)
+	// =========================
+	dashboard := app.Group("/api/dashboard").Use(AuthMiddleware)
+	dashboard.Get("/", controller.GetDashboard)
+
 	// =========================
 	// PRODUCTOS (protegidas)
 	// =========================
 	productos := app.Group("/api/productos").Use(AuthMiddleware)
 	productos.Post("/", controller.CreateProduct)
 	productos.Get("/", controller.GetProducts)
+	productos.Post("/importar", controller.ImportProducts)
+	productos.Get("/pdf", controller.GetProductsPDF)
+	productos.Get("/stock-bajo", controller.GetLowStock)
+	productos.Get("/stock-a-fecha", controller.GetStockAtDate)
+	productos.Get("/escanear", controller.ScanCode)
 	productos.Get("/codigo/:codigo", controller.GetProductByCodigo)
 	productos.Get("/:id", controller.GetProductByID)
 	productos.Get("/:id/movimientos", controller.GetMovementsByProductID) // ✨ NUEVA RUTA
+	productos.Get("/:id/kardex", controller.GetKardex)
+	productos.Get("/:id/conversiones", controller.GetUnitConversions)
+	productos.Put("/:id/conversiones", controller.SetUnitConversions)
+	productos.Get("/:id/codigos-barra", controller.GetProductBarcodes)
+	productos.Get("/:id/etiquetas", controller.GetProductLabels)
+	productos.Put("/:id/codigos-barra", controller.SetProductBarcodes)
 	productos.Put("/:id", controller.UpdateProduct)
+	productos.Post("/:id/archivar", controller.ArchiveProduct)
+	productos.Post("/:id/restaurar", controller.RestoreProduct)
+	productos.Post("/:id/fusionar", controller.MergeProducts)
 	productos.Delete("/:id", controller.DeleteProduct)
 
 	// =========================
@@ -102,7 +122,10 @@ func SetupRoutes(app *fiber.App) {
 	// Rutas fijas primero
 	movimientos.Post("/entrada", controller.CreateInMovement)
 	movimientos.Post("/salida", controller.CreateOutMovement)
+	movimientos.Post("/salida/ticket", controller.CreateSaleTicket)
+	movimientos.Post("/salida/escaneo", controller.CreateScanOutMovement)
 	movimientos.Get("/", controller.GetMovements)
+	movimientos.Get("/comprobante/:numero", controller.GetMovementsByDocument)
 
 	// ✨ ACTUALIZADO: Solo anula si Estado = true
 	movimientos.Put("/:id/cancelar", controller.CancelMovement)
@@ -115,4 +138,123 @@ func SetupRoutes(app *fiber.App) {
 
 	// Paramétrica al final
 	movimientos.Get("/:id", controller.GetMovementByID)
+
+	// =========================
+	// CLIENTES Y PROVEEDORES (protegidas)
+	// =========================
+	clientes := app.Group("/api/clientes").Use(AuthMiddleware)
+	clientes.Post("/", controller.CreateCustomer)
+	clientes.Get("/", controller.GetCustomers)
+	clientes.Get("/:id", controller.GetCustomerByID)
+	clientes.Put("/:id", controller.UpdateCustomer)
+
+	proveedores := app.Group("/api/proveedores").Use(AuthMiddleware)
+	proveedores.Post("/", controller.CreateSupplier)
+	proveedores.Get("/", controller.GetSuppliers)
+	proveedores.Get("/:id", controller.GetSupplierByID)
+	proveedores.Put("/:id", controller.UpdateSupplier)
+
+	// =========================
+	// CATÁLOGO (protegidas)
+	// =========================
+	especies := app.Group("/api/especies").Use(AuthMiddleware)
+	especies.Post("/", controller.CreateSpecies)
+	especies.Get("/", controller.GetSpecies)
+	especies.Get("/:id", controller.GetSpeciesByID)
+	especies.Put("/:id", controller.UpdateSpecies)
+
+	etiquetas := app.Group("/api/etiquetas").Use(AuthMiddleware)
+	etiquetas.Post("/plantillas", controller.CreateLabelTemplate)
+	etiquetas.Get("/plantillas", controller.GetLabelTemplates)
+	etiquetas.Put("/plantillas/:id", controller.UpdateLabelTemplate)
+
+	// =========================
+	// PEDIDOS (protegidas)
+	// =========================
+	pedidos := app.Group("/api/pedidos").Use(AuthMiddleware)
+	pedidos.Post("/", controller.CreateCustomerOrder)
+	pedidos.Get("/", controller.GetCustomerOrders)
+	pedidos.Put("/:id/estado", controller.UpdateCustomerOrderStatus)
+	pedidos.Get("/:id", controller.GetCustomerOrderByID)
+
+	// =========================
+	// ÓRDENES DE COMPRA (protegidas)
+	// =========================
+	ordenes := app.Group("/api/ordenes-compra").Use(AuthMiddleware)
+	ordenes.Post("/", controller.CreatePurchaseOrder)
+	ordenes.Get("/", controller.GetPurchaseOrders)
+	ordenes.Post("/:id/recepciones", controller.ReceivePurchaseOrder)
+	ordenes.Put("/:id/recepciones/:recepcion_id/anular", controller.CancelPurchaseReceipt)
+	ordenes.Put("/:id/cerrar", controller.ClosePurchaseOrder)
+	ordenes.Put("/:id/anular", controller.CancelPurchaseOrder)
+	ordenes.Get("/:id", controller.GetPurchaseOrderByID)
+
+	// =========================
+	// TRANSFORMACIONES (protegidas)
+	// =========================
+	transformaciones := app.Group("/api/transformaciones").Use(AuthMiddleware)
+	transformaciones.Post("/", controller.CreateTransformation)
+	transformaciones.Get("/", controller.GetTransformations)
+	transformaciones.Put("/:id/anular", controller.CancelTransformation)
+	transformaciones.Get("/:id/etiquetas", controller.GetTransformationLabels)
+	transformaciones.Get("/:id", controller.GetTransformationByID)
+
+	// =========================
+	// AUDITORÍA (protegidas)
+	// =========================
+	auditoria := app.Group("/api/auditoria").Use(AuthMiddleware)
+	auditoria.Get("/", controller.GetAuditLogs)
+
+	// =========================
+	// NUMERADORES (protegidas)
+	// =========================
+	numeradores := app.Group("/api/numeradores").Use(AuthMiddleware)
+	numeradores.Get("/", controller.GetDocumentSequences)
+
+	// =========================
+	// FACTURAS (protegidas)
+	// =========================
+	facturas := app.Group("/api/facturas").Use(AuthMiddleware)
+	facturas.Post("/", controller.CreateInvoice)
+	facturas.Get("/", controller.GetInvoices)
+	facturas.Get("/afip/pendientes", controller.GetPendingAFIPInvoices)
+	facturas.Post("/:id/autorizar", controller.AuthorizeInvoice)
+	facturas.Post("/:id/nota-credito", controller.CreateCreditNote)
+	facturas.Get("/:id/pdf", controller.GetInvoicePDF)
+	facturas.Put("/:id/anular", controller.CancelInvoice)
+	facturas.Get("/:id", controller.GetInvoiceByID)
+
+	// =========================
+	// REMITOS (protegidas)
+	// =========================
+	remitos := app.Group("/api/remitos").Use(AuthMiddleware)
+	remitos.Post("/", controller.CreateDeliveryNote)
+	remitos.Get("/", controller.GetDeliveryNotes)
+	remitos.Post("/:id/facturar", controller.InvoiceDeliveryNote)
+	remitos.Put("/:id/anular", controller.CancelDeliveryNote)
+	remitos.Get("/:id/pdf", controller.GetDeliveryNotePDF)
+	remitos.Get("/:id", controller.GetDeliveryNoteByID)
+
+	// =========================
+	// EXPORTACIONES (protegidas)
+	// =========================
+	exportar := app.Group("/api/exportar").Use(AuthMiddleware)
+	exportar.Get("/productos", controller.ExportProducts)
+	exportar.Get("/movimientos", controller.ExportMovements)
+	exportar.Get("/kardex/:id", controller.ExportKardex)
+
+	// =========================
+	// REPORTES (protegidas)
+	// =========================
+	reportes := app.Group("/api/reportes").Use(AuthMiddleware)
+	reportes.Get("/libro-iva/:libro", controller.GetLibroIVA)
+	reportes.Get("/rendimiento", controller.GetYieldReport)
+	reportes.Get("/reposicion", controller.GetReorderSuggestions)
+	reportes.Get("/cierre-diario", controller.GetDailyClosing)
+	reportes.Get("/ventas/top", controller.GetTopProducts)
+	reportes.Get("/ventas/rotacion", controller.GetStockRotation)
+	reportes.Get("/ventas/semanal", controller.GetWeeklySales)
+	reportes.Get("/ventas/estacionalidad", controller.GetSeasonality)
+	reportes.Get("/pronostico", controller.GetForecast)
+	reportes.Get("/pronostico/backtest", controller.GetForecastBacktest)
 }
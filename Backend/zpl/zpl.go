@@ -0,0 +1,163 @@
+// Package zpl arma las etiquetas en ZPL para imprimir directo en las
+// impresoras Zebra, a partir de una plantilla con los datos de la etiqueta.
+package zpl
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"sanJoseProyect/models"
+)
+
+// PlantillaPorDefecto es el diseño estándar, pensado para 60 × 40 mm a 203 dpi
+const PlantillaPorDefecto = `^XA
+^CI28
+^PW{{.Ancho}}
+^LL{{.Alto}}
+^FO20,15^A0N,30,30^FB440,2,0,L^FD{{texto .Descripcion}}^FS
+{{- if .Especie}}
+^FO20,78^A0N,20,20^FD{{texto .Especie}}{{if .NombreCientifico}} ({{texto .NombreCientifico}}){{end}}^FS
+{{- end}}
+{{- if .ZonaCaptura}}
+^FO20,100^A0N,18,18^FD{{texto .ZonaCaptura}}{{if .Congelado}} - Congelado{{end}}^FS
+{{- end}}
+^FO20,125^A0N,30,30^FD{{cantidad .}}^FS
+{{- if .Importe}}
+^FO280,125^A0N,30,30^FD{{moneda .Importe}}^FS
+{{- end}}
+^FO20,160^A0N,20,20^FDLote {{texto .Lote}}  Env. {{fecha .Envasado}}{{if .Vencimiento}}  Vto. {{fecha .Vencimiento}}{{end}}^FS
+^FO60,190^BY2{{barra . 80}}
+^PQ{{.Copias}}
+^XZ
+`
+
+// datos es lo que recibe la plantilla: la etiqueta y el tamaño en puntos
+type datos struct {
+	models.Label
+	Ancho int
+	Alto  int
+}
+
+// PuntosPorMM es la resolución de la impresora (ETIQUETA_DPMM): 8 = 203 dpi, 12 = 300 dpi
+func PuntosPorMM() int {
+	if n, err := strconv.Atoi(os.Getenv("ETIQUETA_DPMM")); err == nil && n > 0 {
+		return n
+	}
+	return 8
+}
+
+var funciones = template.FuncMap{
+	"texto":    texto,
+	"fecha":    fecha,
+	"cantidad": cantidad,
+	"moneda":   func(x float64) string { return "$ " + numero(x, 2) },
+	"barra":    barra,
+	"mm":       func(mm float64) int { return int(math.Round(mm * float64(PuntosPorMM()))) },
+}
+
+// Generar arma el ZPL de todas las etiquetas con la plantilla (vacía = la estándar)
+func Generar(plantilla string, anchoMM, altoMM float64, etiquetas []models.Label) ([]byte, error) {
+	if strings.TrimSpace(plantilla) == "" {
+		plantilla = PlantillaPorDefecto
+	}
+	t, err := template.New("etiqueta").Funcs(funciones).Parse(plantilla)
+	if err != nil {
+		return nil, fmt.Errorf("plantilla inválida: %v", err)
+	}
+	ppm := float64(PuntosPorMM())
+	var buf bytes.Buffer
+	for _, e := range etiquetas {
+		if e.Copias < 1 {
+			e.Copias = 1
+		}
+		d := datos{Label: e, Ancho: int(math.Round(anchoMM * ppm)), Alto: int(math.Round(altoMM * ppm))}
+		if err := t.Execute(&buf, d); err != nil {
+			return nil, fmt.Errorf("plantilla inválida: %v", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// Validar prueba la plantilla con una etiqueta de ejemplo, para rechazarla al guardarla
+func Validar(plantilla string) error {
+	vto := time.Now().AddDate(0, 0, 3)
+	_, err := Generar(plantilla, 60, 40, []models.Label{{
+		Codigo:      1,
+		Descripcion: "Filet de merluza",
+		Especie:     "Merluza",
+		Cantidad:    0.5,
+		Unidad:      models.UnidadKg,
+		Importe:     1000,
+		Lote:        "T000001",
+		Envasado:    time.Now(),
+		Vencimiento: &vto,
+		CodigoBarra: "2000420005008",
+		TipoBarra:   models.BarraEAN13,
+	}})
+	return err
+}
+
+// texto saca los caracteres de control de ZPL (^ y ~) de un dato
+func texto(s string) string {
+	return strings.NewReplacer("^", " ", "~", " ").Replace(s)
+}
+
+// fecha acepta time.Time o *time.Time y devuelve dd/mm/aaaa
+func fecha(v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format("02/01/2006")
+	case *time.Time:
+		if t != nil {
+			return t.Format("02/01/2006")
+		}
+	}
+	return ""
+}
+
+// cantidad muestra el peso con gramos o las unidades enteras
+func cantidad(e datos) string {
+	if models.NormalizarUnidad(e.Unidad) == models.UnidadKg {
+		return numero(e.Cantidad, 3) + " kg"
+	}
+	return numero(e.Cantidad, 0) + " " + e.Unidad
+}
+
+// barra es el comando del código de barras con su alto en puntos y los números abajo
+func barra(e datos, alto int) string {
+	if e.TipoBarra == models.BarraEAN13 && len(e.CodigoBarra) == 13 {
+		// ^BE calcula el dígito verificador: recibe los primeros 12
+		return fmt.Sprintf("^BEN,%d,Y,N^FD%s^FS", alto, e.CodigoBarra[:12])
+	}
+	return fmt.Sprintf("^BCN,%d,Y,N,N^FD%s^FS", alto, texto(e.CodigoBarra))
+}
+
+// numero formatea al estilo argentino: 1.234,56
+func numero(x float64, decimales int) string {
+	s := strconv.FormatFloat(math.Abs(x), 'f', decimales, 64)
+	entera, fraccion := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		entera, fraccion = s[:i], s[i+1:]
+	}
+	var b strings.Builder
+	if x < 0 {
+		b.WriteByte('-')
+	}
+	for i, r := range entera {
+		if i > 0 && (len(entera)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(r)
+	}
+	if fraccion != "" {
+		b.WriteByte(',')
+		b.WriteString(fraccion)
+	}
+	return b.String()
+}